@@ -2,15 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/control"
+	"github.com/jvreagan/perf-test/internal/curl"
+	"github.com/jvreagan/perf-test/internal/data"
+	"github.com/jvreagan/perf-test/internal/debug"
 	"github.com/jvreagan/perf-test/internal/engine"
+	"github.com/jvreagan/perf-test/internal/importer"
+	"github.com/jvreagan/perf-test/internal/mock"
+	"github.com/jvreagan/perf-test/internal/recorder"
+	"github.com/jvreagan/perf-test/internal/replay"
+	"github.com/jvreagan/perf-test/internal/reporter"
+	"github.com/jvreagan/perf-test/internal/scaffold"
+	"github.com/jvreagan/perf-test/internal/scheduler"
 )
 
 var version = "0.1.0"
@@ -24,7 +42,7 @@ It supports weighted multi-endpoint tests, stage-based load ramp profiles,
 data templating, and periodic stats output.`,
 	}
 
-	root.AddCommand(runCmd(), validateCmd(), versionCmd())
+	root.AddCommand(initCmd(), runCmd(), debugCmd(), validateCmd(), planCmd(), replayCmd(), recordCmd(), mockCmd(), importCmd(), exportCmd(), schemaCmd(), ctlCmd(), versionCmd())
 
 	if err := root.Execute(); err != nil {
 		os.Exit(1)
@@ -32,7 +50,11 @@ data templating, and periodic stats output.`,
 }
 
 func runCmd() *cobra.Command {
-	return &cobra.Command{
+	var controlSocket string
+	var dryRun int
+	var overrides overrideFlags
+
+	cmd := &cobra.Command{
 		Use:   "run [config.yaml]",
 		Short: "Run a load test",
 		Args:  cobra.MaximumNArgs(1),
@@ -42,10 +64,14 @@ func runCmd() *cobra.Command {
 				path = args[0]
 			}
 
-			cfg, err := config.Load(path)
+			cfg, err := overrides.load(path)
 			if err != nil {
 				return fmt.Errorf("loading config: %w", err)
 			}
+			if dryRun > 0 {
+				debug.DryRun(os.Stdout, cfg, dryRun, debug.Options{})
+				return nil
+			}
 
 			fmt.Printf("Starting load test: %s\n", cfg.Name)
 			if cfg.Description != "" {
@@ -56,27 +82,226 @@ func runCmd() *cobra.Command {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
-			// Handle OS signals for graceful shutdown
-			sigCh := make(chan os.Signal, 1)
+			// Handle OS signals for graceful shutdown; a second signal exits immediately.
+			sigCh := make(chan os.Signal, 2)
 			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 			go func() {
 				<-sigCh
-				fmt.Println("\nShutting down gracefully...")
+				if grace := cfg.Load.GracefulStop.Duration; grace > 0 {
+					fmt.Printf("\nShutting down gracefully (waiting up to %s for in-flight requests; interrupt again to force)...\n", grace)
+				} else {
+					fmt.Println("\nShutting down gracefully...")
+				}
 				cancel()
+				<-sigCh
+				fmt.Fprintln(os.Stderr, "Forced exit")
+				os.Exit(130)
 			}()
 
 			eng := engine.New(cfg)
+
+			if controlSocket != "" {
+				ln, err := control.Listen(controlSocket)
+				if err != nil {
+					return fmt.Errorf("opening control socket: %w", err)
+				}
+				defer os.Remove(controlSocket)
+				go control.Serve(ctx, ln, eng)
+				fmt.Printf("  Control socket: %s\n\n", controlSocket)
+			}
+
 			if _, err := eng.Run(ctx, os.Stdout); err != nil {
-				fmt.Fprintf(os.Stderr, "Test completed with failures: %v\n", err)
+				return reportFailure(cmd, "Test", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&controlSocket, "control-socket", "", "Unix socket path for runtime control (see 'perf-test ctl')")
+	cmd.Flags().IntVar(&dryRun, "dry-run", 0, "print this many rendered requests (default 5) instead of running the test")
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = "5"
+	overrides.register(cmd)
+	return cmd
+}
+
+func debugCmd() *cobra.Command {
+	var opts debug.Options
+	var overrides overrideFlags
+
+	cmd := &cobra.Command{
+		Use:   "debug [config.yaml]",
+		Short: "Send each endpoint once and dump requests and responses",
+		Long: `Send one request to every endpoint, in config order, and print the full
+request and response (headers and body), phase timings (DNS, connect, TLS,
+time to first byte, transfer) and whether the endpoint's expectations held.
+
+Credentials are redacted: values of headers and variables whose names look
+secret (Authorization, Cookie, *token*, *password*, *api_key*, ...). Use
+--show-secrets to print them. Exits non-zero if any endpoint fails.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "perf-test.yaml"
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			cfg, err := overrides.load(path)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer cancel()
+			failed, err := debug.Run(ctx, os.Stdout, cfg, opts)
+			if err != nil {
+				return err
+			}
+			if failed > 0 {
 				os.Exit(1)
 			}
 			return nil
 		},
 	}
+
+	cmd.Flags().StringArrayVarP(&opts.Endpoints, "endpoint", "e", nil, "only send the endpoint with this name, repeatable")
+	cmd.Flags().BoolVar(&opts.ShowSecrets, "show-secrets", false, "print credentials instead of redacting them")
+	cmd.Flags().IntVar(&opts.MaxBody, "max-body", 4096, "bytes of each body to print (-1 for all)")
+	overrides.register(cmd)
+	return cmd
+}
+
+func ctlCmd() *cobra.Command {
+	var socket string
+
+	cmd := &cobra.Command{
+		Use:   "ctl <pause|resume|status|skip|target N|extend DURATION>",
+		Short: "Control a running test via its control socket",
+		Long: `Send a command to a test started with 'perf-test run --control-socket PATH'.
+
+  pause            idle all VUs / stop the arrival dispatcher
+  resume           continue after pause
+  target N         override the current VU or RPS target until the stage ends
+  skip             jump to the next stage
+  extend DURATION  lengthen the current stage (e.g. 30s)
+  status           print the current control state`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := control.Send(socket, strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+			st := resp.State
+			if !resp.OK {
+				return fmt.Errorf("%s", resp.Error)
+			}
+			if !st.Running {
+				fmt.Println("Test is not running")
+				return nil
+			}
+			status := "running"
+			if st.Paused {
+				status = "paused"
+			}
+			fmt.Printf("Status: %s  Target: %d", status, st.Target)
+			if st.Override {
+				fmt.Print(" (override)")
+			}
+			if !st.Adaptive {
+				fmt.Printf("  Stage: %d/%d  Elapsed: %s / %s", st.Stage+1, st.Stages,
+					st.Elapsed.Round(time.Second), st.Total.Round(time.Second))
+			}
+			fmt.Println()
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "perf-test.sock", "path to the control socket")
+	return cmd
+}
+
+func initCmd() *cobra.Command {
+	var opts scaffold.Options
+	var endpoints []string
+	var force, list bool
+
+	cmd := &cobra.Command{
+		Use:   "init [perf-test.yaml]",
+		Short: "Create a new config file",
+		Long: `Create a commented starter config. With no --template, asks for the target
+URL, endpoints, load style and output settings. With --template, builds the
+config from the template and flags without asking. Writes perf-test.yaml
+unless another path is given ("-" for stdout).
+
+Templates: ` + strings.Join(scaffold.TemplateNames(), ", ") + ` (see --list).`,
+		Example: `  perf-test init
+  perf-test init -t spike --url https://api.example.com -e "GET /products" -e "POST /cart 201"
+  perf-test init soak.yaml -t soak --target 100 --duration 4h`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if list {
+				for _, t := range scaffold.Templates {
+					fmt.Printf("  %-12s %-14s %s\n", t.Name, t.Style, t.Summary)
+				}
+				return nil
+			}
+			path := "perf-test.yaml"
+			if len(args) > 0 {
+				path = args[0]
+			}
+			if path != "-" && !force {
+				if _, err := os.Stat(path); err == nil {
+					return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+				}
+			}
+			for _, e := range endpoints {
+				ep, err := scaffold.ParseEndpoint(e)
+				if err != nil {
+					return err
+				}
+				opts.Endpoints = append(opts.Endpoints, ep)
+			}
+
+			opts.Command = "perf-test init"
+			if opts.Template == "" {
+				var err error
+				if opts, err = scaffold.Interactive(os.Stdin, os.Stderr, opts); err != nil {
+					return err
+				}
+			} else {
+				opts.Command += " --template " + opts.Template
+			}
+			out, err := scaffold.Render(opts)
+			if err != nil {
+				return err
+			}
+			if err := writeChecked(path, out); err != nil || path == "-" {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "\nWrote %s. Next steps:\n", path)
+			fmt.Fprintf(os.Stderr, "  perf-test debug %s   # send each endpoint once\n", path)
+			fmt.Fprintf(os.Stderr, "  perf-test plan %s    # preview the load curve\n", path)
+			fmt.Fprintf(os.Stderr, "  perf-test run %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Template, "template", "t", "", "build from this template without prompting")
+	cmd.Flags().StringVar(&opts.BaseURL, "url", "", "target base URL (default http://localhost:8080)")
+	cmd.Flags().StringArrayVarP(&endpoints, "endpoint", "e", nil, `endpoint as "[METHOD] /path [status]", repeatable (default "GET /")`)
+	cmd.Flags().StringVar(&opts.Name, "name", "", "test name")
+	cmd.Flags().IntVar(&opts.Target, "target", 0, "peak VUs, or RPS for arrival_rate templates (default from the template)")
+	cmd.Flags().DurationVar(&opts.Duration, "duration", 0, "length of the main phase (default from the template)")
+	cmd.Flags().StringVar(&opts.Format, "format", "", "output format: console, json or csv")
+	cmd.Flags().StringVar(&opts.File, "results", "", "results file for json and csv output")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "overwrite an existing file")
+	cmd.Flags().BoolVar(&list, "list", false, "list the templates and exit")
+	return cmd
 }
 
 func validateCmd() *cobra.Command {
-	return &cobra.Command{
+	var overrides overrideFlags
+
+	cmd := &cobra.Command{
 		Use:   "validate [config.yaml]",
 		Short: "Validate a config file",
 		Args:  cobra.MaximumNArgs(1),
@@ -86,11 +311,14 @@ func validateCmd() *cobra.Command {
 				path = args[0]
 			}
 
-			cfg, err := config.Load(path)
+			cfg, err := overrides.load(path)
 			if err != nil {
 				return fmt.Errorf("config is invalid: %w", err)
 			}
 
+			// Values from ${ENV} and --env-file are expanded by now; keep
+			// credentials out of CI logs.
+			cfg = debug.Redact(cfg)
 			fmt.Printf("Config is valid!\n")
 			fmt.Printf("  Name:      %s\n", cfg.Name)
 			fmt.Printf("  Duration:  %s\n", cfg.TotalDuration())
@@ -98,9 +326,630 @@ func validateCmd() *cobra.Command {
 			for _, ep := range cfg.Endpoints {
 				fmt.Printf("    - [weight:%d] %s %s\n", ep.Weight, ep.Method, ep.URL)
 			}
+
+			out, err := config.Marshal(cfg)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\nEffective config (defaults and overrides applied):\n\n%s", out)
+			return nil
+		},
+	}
+	overrides.register(cmd)
+	return cmd
+}
+
+func planCmd() *cobra.Command {
+	var format string
+	var step time.Duration
+	var overrides overrideFlags
+
+	cmd := &cobra.Command{
+		Use:   "plan [config.yaml]",
+		Short: "Show the load curve a config will follow, without sending traffic",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "perf-test.yaml"
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			cfg, err := overrides.load(path)
+			if err != nil {
+				return fmt.Errorf("config is invalid: %w", err)
+			}
+			if cfg.Load.Adaptive != nil {
+				return fmt.Errorf("adaptive load has no fixed curve to plan; the target depends on live latency")
+			}
+
+			if step <= 0 {
+				// Charts plot the peak of each column, so sample finely enough
+				// that short spikes still show up.
+				samples := time.Duration(60)
+				if format == "chart" {
+					samples = 600
+				}
+				step = max(cfg.TotalDuration()/samples, 100*time.Millisecond)
+			}
+			unit := "VUs"
+			if cfg.Load.Mode == "arrival_rate" {
+				unit = "RPS"
+			}
+			return reporter.Plan(os.Stdout, scheduler.Plan(cfg.Load.Stages, step), unit, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "chart", "output format: chart, table or csv")
+	cmd.Flags().DurationVar(&step, "step", 0, "sampling interval (default: 1/60 of the test for tables, 1/600 for charts)")
+	overrides.register(cmd)
+	return cmd
+}
+
+func replayCmd() *cobra.Command {
+	var (
+		opts      replay.Options
+		format    string
+		timeField string
+		headers   []string
+		timeout   time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay <access.log|->",
+		Short: "Replay requests from an access log against a base URL",
+		Long: `Re-issue the requests recorded in an access log, in order, against --base-url.
+
+Supported formats are nginx/Apache combined, Apache common (CLF) and JSON lines.
+Requests are sent at their original spacing, divided by --speedup. Lines without
+a timestamp are sent at --rate requests per second. Use "-" to read from stdin.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.BaseURL == "" {
+				return fmt.Errorf("--base-url is required")
+			}
+			in := os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("opening log: %w", err)
+				}
+				defer f.Close()
+				in = f
+			}
+			src, err := replay.NewReader(in, format, timeField)
+			if err != nil {
+				return err
+			}
+
+			opts.Headers = make(map[string]string)
+			for _, h := range headers {
+				k, v, ok := strings.Cut(h, ":")
+				if !ok {
+					return fmt.Errorf("invalid header %q (expected \"Name: value\")", h)
+				}
+				opts.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+			opts.HTTP.Timeout = config.Duration{Duration: timeout}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				<-sigCh
+				fmt.Println("\nStopping replay...")
+				cancel()
+			}()
+
+			fmt.Printf("Replaying %s against %s (speedup %gx)\n\n", args[0], opts.BaseURL, max(opts.Speedup, 1))
+			if _, err := replay.Run(ctx, src, opts, os.Stdout); err != nil {
+				return reportFailure(cmd, "Replay", err)
+			}
 			return nil
 		},
 	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.BaseURL, "base-url", "", "scheme and host to send requests to (required)")
+	f.StringVar(&format, "format", "auto", "log format: auto, combined, clf or json")
+	f.StringVar(&timeField, "time-field", "", "JSON key holding the request timestamp (default: time, timestamp, @timestamp, ...)")
+	f.Float64Var(&opts.Speedup, "speedup", 1, "compress the original timing by this factor (2 = twice as fast)")
+	f.Float64Var(&opts.Rate, "rate", 10, "requests per second for lines without a timestamp")
+	f.IntVar(&opts.Concurrency, "concurrency", 100, "maximum requests in flight")
+	f.BoolVar(&opts.MatchStatus, "match-status", false, "fail requests whose status differs from the logged status (default: only 5xx fail)")
+	f.StringArrayVarP(&headers, "header", "H", nil, "extra header for every request, e.g. -H 'Authorization: Bearer x'")
+	f.DurationVar(&timeout, "timeout", 30*time.Second, "per-request timeout")
+	f.BoolVar(&opts.HTTP.InsecureSkipVerify, "insecure", false, "skip TLS certificate verification")
+	f.BoolVar(&opts.HTTP.FollowRedirects, "follow-redirects", false, "follow HTTP redirects")
+	f.DurationVar(&opts.Interval, "interval", 5*time.Second, "periodic stats interval")
+	f.StringVar(&opts.OutputFile, "output", "", "write JSON results to this file")
+	return cmd
+}
+
+func recordCmd() *cobra.Command {
+	var opts importer.RecordOptions
+	var listen, output string
+	var quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "record --target URL",
+		Short: "Record traffic through a proxy and write it as a test config",
+		Long: `Run a reverse proxy on --listen that forwards to --target and records every
+request. Point a browser, a manual session or an integration test suite at the
+proxy, then press Ctrl+C to write the config.
+
+Identical requests are merged into endpoints weighted by how often they were
+seen. The median pause between a client's requests becomes load.think_time;
+pauses longer than --idle-gap are treated as breaks, not think time.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.BaseURL == "" {
+				return fmt.Errorf("--target is required")
+			}
+			rec, err := recorder.New(opts.BaseURL)
+			if err != nil {
+				return err
+			}
+			if !quiet {
+				rec.OnRecord = func(r importer.Recorded) {
+					fmt.Fprintf(os.Stderr, "  %s %s -> %d (%s)\n", r.Method, r.Path, r.Status, r.Duration.Round(time.Millisecond))
+				}
+			}
+
+			srv := &http.Server{Addr: listen, Handler: rec}
+			errCh := make(chan error, 1)
+			go func() { errCh <- srv.ListenAndServe() }()
+
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+			fmt.Fprintf(os.Stderr, "Recording on %s -> %s (Ctrl+C to stop and write the config)\n", listen, opts.BaseURL)
+			select {
+			case err := <-errCh:
+				return fmt.Errorf("proxy: %w", err)
+			case <-sigCh:
+			}
+			signal.Stop(sigCh)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+
+			reqs := rec.Requests()
+			cfg, err := importer.Recording(reqs, opts)
+			if err != nil {
+				return err
+			}
+			if err := writeConfig(cfg, output, "perf-test record --target "+opts.BaseURL); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Recorded %d requests as %d endpoints (think time %s)\n", len(reqs), len(cfg.Endpoints), cfg.Load.ThinkTime.Duration)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", ":9000", "address for the recording proxy")
+	cmd.Flags().StringVar(&opts.BaseURL, "target", "", "URL to forward requests to (required)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the config to this file (default: stdout)")
+	cmd.Flags().StringVar(&opts.Name, "name", "recorded", "test name")
+	cmd.Flags().DurationVar(&opts.IdleGap, "idle-gap", 30*time.Second, "pauses longer than this are not counted as think time")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "don't log each recorded request")
+	return cmd
+}
+
+func mockCmd() *cobra.Command {
+	var listen string
+
+	cmd := &cobra.Command{
+		Use:   "mock [mock.yaml]",
+		Short: "Serve a fake API to test against",
+		Long: `Serve a fake HTTP API described in YAML, with per-route status codes, bodies,
+latency distributions (fixed, normal, long_tail), injected errors and 429 rate
+limiting. Without a config file, a small demo API is served.
+
+See examples/mock.yaml for the format.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := mock.Default()
+			if len(args) > 0 {
+				var err error
+				if cfg, err = mock.Load(args[0]); err != nil {
+					return err
+				}
+			}
+			if listen != "" {
+				cfg.Listen = listen
+			}
+			cfg.ApplyDefaults()
+			srv, err := mock.New(*cfg)
+			if err != nil {
+				return fmt.Errorf("mock config is invalid: %w", err)
+			}
+
+			fmt.Printf("Mock API listening on %s\n", cfg.Listen)
+			for _, r := range cfg.Routes {
+				fmt.Printf("  %s\n", r)
+			}
+			return http.ListenAndServe(cfg.Listen, srv)
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", "", "address to listen on (default: the config's listen, or :8080)")
+	return cmd
+}
+
+func importCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Generate a test config from recorded traffic or API descriptions",
+	}
+	cmd.AddCommand(importHARCmd(), importOpenAPICmd(), importCurlCmd())
+	return cmd
+}
+
+func importHARCmd() *cobra.Command {
+	var opts importer.HAROptions
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "har <session.har>",
+		Short: "Generate a test config from a browser HAR recording",
+		Long: `Convert the requests in a HAR file into endpoints.
+
+Static assets (scripts, stylesheets, images, fonts, media) and requests to
+third-party domains are dropped. Hostnames become variables such as
+${base_url}, and request headers, bodies and response statuses are kept.
+With --flow, each VU replays the requests in recorded order, pausing for the
+recorded gaps; otherwise identical requests are merged and weighted by count.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("opening HAR: %w", err)
+			}
+			defer f.Close()
+
+			if opts.Name == "" {
+				opts.Name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			}
+			cfg, sum, err := importer.HAR(f, opts)
+			if err != nil {
+				return err
+			}
+			if err := writeConfig(cfg, output, "perf-test import har "+filepath.Base(args[0])); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Imported %d requests as %d endpoints (skipped %d static, %d third-party, %d aborted)\n",
+				sum.Kept, len(cfg.Endpoints), sum.Static, sum.ThirdParty, sum.Aborted)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the config to this file (default: stdout)")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "test name (default: the HAR file name)")
+	cmd.Flags().StringArrayVar(&opts.Domains, "domain", nil, "first-party domain to keep, repeatable (default: the recorded site's domain)")
+	cmd.Flags().BoolVar(&opts.IncludeStatic, "include-static", false, "keep static asset requests")
+	cmd.Flags().BoolVar(&opts.IncludeThirdParty, "include-third-party", false, "keep requests to other domains")
+	cmd.Flags().BoolVar(&opts.Flow, "flow", false, "keep recorded order as a flow and recorded gaps as think time")
+	return cmd
+}
+
+func importOpenAPICmd() *cobra.Command {
+	var opts importer.OpenAPIOptions
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "openapi <spec.yaml|spec.json>",
+		Short: "Generate a baseline test config from an OpenAPI 3 spec",
+		Long: `Create one endpoint per operation in an OpenAPI 3 spec.
+
+Path, required query and required header parameters are filled with data
+generator functions chosen from their schemas (random.uuid for uuid,
+random.email for email, random.int over the documented range, random.choice
+for enums, ...). JSON request bodies are synthesized from their schemas, and
+the lowest documented 2xx status becomes expect.status.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("opening spec: %w", err)
+			}
+			defer f.Close()
+
+			cfg, err := importer.OpenAPI(f, opts)
+			if err != nil {
+				return err
+			}
+			if err := writeConfig(cfg, output, "perf-test import openapi "+filepath.Base(args[0])); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Imported %d operations\n", len(cfg.Endpoints))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the config to this file (default: stdout)")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "test name (default: the spec title)")
+	cmd.Flags().StringVar(&opts.BaseURL, "base-url", "", "value for ${base_url} (default: the spec's first server)")
+	cmd.Flags().StringArrayVar(&opts.Tags, "tag", nil, "only import operations with this tag, repeatable")
+	return cmd
+}
+
+func importCurlCmd() *cobra.Command {
+	var opts importer.CurlOptions
+	var output, file string
+
+	cmd := &cobra.Command{
+		Use:   "curl [-f commands.sh | curl <args>...]",
+		Short: "Generate a test config from curl commands",
+		Long: `Convert curl invocations into endpoints, one per command.
+
+Pass a single command after the flags, either as separate arguments or as one
+quoted string, or read several commands from a file with -f (- for stdin).
+Commands may span lines with trailing backslashes, as produced by a browser's
+"Copy as cURL". -X, -H, -d/--data-raw/--data-binary, -u, --compressed, -k and
+-L are understood; output-only options are ignored.
+
+  perf-test import curl -o test.yaml curl -X POST https://api.example.com/orders -d '{"qty":1}'
+  perf-test import curl -f requests.sh -o test.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var cmds [][]string
+			var source string
+			switch {
+			case file != "" && len(args) > 0:
+				return fmt.Errorf("pass either -f or a command, not both")
+			case file != "":
+				text, err := readInput(file)
+				if err != nil {
+					return err
+				}
+				if cmds, err = curl.Split(string(text)); err != nil {
+					return fmt.Errorf("parsing %s: %w", file, err)
+				}
+				source = "perf-test import curl -f " + filepath.Base(file)
+			case len(args) == 1:
+				// One quoted command line.
+				text := args[0]
+				if !strings.HasPrefix(strings.TrimSpace(text), "curl") {
+					text = "curl " + text
+				}
+				var err error
+				if cmds, err = curl.Split(text); err != nil {
+					return err
+				}
+				source = "perf-test import curl"
+			case len(args) > 1:
+				cmds = [][]string{args}
+				source = "perf-test import curl"
+			default:
+				return fmt.Errorf("no curl command given")
+			}
+
+			cfg, warnings, err := importer.Curl(cmds, opts)
+			if err != nil {
+				return err
+			}
+			for _, w := range warnings {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+			}
+			if err := writeConfig(cfg, output, source); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Imported %d endpoints\n", len(cfg.Endpoints))
+			return nil
+		},
+	}
+	// Everything after the first positional argument belongs to curl.
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the config to this file (default: stdout)")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "read curl commands from this file (- for stdin)")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "test name")
+	return cmd
+}
+
+func exportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Render a test config in another tool's format",
+	}
+	cmd.AddCommand(exportCurlCmd())
+	return cmd
+}
+
+func exportCurlCmd() *cobra.Command {
+	var endpoints []string
+	var overrides overrideFlags
+
+	cmd := &cobra.Command{
+		Use:   "curl [config.yaml]",
+		Short: "Print each endpoint as a curl command",
+		Long: `Print one curl command per endpoint, with variables and data generator
+functions evaluated as they would be for a single request, so a failing
+request can be reproduced by hand. Each run generates fresh random values.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "perf-test.yaml"
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			cfg, err := overrides.load(path)
+			if err != nil {
+				return fmt.Errorf("config is invalid: %w", err)
+			}
+			gen := data.NewGenerator(cfg.Variables)
+			found := 0
+			for _, ep := range cfg.Endpoints {
+				if len(endpoints) > 0 && !slices.Contains(endpoints, ep.Name) {
+					continue
+				}
+				found++
+				fmt.Printf("# %s\n%s\n\n", ep.Name, curl.RenderEndpoint(ep, gen, cfg.HTTP))
+			}
+			if found == 0 {
+				return fmt.Errorf("no endpoint named %s", strings.Join(endpoints, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&endpoints, "endpoint", "e", nil, "only export the endpoint with this name, repeatable")
+	overrides.register(cmd)
+	return cmd
+}
+
+// overrideFlags are the config override flags shared by commands that load
+// a config file.
+type overrideFlags struct {
+	profile  string
+	sets     []string
+	vars     []string
+	envFiles []string
+}
+
+func (o *overrideFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.profile, "profile", "", "apply this entry from the config's profiles to the load block")
+	cmd.Flags().StringArrayVar(&o.sets, "set", nil, "override a config value, e.g. --set load.max_vus=200 (repeatable)")
+	cmd.Flags().StringArrayVar(&o.vars, "var", nil, "set a variable, e.g. --var base_url=https://staging.example.com (repeatable)")
+	cmd.Flags().StringArrayVar(&o.envFiles, "env-file", nil, "read KEY=VALUE environment for ${NAME} in variables from this file (repeatable)")
+}
+
+// load reads the config at path with the overrides applied.
+func (o *overrideFlags) load(path string) (*config.Config, error) {
+	ov := config.Overrides{Profile: o.profile, Set: o.sets}
+	for _, v := range o.vars {
+		k, val, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --var %q (expected name=value)", v)
+		}
+		if ov.Vars == nil {
+			ov.Vars = make(map[string]string)
+		}
+		ov.Vars[k] = val
+	}
+	for _, f := range o.envFiles {
+		env, err := config.LoadEnvFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if ov.Env == nil {
+			ov.Env = env
+			continue
+		}
+		for k, v := range env {
+			ov.Env[k] = v
+		}
+	}
+	return config.LoadWithOverrides(path, ov)
+}
+
+// reportFailure prints "<what> completed with failures" for a finished run
+// and returns err without cobra printing it again, so main exits 1.
+// Returning rather than exiting lets deferred cleanup, such as removing the
+// control socket, run.
+func reportFailure(cmd *cobra.Command, what string, err error) error {
+	fmt.Fprintf(os.Stderr, "%s completed with failures: %v\n", what, err)
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	return err
+}
+
+// readInput reads path, or stdin when path is "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeChecked loads out as a config and, if it is valid, writes it to path,
+// or to stdout when path is "-". The config is checked in a temporary file
+// that is renamed into place, so an invalid one is never left at path.
+func writeChecked(path string, out []byte) error {
+	dir := os.TempDir()
+	if path != "-" {
+		dir = filepath.Dir(path)
+	}
+	f, err := os.CreateTemp(dir, ".perf-test-init-*.yaml")
+	if err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // fails harmlessly once renamed
+	_, err = f.Write(out)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if _, err := config.Load(tmp); err != nil {
+		return fmt.Errorf("generated config is invalid: %w", err)
+	}
+	if path == "-" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// writeConfig marshals cfg to path, or to stdout when path is empty, with a
+// comment noting the command that generated it.
+func writeConfig(cfg *config.Config, path, source string) error {
+	out, err := config.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	out = append([]byte("# Generated by "+source+"\n"), out...)
+	if path == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return nil
+}
+
+func schemaCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print a JSON Schema for config files",
+		Long: `Print a JSON Schema describing the config file format. Point your editor's
+YAML support at it for validation and autocompletion, e.g. with the YAML
+language server add this first line to a config:
+
+  # yaml-language-server: $schema=perf-test.schema.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := config.Schema()
+			if err != nil {
+				return err
+			}
+			out = append(out, '\n')
+			if output == "" {
+				_, err = os.Stdout.Write(out)
+				return err
+			}
+			if err := os.WriteFile(output, out, 0o644); err != nil {
+				return fmt.Errorf("writing schema: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the schema to this file instead of stdout")
+	return cmd
 }
 
 func versionCmd() *cobra.Command {
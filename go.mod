@@ -1,6 +1,9 @@
 module github.com/jvreagan/perf-test
 
-go 1.22
+// Go 1.24 is the minimum: http.Protocols selects HTTP/1.1, HTTP/2 or
+// cleartext HTTP/2 (h2c) for http.protocol and grpc:// endpoints, which the
+// standard library could not do before without golang.org/x/net.
+go 1.24
 
 require (
 	github.com/spf13/cobra v1.8.1
@@ -0,0 +1,480 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A config file may be composed from others:
+//
+//	extends: base.yaml          # or a list; deep-merged underneath this file
+//	include: [endpoints/*.yaml] # fragments holding endpoints and/or variables
+//	profiles:                   # named load blocks, selected with --profile
+//	  smoke: {max_vus: 1, steady_state: 30s}
+//
+// Mappings merge key by key with this file winning. Lists are replaced,
+// except endpoints, which merge by name: an endpoint with the same name as
+// a base endpoint is merged onto it, and new names are appended. Paths are
+// relative to the file that names them.
+
+// readComposed reads path and resolves extends, include and the named
+// profile, returning the merged top-level mapping and the composer that
+// knows where each of its nodes came from.
+func readComposed(path, profile string) (*yaml.Node, *composer, error) {
+	c := &composer{files: make(map[*yaml.Node]string)}
+	root, err := c.readTree(path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.applyProfile(root, path, profile); err != nil {
+		return nil, nil, err
+	}
+	return root, c, nil
+}
+
+// composer reads the files making up one config. It remembers which file
+// each node came from, so errors about merged nodes name the right file.
+type composer struct {
+	files map[*yaml.Node]string
+}
+
+// pos formats n's position as file:line.
+func (c *composer) pos(n *yaml.Node, fallback string) string {
+	if f, ok := c.files[n]; ok {
+		return fmt.Sprintf("%s:%d", f, n.Line)
+	}
+	return fallback
+}
+
+// locate returns the file:line:col of the node at a field path such as
+// "endpoints[0].url" under root. If the field is not in the YAML (it was
+// defaulted, or added by an override) the nearest enclosing node is used.
+func (c *composer) locate(root *yaml.Node, path string) string {
+	pos := func(n *yaml.Node) string {
+		if f, ok := c.files[n]; ok {
+			return fmt.Sprintf("%s:%d:%d", f, n.Line, n.Column)
+		}
+		return ""
+	}
+	best := pos(root)
+	n := root
+	for _, key := range strings.Split(strings.NewReplacer("[", ".", "]", "").Replace(path), ".") {
+		if n.Kind == yaml.AliasNode {
+			n = n.Alias
+		}
+		switch n.Kind {
+		case yaml.MappingNode:
+			n = mapValue(n, key)
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(n.Content) {
+				return best
+			}
+			n = n.Content[i]
+		default:
+			return best
+		}
+		if n == nil {
+			return best
+		}
+		if p := pos(n); p != "" {
+			best = p
+		}
+	}
+	return best
+}
+
+func (c *composer) track(n *yaml.Node, path string) {
+	c.files[n] = path
+	for _, child := range n.Content {
+		c.track(child, path)
+	}
+}
+
+// readTree reads one config file and everything it extends and includes.
+// stack holds the absolute paths of the files being read, for cycle detection.
+func (c *composer) readTree(path string, stack []string) (*yaml.Node, error) {
+	root, err := c.readMapping(path)
+	if err != nil {
+		return nil, err
+	}
+	// Type errors are collected rather than returned, so problems in
+	// included and extended files are reported along with this file's.
+	errs := []error{typecheck(path, root, &Config{}, "", "extends", "include", "profiles")}
+	if v := mapValue(root, "profiles"); v != nil {
+		var profiles map[string]LoadConfig
+		errs = append(errs, typecheck(path, v, &profiles, "profiles"))
+	}
+	stack = append(stack, absPath(path))
+
+	// Includes first, so this file's own endpoints and variables follow and
+	// override theirs.
+	if inc, incKey := takeKey(root, "include"); inc != nil {
+		paths, err := pathList(path, incKey, inc)
+		if err != nil {
+			return nil, err
+		}
+		included, err := c.readIncludes(path, incKey, paths, stack)
+		if err != nil {
+			return nil, errors.Join(append(errs, err)...)
+		}
+		root = mergeMapping(included, root, true)
+	}
+
+	if ext, extKey := takeKey(root, "extends"); ext != nil {
+		paths, err := pathList(path, extKey, ext)
+		if err != nil {
+			return nil, err
+		}
+		// Later bases override earlier ones; this file overrides them all.
+		var base *yaml.Node
+		for _, p := range paths {
+			if err := checkCycle(path, extKey, p.path, stack); err != nil {
+				return nil, err
+			}
+			b, err := c.readTree(p.path, stack)
+			if err != nil {
+				return nil, errors.Join(append(errs, fmt.Errorf("%s:%d: extends %s: %w", path, p.line, p.raw, err))...)
+			}
+			if base == nil {
+				base = b
+			} else {
+				base = mergeMapping(base, b, true)
+			}
+		}
+		root = mergeMapping(base, root, true)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// readFragment reads an include file: a mapping with endpoints and/or
+// variables (and optionally further includes), or a bare list of endpoints.
+func (c *composer) readFragment(path string, stack []string) (*yaml.Node, error) {
+	doc, err := c.readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Kind == yaml.SequenceNode {
+		doc = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "endpoints"}, doc,
+		}}
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: an include must be a mapping with endpoints and/or variables, or a list of endpoints", path, doc.Line)
+	}
+	for i := 0; i < len(doc.Content); i += 2 {
+		switch k := doc.Content[i]; k.Value {
+		case "endpoints", "variables", "include":
+		default:
+			return nil, fmt.Errorf("%s:%d: %q is not allowed in an include (only endpoints, variables and include)", path, k.Line, k.Value)
+		}
+	}
+	var frag struct {
+		Endpoints []Endpoint        `yaml:"endpoints"`
+		Variables map[string]string `yaml:"variables"`
+	}
+	checkErr := typecheck(path, doc, &frag, "", "include")
+	stack = append(stack, absPath(path))
+
+	if inc, incKey := takeKey(doc, "include"); inc != nil {
+		paths, err := pathList(path, incKey, inc)
+		if err != nil {
+			return nil, err
+		}
+		included, err := c.readIncludes(path, incKey, paths, stack)
+		if err != nil {
+			return nil, errors.Join(checkErr, err)
+		}
+		doc = mergeMapping(included, doc, true)
+	}
+	if checkErr != nil {
+		return nil, checkErr
+	}
+	return doc, nil
+}
+
+// readIncludes reads the fragments named by an include key and merges them
+// in order.
+func (c *composer) readIncludes(path string, key *yaml.Node, refs []pathRef, stack []string) (*yaml.Node, error) {
+	out := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, p := range refs {
+		if err := checkCycle(path, key, p.path, stack); err != nil {
+			return nil, err
+		}
+		frag, err := c.readFragment(p.path, stack)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: include %s: %w", path, p.line, p.raw, err)
+		}
+		out = mergeMapping(out, frag, true)
+	}
+	return out, nil
+}
+
+// applyProfile merges profiles[name] over the load block and removes the
+// profiles key. With no name, profiles are just removed.
+func (c *composer) applyProfile(root *yaml.Node, path, name string) error {
+	profiles, key := takeKey(root, "profiles")
+	if name == "" {
+		return nil
+	}
+	if profiles == nil {
+		return fmt.Errorf("%s: profile %q not found: the config defines no profiles", path, name)
+	}
+	if profiles.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: profiles must be a mapping of name to load settings", c.pos(key, path))
+	}
+	prof := mapValue(profiles, name)
+	if prof == nil {
+		var names []string
+		for i := 0; i < len(profiles.Content); i += 2 {
+			names = append(names, profiles.Content[i].Value)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("%s: profile %q not found (available: %s)", c.pos(key, path), name, strings.Join(names, ", "))
+	}
+	if prof.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: profile %q must be a mapping of load settings", c.pos(prof, path), name)
+	}
+
+	load := mapValue(root, "load")
+	if load == nil {
+		setKey(root, "load", prof)
+		return nil
+	}
+	// A profile that describes its own stages, in either form, replaces the
+	// base's stages rather than mixing the two forms.
+	if mapValue(prof, "stages") != nil {
+		for _, k := range []string{"ramp_up", "steady_state", "ramp_down"} {
+			takeKey(load, k)
+		}
+	}
+	for _, k := range []string{"ramp_up", "steady_state", "ramp_down", "max_vus"} {
+		if mapValue(prof, k) != nil {
+			takeKey(load, "stages")
+		}
+	}
+	setKey(root, "load", mergeMapping(load, prof, false))
+	return nil
+}
+
+// mergeMapping returns base with over merged on top. Nested mappings merge
+// recursively; other values in over replace those in base. At the top level
+// of a config, endpoints merge by name.
+func mergeMapping(base, over *yaml.Node, top bool) *yaml.Node {
+	if base.Kind != yaml.MappingNode || over.Kind != yaml.MappingNode {
+		return over
+	}
+	out := &yaml.Node{Kind: yaml.MappingNode, Tag: base.Tag, Line: base.Line, Column: base.Column}
+	out.Content = append(out.Content, base.Content...)
+	for i := 0; i < len(over.Content); i += 2 {
+		k, v := over.Content[i], over.Content[i+1]
+		j := keyIndex(out, k.Value)
+		switch {
+		case j < 0:
+			out.Content = append(out.Content, k, v)
+		case top && k.Value == "endpoints":
+			out.Content[j+1] = mergeEndpoints(out.Content[j+1], v)
+		default:
+			out.Content[j+1] = mergeMapping(out.Content[j+1], v, false)
+		}
+	}
+	return out
+}
+
+// mergeEndpoints merges over onto base by endpoint name. Unnamed endpoints
+// are appended.
+func mergeEndpoints(base, over *yaml.Node) *yaml.Node {
+	if base.Kind != yaml.SequenceNode || over.Kind != yaml.SequenceNode {
+		return over
+	}
+	out := &yaml.Node{Kind: yaml.SequenceNode, Tag: base.Tag, Line: base.Line, Column: base.Column}
+	out.Content = append(out.Content, base.Content...)
+	for _, ep := range over.Content {
+		name := mapValue(ep, "name")
+		merged := false
+		if name != nil {
+			for i, b := range out.Content {
+				if bn := mapValue(b, "name"); bn != nil && bn.Value == name.Value {
+					out.Content[i] = mergeMapping(b, ep, false)
+					merged = true
+					break
+				}
+			}
+		}
+		if !merged {
+			out.Content = append(out.Content, ep)
+		}
+	}
+	return out
+}
+
+// readDocument parses path and returns its top-level node. An empty file is
+// an empty mapping.
+func (c *composer) readDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlError(path, err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}, nil
+	}
+	c.track(doc.Content[0], path)
+	return doc.Content[0], nil
+}
+
+func (c *composer) readMapping(path string) (*yaml.Node, error) {
+	doc, err := c.readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: config must be a mapping", path, doc.Line)
+	}
+	return doc, nil
+}
+
+// typecheck decodes n into v to report unknown keys and type errors against
+// the file they came from, before nodes from several files are merged. where
+// is the YAML path of n and allow lists extra keys accepted at its top.
+func typecheck(path string, n *yaml.Node, v any, where string, allow ...string) error {
+	errs := checkKeys(path, n, reflect.TypeOf(v).Elem(), where, allow...)
+	if err := n.Decode(v); err != nil {
+		errs = append(errs, yamlError(path, err))
+	}
+	return errors.Join(errs...)
+}
+
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// yamlError rewrites yaml.v3's "line N: msg" errors as "path:N: msg".
+func yamlError(path string, err error) error {
+	var te *yaml.TypeError
+	if errors.As(err, &te) {
+		msgs := make([]string, len(te.Errors))
+		for i, e := range te.Errors {
+			msgs[i] = yamlLine.ReplaceAllString(e, path+":$1: ")
+		}
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+	if msg := err.Error(); yamlLine.MatchString(msg) {
+		return errors.New(yamlLine.ReplaceAllString(msg, path+":$1: "))
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+type pathRef struct {
+	raw  string // as written
+	path string // resolved against the referring file
+	line int
+}
+
+// pathList reads an extends or include value: one path or a list of paths.
+// Glob patterns are expanded.
+func pathList(file string, key, v *yaml.Node) ([]pathRef, error) {
+	items := []*yaml.Node{v}
+	if v.Kind == yaml.SequenceNode {
+		items = v.Content
+	}
+	dir := filepath.Dir(file)
+	var refs []pathRef
+	for _, it := range items {
+		if it.Kind != yaml.ScalarNode || it.Value == "" {
+			return nil, fmt.Errorf("%s:%d: %s must be a file path or a list of file paths", file, it.Line, key.Value)
+		}
+		p := it.Value
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		if strings.ContainsAny(it.Value, "*?[") {
+			matches, err := filepath.Glob(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s %s: %w", file, it.Line, key.Value, it.Value, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s:%d: %s %s: no files match", file, it.Line, key.Value, it.Value)
+			}
+			for _, m := range matches {
+				refs = append(refs, pathRef{raw: m, path: m, line: it.Line})
+			}
+			continue
+		}
+		refs = append(refs, pathRef{raw: it.Value, path: p, line: it.Line})
+	}
+	return refs, nil
+}
+
+func checkCycle(file string, key *yaml.Node, target string, stack []string) error {
+	abs := absPath(target)
+	for i, s := range stack {
+		if s == abs {
+			chain := append(append([]string(nil), stack[i:]...), abs)
+			for j := range chain {
+				chain[j] = filepath.Base(chain[j])
+			}
+			return fmt.Errorf("%s:%d: %s cycle: %s", file, key.Line, key.Value, strings.Join(chain, " -> "))
+		}
+	}
+	return nil
+}
+
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+// mapValue returns the value for key in mapping m, or nil.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	if i := keyIndex(m, key); i >= 0 {
+		return m.Content[i+1]
+	}
+	return nil
+}
+
+func keyIndex(m *yaml.Node, key string) int {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// takeKey removes key from mapping m, returning its value and key nodes.
+func takeKey(m *yaml.Node, key string) (value, keyNode *yaml.Node) {
+	i := keyIndex(m, key)
+	if i < 0 {
+		return nil, nil
+	}
+	keyNode, value = m.Content[i], m.Content[i+1]
+	m.Content = append(m.Content[:i:i], m.Content[i+2:]...)
+	return value, keyNode
+}
+
+func setKey(m *yaml.Node, key string, v *yaml.Node) {
+	if i := keyIndex(m, key); i >= 0 {
+		m.Content[i+1] = v
+		return
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles writes name -> content into a temp dir and returns the dir.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const composeBase = `name: base
+load:
+  ramp_up: 10s
+  steady_state: 1m
+  ramp_down: 10s
+  max_vus: 5
+  think_time: 1s
+http:
+  timeout: 10s
+variables:
+  base_url: http://localhost:8080
+  region: us
+endpoints:
+  - name: health
+    url: ${base_url}/health
+    expect: {status: 200}
+profiles:
+  smoke:
+    max_vus: 1
+    steady_state: 30s
+  soak:
+    stages:
+      - duration: 4h
+        target: 20
+`
+
+func TestLoad_Extends(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"shared/base.yaml": composeBase,
+		"svc/test.yaml": `extends: ../shared/base.yaml
+name: orders
+http:
+  insecure_skip_verify: true
+variables:
+  region: eu
+endpoints:
+  - name: health
+    expect: {status: 204}
+  - name: orders
+    url: ${base_url}/orders
+`,
+	})
+	cfg, err := Load(filepath.Join(dir, "svc/test.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "orders" {
+		t.Errorf("name = %q", cfg.Name)
+	}
+	// Nested mappings merge key by key.
+	if cfg.HTTP.Timeout.Duration != 10*time.Second || !cfg.HTTP.InsecureSkipVerify {
+		t.Errorf("http = %+v", cfg.HTTP)
+	}
+	if cfg.Variables["base_url"] != "http://localhost:8080" || cfg.Variables["region"] != "eu" {
+		t.Errorf("variables = %v", cfg.Variables)
+	}
+	if cfg.Load.MaxVUs != 5 || cfg.Load.ThinkTime.Duration != time.Second {
+		t.Errorf("load = %+v", cfg.Load)
+	}
+	// Endpoints merge by name; new ones are appended.
+	if len(cfg.Endpoints) != 2 {
+		t.Fatalf("got %d endpoints, want 2", len(cfg.Endpoints))
+	}
+	if h := cfg.Endpoints[0]; h.Name != "health" || h.URL != "${base_url}/health" || h.Expect.Status != 204 {
+		t.Errorf("health = %+v", h)
+	}
+	if cfg.Endpoints[1].Name != "orders" {
+		t.Errorf("endpoint 1 = %+v", cfg.Endpoints[1])
+	}
+}
+
+func TestLoad_ExtendsChainAndList(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yaml":    "load: {max_vus: 1, steady_state: 1m}\nvariables: {a: '1', shared: a}\n",
+		"b.yaml":    "extends: a.yaml\nvariables: {b: '2', shared: b}\n",
+		"c.yaml":    "variables: {c: '3', shared: c}\nendpoints: [{name: x, url: 'http://x'}]\n",
+		"test.yaml": "extends: [b.yaml, c.yaml]\nname: t\n",
+	})
+	cfg, err := Load(filepath.Join(dir, "test.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := cfg.Variables
+	if v["a"] != "1" || v["b"] != "2" || v["c"] != "3" || v["shared"] != "c" {
+		t.Errorf("variables = %v (later bases should win)", v)
+	}
+}
+
+func TestLoad_Include(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"test.yaml": `name: t
+include:
+  - endpoints/users.yaml
+  - vars.yaml
+load: {max_vus: 1, steady_state: 1m}
+variables:
+  base_url: http://override
+endpoints:
+  - name: local
+    url: ${base_url}/local
+`,
+		"endpoints/users.yaml": `- name: list users
+  url: ${base_url}/users
+- name: get user
+  url: ${base_url}/users/1
+`,
+		"vars.yaml": `include: more-vars.yaml
+variables:
+  base_url: http://from-include
+  token: abc
+`,
+		"more-vars.yaml": "variables: {region: eu}\n",
+	})
+	cfg, err := Load(filepath.Join(dir, "test.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, ep := range cfg.Endpoints {
+		names = append(names, ep.Name)
+	}
+	if strings.Join(names, ",") != "list users,get user,local" {
+		t.Errorf("endpoints = %v", names)
+	}
+	v := cfg.Variables
+	if v["base_url"] != "http://override" || v["token"] != "abc" || v["region"] != "eu" {
+		t.Errorf("variables = %v", v)
+	}
+}
+
+func TestLoad_IncludeGlob(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"test.yaml":   "include: eps/*.yaml\nload: {max_vus: 1, steady_state: 1m}\n",
+		"eps/a.yaml":  "endpoints: [{name: a, url: 'http://x/a'}]\n",
+		"eps/b.yaml":  "endpoints: [{name: b, url: 'http://x/b'}]\n",
+		"eps/c.txt":   "not yaml: [",
+		"unused.yaml": "",
+	})
+	cfg, err := Load(filepath.Join(dir, "test.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Endpoints) != 2 || cfg.Endpoints[0].Name != "a" || cfg.Endpoints[1].Name != "b" {
+		t.Errorf("endpoints = %+v", cfg.Endpoints)
+	}
+}
+
+func TestLoad_Profiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": composeBase,
+		"test.yaml": "extends: base.yaml\nprofiles:\n  spike:\n    mode: arrival_rate\n    stages: [{duration: 10s, target: 100}]\n",
+	})
+	path := filepath.Join(dir, "test.yaml")
+
+	smoke, err := LoadWithOverrides(path, Overrides{Profile: "smoke"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if smoke.Load.MaxVUs != 1 || smoke.Load.SteadyState.Duration != 30*time.Second || smoke.Load.RampUp.Duration != 10*time.Second {
+		t.Errorf("smoke load = %+v", smoke.Load)
+	}
+	if smoke.TotalDuration() != 50*time.Second {
+		t.Errorf("smoke duration = %s, want 50s", smoke.TotalDuration())
+	}
+
+	soak, err := LoadWithOverrides(path, Overrides{Profile: "soak"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(soak.Load.Stages) != 1 || soak.Load.Stages[0].Target != 20 || soak.Load.RampUp.Duration != 0 {
+		t.Errorf("soak load = %+v (the profile's stages should replace the shorthand)", soak.Load)
+	}
+	if soak.Load.ThinkTime.Duration != time.Second {
+		t.Errorf("soak think_time = %s, want the base's 1s", soak.Load.ThinkTime.Duration)
+	}
+
+	spike, err := LoadWithOverrides(path, Overrides{Profile: "spike"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spike.Load.Mode != "arrival_rate" || spike.Load.Stages[0].Target != 100 {
+		t.Errorf("spike load = %+v", spike.Load)
+	}
+
+	// Without --profile, the base load block is used as written.
+	plain, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Load.MaxVUs != 5 {
+		t.Errorf("plain load = %+v", plain.Load)
+	}
+
+	_, err = LoadWithOverrides(path, Overrides{Profile: "nope"})
+	if err == nil || !strings.Contains(err.Error(), "available: smoke, soak, spike") {
+		t.Errorf("err = %v, want the available profiles listed", err)
+	}
+	// profiles was first defined on line 17 of base.yaml, not in test.yaml.
+	if err == nil || !strings.Contains(err.Error(), "base.yaml:17:") {
+		t.Errorf("err = %v, want it to point at base.yaml:17", err)
+	}
+}
+
+func TestLoad_ComposeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "extends cycle",
+			files: map[string]string{
+				"test.yaml": "name: t\nextends: a.yaml\n",
+				"a.yaml":    "extends: b.yaml\n",
+				"b.yaml":    "\n\nextends: a.yaml\n",
+			},
+			want: "b.yaml:3: extends cycle: a.yaml -> b.yaml -> a.yaml",
+		},
+		{
+			name: "self include",
+			files: map[string]string{
+				"test.yaml": "include: frag.yaml\n",
+				"frag.yaml": "include: frag.yaml\n",
+			},
+			want: "frag.yaml:1: include cycle: frag.yaml -> frag.yaml",
+		},
+		{
+			name:  "missing base",
+			files: map[string]string{"test.yaml": "name: t\n\nextends: nope.yaml\n"},
+			want:  "test.yaml:3: extends nope.yaml: reading config file",
+		},
+		{
+			name: "type error in base",
+			files: map[string]string{
+				"test.yaml": "extends: base.yaml\n",
+				"base.yaml": "load:\n  max_vus: lots\n",
+			},
+			want: "base.yaml:2: cannot unmarshal",
+		},
+		{
+			name: "syntax error",
+			files: map[string]string{
+				"test.yaml": "name: t\nload: [\n",
+			},
+			want: "test.yaml:",
+		},
+		{
+			name: "bad include key",
+			files: map[string]string{
+				"test.yaml": "include: [frag.yaml]\n",
+				"frag.yaml": "endpoints: []\nload: {max_vus: 1}\n",
+			},
+			want: `frag.yaml:2: "load" is not allowed in an include`,
+		},
+		{
+			name:  "extends not a path",
+			files: map[string]string{"test.yaml": "extends: {a: b}\n"},
+			want:  "test.yaml:1: extends must be a file path",
+		},
+		{
+			name:  "glob matches nothing",
+			files: map[string]string{"test.yaml": "include: none/*.yaml\n"},
+			want:  "test.yaml:1: include none/*.yaml: no files match",
+		},
+		{
+			name:  "bad profile type",
+			files: map[string]string{"test.yaml": "profiles:\n  smoke:\n    max_vus: many\n"},
+			want:  "test.yaml:3: cannot unmarshal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			_, err := Load(filepath.Join(dir, "test.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_ProfileWithoutProfiles(t *testing.T) {
+	path := writeTemp(t, "load: {max_vus: 1, steady_state: 1m}\nendpoints: [{name: a, url: 'http://x'}]\n")
+	_, err := LoadWithOverrides(path, Overrides{Profile: "smoke"})
+	if err == nil || !strings.Contains(err.Error(), "defines no profiles") {
+		t.Errorf("err = %v", err)
+	}
+}
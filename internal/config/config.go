@@ -1,11 +1,15 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"os"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/jvreagan/perf-test/internal/data"
 	"gopkg.in/yaml.v3"
 )
 
@@ -29,74 +33,269 @@ func (d Duration) MarshalYAML() (interface{}, error) {
 
 // Stage represents a single load stage.
 type Stage struct {
-	Duration Duration `yaml:"duration"`
+	Duration Duration `yaml:"duration,omitempty"`
 	Target   int      `yaml:"target"`
-	Ramp     string   `yaml:"ramp"` // "linear" (default) or "step"
+	Ramp     string   `yaml:"ramp,omitempty"` // see RampShapes; "linear" by default
+
+	// Shape parameters; each applies only to the ramps noted.
+	Period    Duration `yaml:"period,omitempty"`    // sine: cycle length (default: stage duration); random_walk: time between steps (default 1s)
+	Amplitude int      `yaml:"amplitude,omitempty"` // sine: swing above and below target; random_walk: max change per step
+	Hold      Duration `yaml:"hold,omitempty"`      // spike: time spent at target before returning (default: 10% of duration)
+	Min       int      `yaml:"min,omitempty"`       // random_walk: lower bound (target is the upper bound)
+	Seed      int64    `yaml:"seed,omitempty"`      // random_walk: the same seed always produces the same walk
 }
 
+// RampShapes lists the valid values of Stage.Ramp.
+var RampShapes = []string{"linear", "step", "exponential", "logarithmic", "sine", "spike", "random_walk"}
+
 // LoadConfig holds the load profile configuration.
 type LoadConfig struct {
-	Mode        string   `yaml:"mode"` // "vu" (default) or "arrival_rate"
-	Stages      []Stage  `yaml:"stages"`
-	RampUp      Duration `yaml:"ramp_up"`
-	SteadyState Duration `yaml:"steady_state"`
-	RampDown    Duration `yaml:"ramp_down"`
-	MaxVUs      int      `yaml:"max_vus"`
-	MaxRPS      float64  `yaml:"max_rps"`
-	ThinkTime   Duration `yaml:"think_time"`
+	Mode        string   `yaml:"mode,omitempty"` // "vu" (default) or "arrival_rate"
+	Stages      []Stage  `yaml:"stages,omitempty"`
+	RampUp      Duration `yaml:"ramp_up,omitempty"`
+	SteadyState Duration `yaml:"steady_state,omitempty"`
+	RampDown    Duration `yaml:"ramp_down,omitempty"`
+	MaxVUs      int      `yaml:"max_vus,omitempty"`
+	MaxRPS      float64  `yaml:"max_rps,omitempty"`
+	ThinkTime   Duration `yaml:"think_time,omitempty"`
+	// GracefulStop is how long in-flight requests may run after a VU is
+	// removed or the test is stopped before they are cancelled (0 = cancel at once).
+	GracefulStop Duration        `yaml:"graceful_stop,omitempty"`
+	Adaptive     *AdaptiveConfig `yaml:"adaptive,omitempty"`
+	// Flow makes each VU request the endpoints in the order listed, looping,
+	// instead of picking them at random by weight. vu mode only.
+	Flow bool `yaml:"flow,omitempty"`
+}
+
+// AdaptiveConfig enables closed-loop control of the load target. Instead of
+// following the stage curve, a controller adjusts the VU count (vu mode) or
+// RPS (arrival_rate mode) to hold windowed latency at or below TargetLatency.
+type AdaptiveConfig struct {
+	Algorithm     string   `yaml:"algorithm,omitempty"`      // "aimd" (default) or "pid"
+	TargetLatency Duration `yaml:"target_latency,omitempty"` // latency goal at Percentile
+	Percentile    float64  `yaml:"percentile,omitempty"`     // default 99
+	MaxErrorRate  *float64 `yaml:"max_error_rate,omitempty"` // fraction 0-1, default 0.01; 0 tolerates no errors
+	Min           int      `yaml:"min,omitempty"`            // lower bound on the target, default 1
+	Max           int      `yaml:"max,omitempty"`            // upper bound on the target
+	Initial       int      `yaml:"initial,omitempty"`        // starting target, default min
+	Interval      Duration `yaml:"interval,omitempty"`       // adjustment period and metrics window, default 5s
+	Duration      Duration `yaml:"duration,omitempty"`       // total run time when no stages are given
+	MaxStep       int      `yaml:"max_step,omitempty"`       // max change per adjustment (0 = unlimited)
+
+	// AIMD tuning
+	Increase int     `yaml:"increase,omitempty"` // additive step when healthy, default 1
+	Decrease float64 `yaml:"decrease,omitempty"` // multiplicative factor on breach, default 0.75
+
+	// PID tuning
+	Kp float64 `yaml:"kp,omitempty"` // default 0.5
+	Ki float64 `yaml:"ki,omitempty"` // default 0.1
+	Kd float64 `yaml:"kd,omitempty"` // default 0.05
 }
 
 // HTTPConfig holds HTTP client settings.
 type HTTPConfig struct {
-	Timeout            Duration `yaml:"timeout"`
-	FollowRedirects    bool     `yaml:"follow_redirects"`
-	InsecureSkipVerify bool     `yaml:"insecure_skip_verify"`
+	Timeout            Duration  `yaml:"timeout,omitempty"`
+	FollowRedirects    bool      `yaml:"follow_redirects,omitempty"`
+	InsecureSkipVerify bool      `yaml:"insecure_skip_verify,omitempty"`
+	TLS                TLSConfig `yaml:"tls,omitempty"`
+
+	// Protocol selects the HTTP version: "auto" (default) negotiates HTTP/2
+	// over TLS and uses HTTP/1.1 otherwise; "http1" and "http2" force one;
+	// "h2c" speaks cleartext HTTP/2 with prior knowledge.
+	Protocol string `yaml:"protocol,omitempty"`
+	// MaxConcurrentStreams caps the requests in flight on each HTTP/2
+	// connection, spreading load over max_connections_per_host connections
+	// (0 = as many as the server allows). http2 and h2c only.
+	MaxConcurrentStreams int `yaml:"max_concurrent_streams,omitempty"`
+	// MaxConnsPerHost limits the connections open to each host (0 = unlimited).
+	MaxConnsPerHost int `yaml:"max_connections_per_host,omitempty"`
+
+	// ConnectionMode decides which requests share connections: "shared"
+	// (default) pools them across all VUs, "per_vu" gives each VU its own
+	// pool like separate browsers, and "no_keepalive" opens a new
+	// connection for every request.
+	ConnectionMode string `yaml:"connection_mode,omitempty"`
+	// MaxIdleConns and MaxIdleConnsPerHost size the pool of idle connections
+	// kept for reuse (defaults 1000 and 100); IdleTimeout closes connections
+	// idle for longer (default 90s).
+	MaxIdleConns        int      `yaml:"max_idle_connections,omitempty"`
+	MaxIdleConnsPerHost int      `yaml:"max_idle_connections_per_host,omitempty"`
+	IdleTimeout         Duration `yaml:"idle_timeout,omitempty"`
+
+	// Proxy routes requests through an HTTP, HTTPS or SOCKS5 proxy.
+	Proxy ProxyConfig `yaml:"proxy,omitempty"`
+	// LocalAddresses are source IPs to bind outgoing connections to, taken
+	// in turn: one per VU in per_vu connection mode, else one per connection.
+	LocalAddresses []string `yaml:"local_addresses,omitempty"`
+	// Resolve maps "host" or "host:port" to the IP to connect to instead of
+	// looking it up, like curl --resolve.
+	Resolve map[string]string `yaml:"resolve,omitempty"`
+	// UnixSocket sends every request over this Unix domain socket, given as
+	// a path or unix:///path. URLs then only supply the host header and path.
+	UnixSocket string `yaml:"unix_socket,omitempty"`
+}
+
+// Protocols lists the valid values of HTTPConfig.Protocol.
+var Protocols = []string{"auto", "http1", "http2", "h2c"}
+
+// ConnectionModes lists the valid values of HTTPConfig.ConnectionMode.
+var ConnectionModes = []string{"shared", "per_vu", "no_keepalive"}
+
+// TLSConfig holds TLS client settings. An endpoint's tls block overrides the
+// fields it sets in http.tls.
+type TLSConfig struct {
+	CertFile          string   `yaml:"cert_file,omitempty"`          // client certificate (PEM), for mutual TLS
+	KeyFile           string   `yaml:"key_file,omitempty"`           // private key for cert_file (PEM)
+	CAFile            string   `yaml:"ca_file,omitempty"`            // CA bundle (PEM) to trust instead of the system roots
+	ServerName        string   `yaml:"server_name,omitempty"`        // SNI and certificate name, if not the URL's host
+	MinVersion        string   `yaml:"min_version,omitempty"`        // "1.0", "1.1", "1.2" or "1.3"
+	MaxVersion        string   `yaml:"max_version,omitempty"`        // as min_version
+	CipherSuites      []string `yaml:"cipher_suites,omitempty"`      // IANA names; applies to TLS 1.2 and below
+	SessionResumption *bool    `yaml:"session_resumption,omitempty"` // resume sessions on new connections (default off)
 }
 
 // ExpectConfig holds response expectations.
 type ExpectConfig struct {
-	Status int `yaml:"status"`
+	Status int `yaml:"status,omitempty"`
 }
 
-// Endpoint defines a single HTTP endpoint to test.
+// Endpoint defines a single endpoint to test.
 type Endpoint struct {
-	Name    string            `yaml:"name"`
-	Method  string            `yaml:"method"`
-	URL     string            `yaml:"url"`
-	Headers map[string]string `yaml:"headers"`
-	Body    string            `yaml:"body"`
-	Weight  int               `yaml:"weight"`
-	Expect  ExpectConfig      `yaml:"expect"`
+	// Type is the kind of endpoint: "http" (default), "websocket", "grpc",
+	// "graphql", "tcp" or "udp".
+	Type    string            `yaml:"type,omitempty"`
+	Name    string            `yaml:"name,omitempty"`
+	Method  string            `yaml:"method,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+	Weight  int               `yaml:"weight,omitempty"`
+	Expect  ExpectConfig      `yaml:"expect,omitempty"`
+	// ThinkTime overrides load.think_time for the pause after this endpoint.
+	ThinkTime Duration `yaml:"think_time,omitempty"`
+	// TLS overrides http.tls for this endpoint.
+	TLS *TLSConfig `yaml:"tls,omitempty"`
+	// WebSocket configures the session of a websocket endpoint.
+	WebSocket *WebSocketConfig `yaml:"websocket,omitempty"`
+	// GRPC configures the call a grpc endpoint makes.
+	GRPC *GRPCConfig `yaml:"grpc,omitempty"`
+	// Stream makes an http endpoint read its response as a stream of events.
+	Stream *StreamConfig `yaml:"stream,omitempty"`
+	// GraphQL is the operation a graphql endpoint sends.
+	GraphQL *GraphQLConfig `yaml:"graphql,omitempty"`
+	// Socket is the exchange a tcp or udp endpoint makes.
+	Socket *SocketConfig `yaml:"socket,omitempty"`
+}
+
+// IsHTTP reports whether e is a plain HTTP request.
+func (e Endpoint) IsHTTP() bool {
+	return e.Type == "" || e.Type == "http"
+}
+
+// EndpointTypes lists the valid values of Endpoint.Type.
+var EndpointTypes = []string{"http", "websocket", "grpc", "graphql", "tcp", "udp"}
+
+// WebSocketConfig describes what a websocket endpoint does once connected.
+// Each execution is one session: connect, then send Send every Interval
+// (once if Interval is 0) and wait up to Timeout for a reply matching
+// Expect, until Hold has passed; then close.
+type WebSocketConfig struct {
+	Subprotocols []string `yaml:"subprotocols,omitempty"`
+	Send         string   `yaml:"send,omitempty"`     // message template
+	Interval     Duration `yaml:"interval,omitempty"` // between sends
+	Expect       string   `yaml:"expect,omitempty"`   // regexp a reply must match
+	Timeout      Duration `yaml:"timeout,omitempty"`  // for each expected reply; default 10s
+	Hold         Duration `yaml:"hold,omitempty"`     // how long to keep the connection open
+}
+
+// GRPCConfig describes the unary call a grpc endpoint makes. The endpoint's
+// body is the request message as JSON and its headers are sent as metadata.
+type GRPCConfig struct {
+	Method     string   `yaml:"method"`                // "package.Service/Method"
+	Protoset   string   `yaml:"protoset,omitempty"`    // FileDescriptorSet; server reflection is used if empty
+	Deadline   Duration `yaml:"deadline,omitempty"`    // per call; none if 0
+	ExpectCode string   `yaml:"expect_code,omitempty"` // status code name or number; default OK
+}
+
+// GraphQLConfig describes the operation a graphql endpoint POSTs to its
+// URL. Variables is a template that must render to a JSON object.
+type GraphQLConfig struct {
+	Query         string `yaml:"query"`                    // query or mutation document
+	Variables     string `yaml:"variables,omitempty"`      // JSON object template
+	OperationName string `yaml:"operation_name,omitempty"` // which operation in Query to run
+}
+
+// SocketEncodings lists the valid values of SocketConfig.Encoding.
+var SocketEncodings = []string{"text", "hex"}
+
+// SocketConfig describes the exchange a tcp or udp endpoint makes on a new
+// connection: send Send, then, if any of the read settings is set, read a
+// response until it ends with Until, holds ReadBytes bytes or matches
+// Expect. With only ReadTimeout set, whatever arrives within it is the
+// response. With Encoding "hex", Send and Until are written in hex and
+// Expect is matched against the response in hex.
+type SocketConfig struct {
+	Send        string   `yaml:"send,omitempty"`         // payload template
+	Encoding    string   `yaml:"encoding,omitempty"`     // "text" (default) or "hex"
+	Until       string   `yaml:"until,omitempty"`        // delimiter that ends the response
+	ReadBytes   int      `yaml:"read_bytes,omitempty"`   // response length
+	Expect      string   `yaml:"expect,omitempty"`       // regexp the response must match
+	ReadTimeout Duration `yaml:"read_timeout,omitempty"` // for the response; default 5s
+}
+
+// Reads reports whether the exchange reads a response.
+func (s *SocketConfig) Reads() bool {
+	return s.Until != "" || s.ReadBytes > 0 || s.Expect != "" || s.ReadTimeout.Duration > 0
+}
+
+// StreamFormats lists the valid values of StreamConfig.Format.
+var StreamFormats = []string{"sse", "lines"}
+
+// StreamConfig describes how an http endpoint's response is read as a
+// stream: as Server-Sent Events (the default), or as "lines", one event per
+// non-empty line as in NDJSON. The stream ends when the server closes it or
+// at the first of MaxEvents and MaxDuration.
+type StreamConfig struct {
+	Format      string   `yaml:"format,omitempty"`
+	Expect      string   `yaml:"expect,omitempty"`       // regexp some event must match
+	MaxEvents   int      `yaml:"max_events,omitempty"`   // 0 = no limit
+	MaxDuration Duration `yaml:"max_duration,omitempty"` // from sending the request; default 1m
 }
 
 // OutputConfig defines reporting settings.
 type OutputConfig struct {
-	Format   string   `yaml:"format"`
-	Interval Duration `yaml:"interval"`
-	File     string   `yaml:"file"`
+	Format   string   `yaml:"format,omitempty"`
+	Interval Duration `yaml:"interval,omitempty"`
+	File     string   `yaml:"file,omitempty"`
 }
 
 // Config is the top-level configuration structure.
 type Config struct {
-	Name        string            `yaml:"name"`
-	Description string            `yaml:"description"`
-	Load        LoadConfig        `yaml:"load"`
-	HTTP        HTTPConfig        `yaml:"http"`
-	Variables   map[string]string `yaml:"variables"`
-	Endpoints   []Endpoint        `yaml:"endpoints"`
-	Output      OutputConfig      `yaml:"output"`
+	Name        string            `yaml:"name,omitempty"`
+	Description string            `yaml:"description,omitempty"`
+	Load        LoadConfig        `yaml:"load,omitempty"`
+	HTTP        HTTPConfig        `yaml:"http,omitempty"`
+	Variables   map[string]string `yaml:"variables,omitempty"`
+	Endpoints   []Endpoint        `yaml:"endpoints,omitempty"`
+	Output      OutputConfig      `yaml:"output,omitempty"`
 }
 
-// Load reads a config file, parses YAML, expands environment variables only
+// Load reads a config file, resolves extends and include, parses YAML, expands environment variables only
 // in the variables section, applies defaults, normalizes stages, and validates.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	return LoadWithOverrides(path, Overrides{})
+}
+
+// LoadWithOverrides is Load with command-line overrides applied after parsing,
+// before defaults and validation.
+func LoadWithOverrides(path string, o Overrides) (*Config, error) {
+	root, comp, err := readComposed(path, o.Profile)
 	if err != nil {
-		return nil, fmt.Errorf("reading config file: %w", err)
+		return nil, err
 	}
 
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := root.Decode(&cfg); err != nil {
 		return nil, fmt.Errorf("parsing config file: %w", err)
 	}
 
@@ -104,24 +303,72 @@ func Load(path string) (*Config, error) {
 	// This allows ${TOKEN} in variables to resolve from the OS environment
 	// without clobbering ${base_url} template tokens in URLs/bodies.
 	for k, v := range cfg.Variables {
-		cfg.Variables[k] = os.ExpandEnv(v)
+		cfg.Variables[k] = o.expandEnv(v)
+	}
+
+	if err := o.Apply(&cfg); err != nil {
+		return nil, err
 	}
 
 	cfg.ApplyDefaults()
 	cfg.NormalizeStages()
 
 	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("validating config: %w", err)
+		return nil, fmt.Errorf("validating config: %w", positioned(err, comp, root))
 	}
 
 	return &cfg, nil
 }
 
+// positioned prefixes each FieldError in err with the file:line:col of its
+// field. Several errors are listed one per line.
+func positioned(err error, comp *composer, root *yaml.Node) error {
+	errs := []error{err}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = j.Unwrap()
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+		var fe *FieldError
+		if errors.As(e, &fe) {
+			if pos := comp.locate(root, fe.Path); pos != "" {
+				msgs[i] = pos + ": " + msgs[i]
+			}
+		}
+	}
+	if len(msgs) == 1 {
+		return errors.New(msgs[0])
+	}
+	return fmt.Errorf("%d problems:\n  %s", len(msgs), strings.Join(msgs, "\n  "))
+}
+
+// Marshal renders cfg as YAML with two-space indentation. Unset fields are
+// omitted, so the output reads like a hand-written config.
+func Marshal(cfg *Config) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // ApplyDefaults sets sensible defaults for unspecified fields.
 func (c *Config) ApplyDefaults() {
 	if c.Load.Mode == "" {
 		c.Load.Mode = "vu"
 	}
+	if c.HTTP.Protocol == "" {
+		c.HTTP.Protocol = "auto"
+	}
+	if c.HTTP.ConnectionMode == "" {
+		c.HTTP.ConnectionMode = "shared"
+	}
 	if c.HTTP.Timeout.Duration == 0 {
 		c.HTTP.Timeout = Duration{30 * time.Second}
 	}
@@ -131,16 +378,88 @@ func (c *Config) ApplyDefaults() {
 	if c.Output.Interval.Duration == 0 {
 		c.Output.Interval = Duration{5 * time.Second}
 	}
+	if a := c.Load.Adaptive; a != nil {
+		if a.Algorithm == "" {
+			a.Algorithm = "aimd"
+		}
+		if a.Percentile == 0 {
+			a.Percentile = 99
+		}
+		if a.MaxErrorRate == nil {
+			rate := 0.01
+			a.MaxErrorRate = &rate
+		}
+		if a.Min == 0 {
+			a.Min = 1
+		}
+		if a.Initial == 0 {
+			a.Initial = a.Min
+		}
+		if a.Interval.Duration == 0 {
+			a.Interval = Duration{5 * time.Second}
+		}
+		if a.Increase == 0 {
+			a.Increase = 1
+		}
+		if a.Decrease == 0 {
+			a.Decrease = 0.75
+		}
+		if a.Kp == 0 && a.Ki == 0 && a.Kd == 0 {
+			a.Kp, a.Ki, a.Kd = 0.5, 0.1, 0.05
+		}
+	}
+	for i := range c.Load.Stages {
+		s := &c.Load.Stages[i]
+		switch s.Ramp {
+		case "sine":
+			if s.Period.Duration == 0 {
+				s.Period = s.Duration
+			}
+		case "spike":
+			if s.Hold.Duration == 0 {
+				s.Hold = Duration{s.Duration.Duration / 10}
+			}
+		case "random_walk":
+			if s.Period.Duration == 0 {
+				s.Period = Duration{time.Second}
+			}
+			if s.Amplitude == 0 {
+				s.Amplitude = max((s.Target-s.Min)/10, 1)
+			}
+		}
+	}
 	for i := range c.Endpoints {
+		if g := c.Endpoints[i].GraphQL; g != nil && c.Endpoints[i].Type == "graphql" {
+			if c.Endpoints[i].Method == "" {
+				c.Endpoints[i].Method = "POST"
+			}
+			if c.Endpoints[i].Name == "" {
+				c.Endpoints[i].Name = g.Operation()
+			}
+			if c.Endpoints[i].Expect.Status == 0 {
+				c.Endpoints[i].Expect.Status = 200
+			}
+		}
 		if c.Endpoints[i].Method == "" {
 			c.Endpoints[i].Method = "GET"
 		}
 		if c.Endpoints[i].Weight == 0 {
 			c.Endpoints[i].Weight = 1
 		}
-		if c.Endpoints[i].Expect.Status == 0 {
+		if c.Endpoints[i].Expect.Status == 0 && c.Endpoints[i].IsHTTP() {
 			c.Endpoints[i].Expect.Status = 200
 		}
+		if ws := c.Endpoints[i].WebSocket; ws != nil && ws.Timeout.Duration == 0 {
+			ws.Timeout = Duration{10 * time.Second}
+		}
+		if st := c.Endpoints[i].Stream; st != nil {
+			if st.Format == "" {
+				st.Format = "sse"
+			}
+			if st.MaxDuration.Duration == 0 {
+				st.MaxDuration = Duration{time.Minute}
+			}
+		}
 	}
 }
 
@@ -149,6 +468,10 @@ func (c *Config) NormalizeStages() {
 	if len(c.Load.Stages) > 0 {
 		return
 	}
+	if a := c.Load.Adaptive; a != nil && a.Duration.Duration > 0 {
+		c.Load.Stages = []Stage{{Duration: a.Duration, Target: a.Max, Ramp: "step"}}
+		return
+	}
 	if c.Load.MaxVUs == 0 {
 		return
 	}
@@ -175,51 +498,226 @@ func (c *Config) NormalizeStages() {
 	c.Load.Stages = stages
 }
 
-// Validate checks that the config has all required fields.
+// Validate checks that the config has all required fields and that every
+// ${var} in a URL, header or body names a variable. It reports every problem
+// found, not just the first.
 func (c *Config) Validate() error {
+	var v validator
 	if len(c.Endpoints) == 0 {
-		return fmt.Errorf("at least one endpoint is required")
+		v.add("endpoints", "at least one endpoint is required")
 	}
 	for i, ep := range c.Endpoints {
+		at := fmt.Sprintf("endpoints[%d]", i)
 		if strings.TrimSpace(ep.URL) == "" {
-			return fmt.Errorf("endpoint[%d] %q: URL is required", i, ep.Name)
+			v.add(at+".url", "endpoint[%d] %q: URL is required", i, ep.Name)
+		} else if socket, _, ok := SplitUnixURL(ep.URL); ok && socket == "" {
+			v.add(at+".url", "endpoint[%d] %q: url %q names no socket (expected unix:///path/to.sock:/request/path)", i, ep.Name, ep.URL)
 		}
+		if ep.ThinkTime.Duration < 0 {
+			v.add(at+".think_time", "endpoint[%d] %q: think_time must be >= 0", i, ep.Name)
+		}
+		c.checkReferences(&v, i, ep)
+		if ep.TLS != nil {
+			ep.TLS.validate(&v, at+".tls", fmt.Sprintf("endpoint[%d] %q: tls", i, ep.Name))
+		}
+		c.validateType(&v, i, ep)
 	}
+	c.HTTP.TLS.validate(&v, "http.tls", "http.tls")
+	c.HTTP.validate(&v)
 	validModes := map[string]bool{"vu": true, "arrival_rate": true}
 	if !validModes[c.Load.Mode] {
-		return fmt.Errorf("load.mode must be \"vu\" or \"arrival_rate\" (got %q)", c.Load.Mode)
+		v.add("load.mode", "load.mode must be \"vu\" or \"arrival_rate\" (got %q)", c.Load.Mode)
 	}
 	if c.Load.MaxRPS < 0 {
-		return fmt.Errorf("load.max_rps must be >= 0")
+		v.add("load.max_rps", "load.max_rps must be >= 0")
+	}
+	if c.Load.GracefulStop.Duration < 0 {
+		v.add("load.graceful_stop", "load.graceful_stop must be >= 0")
+	}
+	if c.Load.Flow && c.Load.Mode == "arrival_rate" {
+		v.add("load.flow", "load.flow is only valid in vu mode")
+	}
+	if c.HTTP.ConnectionMode == "per_vu" && c.Load.Mode == "arrival_rate" {
+		v.add("http.connection_mode", "http.connection_mode per_vu is only valid in vu mode")
 	}
 	if c.Load.MaxRPS > 0 && c.Load.Mode == "arrival_rate" {
-		return fmt.Errorf("load.max_rps is only valid in vu mode")
+		v.add("load.max_rps", "load.max_rps is only valid in vu mode")
 	}
 	if len(c.Load.Stages) == 0 {
-		return fmt.Errorf("load stages are required (use stages or ramp_up/steady_state/ramp_down with max_vus)")
+		v.add("load", "load stages are required (use stages, ramp_up/steady_state/ramp_down with max_vus, or adaptive.duration)")
 	}
 	targetLabel := "VUs"
 	if c.Load.Mode == "arrival_rate" {
 		targetLabel = "RPS"
 	}
 	for i, s := range c.Load.Stages {
+		at := fmt.Sprintf("load.stages[%d]", i)
 		if s.Duration.Duration <= 0 {
-			return fmt.Errorf("stage[%d]: duration must be positive", i)
+			v.add(at+".duration", "stage[%d]: duration must be positive", i)
 		}
 		if s.Target < 0 {
-			return fmt.Errorf("stage[%d]: target %s must be >= 0", i, targetLabel)
+			v.add(at+".target", "stage[%d]: target %s must be >= 0", i, targetLabel)
 		}
-		if s.Ramp != "" && s.Ramp != "linear" && s.Ramp != "step" {
-			return fmt.Errorf("stage[%d]: ramp must be \"linear\" or \"step\" (got %q)", i, s.Ramp)
+		if err := s.validateShape(); err != nil {
+			v.add(at+".ramp", "stage[%d]: %w", i, err)
 		}
 	}
+	c.Load.Adaptive.validate(&v)
 	validFormats := map[string]bool{"console": true, "json": true, "csv": true}
 	if !validFormats[c.Output.Format] {
-		return fmt.Errorf("output.format must be one of: console, json, csv (got %q)", c.Output.Format)
+		v.add("output.format", "output.format must be one of: console, json, csv (got %q)", c.Output.Format)
+	}
+	return errors.Join(v.errs...)
+}
+
+// checkReferences reports ${var} tokens in ep that name no variable. They
+// would otherwise be sent literally.
+func (c *Config) checkReferences(v *validator, i int, ep Endpoint) {
+	at := fmt.Sprintf("endpoints[%d]", i)
+	check := func(path, field, tmpl string) {
+		seen := make(map[string]bool)
+		for _, name := range data.References(tmpl) {
+			if _, ok := c.Variables[name]; !ok && !seen[name] {
+				seen[name] = true
+				v.add(path, "endpoint[%d] %q: %s references undefined variable ${%s}", i, ep.Name, field, name)
+			}
+		}
+	}
+	check(at+".url", "url", ep.URL)
+	names := make([]string, 0, len(ep.Headers))
+	for k := range ep.Headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		check(at+".headers."+k, "header "+k, ep.Headers[k])
+	}
+	check(at+".body", "body", ep.Body)
+	if ep.WebSocket != nil {
+		check(at+".websocket.send", "websocket.send", ep.WebSocket.Send)
+	}
+	if ep.GRPC != nil {
+		check(at+".grpc.method", "grpc.method", ep.GRPC.Method)
+	}
+	if ep.GraphQL != nil {
+		check(at+".graphql.variables", "graphql.variables", ep.GraphQL.Variables)
+	}
+	if ep.Socket != nil {
+		check(at+".socket.send", "socket.send", ep.Socket.Send)
+	}
+}
+
+// FieldError is a validation error about one field, named by its YAML path
+// such as "endpoints[0].url".
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string { return e.Err.Error() }
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// validator collects validation errors.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(path, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{Path: path, Err: fmt.Errorf(format, args...)})
+}
+
+func (h *HTTPConfig) validate(v *validator) {
+	if !slices.Contains(Protocols, h.Protocol) {
+		v.add("http.protocol", "http.protocol must be one of: %s (got %q)", strings.Join(Protocols, ", "), h.Protocol)
+	}
+	if h.MaxConcurrentStreams < 0 {
+		v.add("http.max_concurrent_streams", "http.max_concurrent_streams must be >= 0")
+	}
+	if h.MaxConcurrentStreams > 0 && h.Protocol != "http2" && h.Protocol != "h2c" {
+		v.add("http.max_concurrent_streams", "http.max_concurrent_streams needs http.protocol http2 or h2c")
+	}
+	if h.MaxConnsPerHost < 0 {
+		v.add("http.max_connections_per_host", "http.max_connections_per_host must be >= 0")
+	}
+	if !slices.Contains(ConnectionModes, h.ConnectionMode) {
+		v.add("http.connection_mode", "http.connection_mode must be one of: %s (got %q)", strings.Join(ConnectionModes, ", "), h.ConnectionMode)
+	}
+	if h.MaxIdleConns < 0 {
+		v.add("http.max_idle_connections", "http.max_idle_connections must be >= 0")
+	}
+	if h.MaxIdleConnsPerHost < 0 {
+		v.add("http.max_idle_connections_per_host", "http.max_idle_connections_per_host must be >= 0")
+	}
+	if h.IdleTimeout.Duration < 0 {
+		v.add("http.idle_timeout", "http.idle_timeout must be >= 0")
+	}
+	h.Proxy.validate(v)
+	h.validateNetwork(v)
+}
+
+func (s Stage) validateShape() error {
+	switch s.Ramp {
+	case "", "linear", "step", "exponential", "logarithmic":
+	case "sine":
+		if s.Amplitude <= 0 {
+			return fmt.Errorf("sine ramp requires a positive amplitude")
+		}
+		if s.Period.Duration <= 0 {
+			return fmt.Errorf("sine period must be positive")
+		}
+	case "spike":
+		if s.Hold.Duration <= 0 || s.Hold.Duration > s.Duration.Duration {
+			return fmt.Errorf("spike hold must be positive and no longer than the stage")
+		}
+	case "random_walk":
+		if s.Min < 0 || s.Min > s.Target {
+			return fmt.Errorf("random_walk min must be between 0 and target")
+		}
+		if s.Amplitude <= 0 {
+			return fmt.Errorf("random_walk amplitude must be positive")
+		}
+		if s.Period.Duration <= 0 {
+			return fmt.Errorf("random_walk period must be positive")
+		}
+	default:
+		return fmt.Errorf("ramp must be one of: %s (got %q)", strings.Join(RampShapes, ", "), s.Ramp)
 	}
 	return nil
 }
 
+func (a *AdaptiveConfig) validate(v *validator) {
+	if a == nil {
+		return
+	}
+	if a.Algorithm != "aimd" && a.Algorithm != "pid" {
+		v.add("load.adaptive.algorithm", "load.adaptive.algorithm must be \"aimd\" or \"pid\" (got %q)", a.Algorithm)
+	}
+	if a.TargetLatency.Duration <= 0 {
+		v.add("load.adaptive.target_latency", "load.adaptive.target_latency must be positive")
+	}
+	if a.Percentile <= 0 || a.Percentile > 100 {
+		v.add("load.adaptive.percentile", "load.adaptive.percentile must be in (0, 100]")
+	}
+	if r := a.MaxErrorRate; r != nil && (*r < 0 || *r > 1) {
+		v.add("load.adaptive.max_error_rate", "load.adaptive.max_error_rate must be between 0 and 1")
+	}
+	if a.Min < 1 {
+		v.add("load.adaptive.min", "load.adaptive.min must be >= 1")
+	}
+	if a.Max < a.Min {
+		v.add("load.adaptive.max", "load.adaptive.max must be >= min (%d)", a.Min)
+	}
+	if a.Initial < a.Min || a.Initial > a.Max {
+		v.add("load.adaptive.initial", "load.adaptive.initial must be between min and max")
+	}
+	if a.MaxStep < 0 {
+		v.add("load.adaptive.max_step", "load.adaptive.max_step must be >= 0")
+	}
+	if a.Decrease <= 0 || a.Decrease >= 1 {
+		v.add("load.adaptive.decrease", "load.adaptive.decrease must be between 0 and 1 (exclusive)")
+	}
+}
+
 // TotalDuration returns the sum of all stage durations.
 func (c *Config) TotalDuration() time.Duration {
 	var total time.Duration
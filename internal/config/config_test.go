@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -354,3 +356,487 @@ func TestTotalDuration(t *testing.T) {
 		t.Errorf("expected %v, got %v", expected, got)
 	}
 }
+
+func TestLoad_Adaptive_DefaultsAndDuration(t *testing.T) {
+	yaml := `
+load:
+  adaptive:
+    target_latency: 250ms
+    max: 200
+    duration: 10m
+endpoints:
+  - url: "http://localhost"
+`
+	path := writeTemp(t, yaml)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a := cfg.Load.Adaptive
+	if a.Algorithm != "aimd" || a.Percentile != 99 || a.Min != 1 || a.Initial != 1 || *a.MaxErrorRate != 0.01 {
+		t.Errorf("unexpected defaults: %+v", a)
+	}
+
+	// An explicit zero means no errors are tolerated, not the default.
+	path = writeTemp(t, strings.Replace(yaml, "max: 200", "max: 200\n    max_error_rate: 0", 1))
+	if cfg, err = Load(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := cfg.Load.Adaptive.MaxErrorRate; r == nil || *r != 0 {
+		t.Errorf("max_error_rate = %v, want an explicit 0", r)
+	}
+	if cfg.TotalDuration() != 10*time.Minute {
+		t.Errorf("expected adaptive.duration to define a 10m run, got %v", cfg.TotalDuration())
+	}
+}
+
+func TestValidate_Adaptive_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"missing target_latency": "max: 10",
+		"max below min":          "target_latency: 100ms\n    min: 5\n    max: 2",
+		"bad algorithm":          "target_latency: 100ms\n    max: 10\n    algorithm: fuzzy",
+		"bad decrease":           "target_latency: 100ms\n    max: 10\n    decrease: 1.5",
+		"bad max_error_rate":     "target_latency: 100ms\n    max: 10\n    max_error_rate: 1.5",
+	}
+	for name, block := range cases {
+		yaml := `
+load:
+  adaptive:
+    ` + block + `
+    duration: 1m
+endpoints:
+  - url: "http://localhost"
+`
+		path := writeTemp(t, yaml)
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestLoad_GracefulStop(t *testing.T) {
+	yaml := `
+load:
+  graceful_stop: 30s
+  stages:
+    - duration: 5s
+      target: 1
+endpoints:
+  - url: "http://localhost"
+`
+	cfg, err := Load(writeTemp(t, yaml))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Load.GracefulStop.Duration != 30*time.Second {
+		t.Errorf("expected graceful_stop 30s, got %v", cfg.Load.GracefulStop.Duration)
+	}
+}
+
+func TestValidate_GracefulStop_Negative_Error(t *testing.T) {
+	yaml := `
+load:
+  graceful_stop: -1s
+  stages:
+    - duration: 5s
+      target: 1
+endpoints:
+  - url: "http://localhost"
+`
+	if _, err := Load(writeTemp(t, yaml)); err == nil {
+		t.Error("expected validation error for negative graceful_stop")
+	}
+}
+
+func TestLoad_RampShapeDefaults(t *testing.T) {
+	yaml := `
+load:
+  stages:
+    - duration: 1h
+      target: 100
+      ramp: sine
+      amplitude: 50
+    - duration: 10m
+      target: 500
+      ramp: spike
+    - duration: 10m
+      target: 80
+      min: 20
+      ramp: random_walk
+endpoints:
+  - url: "http://localhost"
+`
+	cfg, err := Load(writeTemp(t, yaml))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := cfg.Load.Stages
+	if s[0].Period.Duration != time.Hour {
+		t.Errorf("sine period: expected stage duration, got %v", s[0].Period.Duration)
+	}
+	if s[1].Hold.Duration != time.Minute {
+		t.Errorf("spike hold: expected 10%% of duration, got %v", s[1].Hold.Duration)
+	}
+	if s[2].Period.Duration != time.Second || s[2].Amplitude != 6 {
+		t.Errorf("random_walk: expected 1s period and amplitude 6, got %v and %d", s[2].Period.Duration, s[2].Amplitude)
+	}
+}
+
+func TestValidate_RampShape_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown ramp":        "ramp: wobble",
+		"sine no amplitude":   "ramp: sine",
+		"spike hold too long": "ramp: spike\n      hold: 1m",
+		"walk min > target":   "ramp: random_walk\n      min: 20",
+	}
+	for name, fields := range cases {
+		yaml := `
+load:
+  stages:
+    - duration: 30s
+      target: 10
+      ` + fields + `
+endpoints:
+  - url: "http://localhost"
+`
+		if _, err := Load(writeTemp(t, yaml)); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestValidate_Flow_ArrivalRate_Error(t *testing.T) {
+	yaml := `
+load:
+  mode: arrival_rate
+  flow: true
+  stages:
+    - duration: 10s
+      target: 10
+endpoints:
+  - url: "http://localhost"
+`
+	if _, err := Load(writeTemp(t, yaml)); err == nil {
+		t.Error("expected validation error: flow not valid in arrival_rate mode")
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	cfg := &Config{
+		Name:      "Round trip",
+		Load:      LoadConfig{Flow: true, Stages: []Stage{{Duration: Duration{30 * time.Second}, Target: 5}}},
+		Variables: map[string]string{"base_url": "http://localhost"},
+		Endpoints: []Endpoint{{
+			Name:      "login",
+			Method:    "POST",
+			URL:       "${base_url}/login",
+			Body:      "{\n  \"user\": \"a\"\n}",
+			ThinkTime: Duration{1500 * time.Millisecond},
+		}},
+	}
+	out, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, unwanted := range []string{"max_rps", "adaptive", "insecure_skip_verify", "weight"} {
+		if strings.Contains(string(out), unwanted) {
+			t.Errorf("expected unset field %q to be omitted:\n%s", unwanted, out)
+		}
+	}
+
+	loaded, err := Load(writeTemp(t, string(out)))
+	if err != nil {
+		t.Fatalf("marshaled config failed to load: %v\n%s", err, out)
+	}
+	ep := loaded.Endpoints[0]
+	if !loaded.Load.Flow || ep.Body != cfg.Endpoints[0].Body || ep.ThinkTime.Duration != 1500*time.Millisecond {
+		t.Errorf("round trip lost fields:\n%s", out)
+	}
+}
+
+func TestValidate_HTTPProtocol(t *testing.T) {
+	for _, tc := range []struct {
+		http string
+		want string // "" means valid
+	}{
+		{"{}", ""},
+		{"{protocol: h2c, max_concurrent_streams: 50}", ""},
+		{"{protocol: http2, max_connections_per_host: 4}", ""},
+		{"{protocol: spdy}", "http.protocol must be one of"},
+		{"{max_concurrent_streams: 10}", "needs http.protocol http2 or h2c"},
+		{"{protocol: http2, max_concurrent_streams: -1}", "max_concurrent_streams must be >= 0"},
+		{"{max_connections_per_host: -1}", "max_connections_per_host must be >= 0"},
+		{"{connection_mode: per_vu, max_idle_connections_per_host: 2, idle_timeout: 5s}", ""},
+		{"{connection_mode: pooled}", "http.connection_mode must be one of"},
+		{"{idle_timeout: -1s}", "http.idle_timeout must be >= 0"},
+		{"{proxy: {url: 'socks5://user:pw@proxy:1080', no_proxy: [localhost]}, local_addresses: [10.0.0.5, '::1'], resolve: {api.example.com: 10.1.2.3}}", ""},
+		{"{proxy: {from_env: true}}", ""},
+		{"{proxy: {url: 'ftp://proxy:21'}}", "http.proxy.url \"ftp://proxy:21\" must be scheme://host:port"},
+		{"{proxy: {url: 'http://proxy:3128', from_env: true}}", "set url or from_env, not both"},
+		{"{proxy: {no_proxy: [localhost]}}", "no_proxy needs http.proxy.url"},
+		{"{local_addresses: [eth0]}", `http.local_addresses: "eth0" is not an IP address`},
+		{"{resolve: {api.example.com: somewhere}}", `api.example.com maps to "somewhere"`},
+		{"{unix_socket: /run/app.sock}", ""},
+		{"{unix_socket: 'unix://'}", `http.unix_socket "unix://" names no socket`},
+		{"{unix_socket: /run/app.sock, proxy: {from_env: true}}", "cannot be combined with proxy"},
+	} {
+		path := writeTemp(t, `load: {max_vus: 1, steady_state: 5s}
+http: `+tc.http+`
+endpoints:
+  - url: http://localhost/
+`)
+		cfg, err := Load(path)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("http %s: unexpected error: %v", tc.http, err)
+			} else if cfg.HTTP.Protocol == "" || cfg.HTTP.ConnectionMode == "" {
+				t.Errorf("http %s: protocol or connection mode not defaulted", tc.http)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("http %s: err = %v, want %q", tc.http, err, tc.want)
+		}
+	}
+}
+
+func TestValidate_PerVU_ArrivalRate_Error(t *testing.T) {
+	path := writeTemp(t, `load:
+  mode: arrival_rate
+  stages: [{duration: 5s, target: 10}]
+http: {connection_mode: per_vu}
+endpoints:
+  - url: http://localhost/
+`)
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "per_vu is only valid in vu mode") {
+		t.Errorf("err = %v, want per_vu rejected in arrival_rate mode", err)
+	}
+}
+
+func TestSplitUnixURL(t *testing.T) {
+	for raw, want := range map[string][3]string{
+		"unix:///run/app.sock:/v1/items?x=1": {"/run/app.sock", "/v1/items?x=1", "true"},
+		"unix:///run/app.sock":               {"/run/app.sock", "/", "true"},
+		"unix://app.sock:health":             {"app.sock", "/health", "true"},
+		"http://localhost/":                  {"", "", "false"},
+	} {
+		socket, path, ok := SplitUnixURL(raw)
+		if got := [3]string{socket, path, fmt.Sprint(ok)}; got != want {
+			t.Errorf("SplitUnixURL(%q) = %q, want %q", raw, got, want)
+		}
+	}
+
+	path := writeTemp(t, `load: {max_vus: 1, steady_state: 5s}
+endpoints:
+  - url: "unix://:/health"
+`)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "names no socket") {
+		t.Errorf("err = %v, want a missing socket error", err)
+	}
+}
+
+func TestValidate_WebSocket(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		want     string // "" means valid
+	}{
+		{"{type: websocket, url: 'ws://localhost/chat'}", ""},
+		{"{type: websocket, url: '${base}/chat', websocket: {send: hi, interval: 1s, expect: '^HI', hold: 30s}}", ""},
+		{"{type: ftp, url: 'http://localhost/'}", "type must be one of: http, websocket"},
+		{"{url: 'http://localhost/', websocket: {send: hi}}", "websocket settings need type: websocket"},
+		{"{type: websocket, url: 'ftp://localhost/'}", "websocket url must start with ws://"},
+		{"{type: websocket, url: 'ws://localhost/', websocket: {expect: '('}}", "websocket.expect: error parsing regexp"},
+		{"{type: websocket, url: 'ws://localhost/', websocket: {interval: 1s}}", "websocket.interval needs a message to send"},
+		{"{type: websocket, url: 'ws://localhost/', websocket: {hold: -1s}}", "websocket.hold must be >= 0"},
+		{"{type: websocket, url: 'ws://localhost/', websocket: {send: '${missing}'}}", "missing"},
+	} {
+		path := writeTemp(t, `load: {max_vus: 1, steady_state: 5s}
+variables: {base: 'ws://localhost'}
+endpoints:
+  - `+tc.endpoint+`
+`)
+		cfg, err := Load(path)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("endpoint %s: unexpected error: %v", tc.endpoint, err)
+			} else if ep := cfg.Endpoints[0]; ep.Expect.Status != 0 || (ep.WebSocket != nil && ep.WebSocket.Timeout.Duration != 10*time.Second) {
+				t.Errorf("endpoint %s: status %d expected, timeout not defaulted", tc.endpoint, ep.Expect.Status)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("endpoint %s: err = %v, want %q", tc.endpoint, err, tc.want)
+		}
+	}
+}
+
+func TestValidate_GRPC(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		want     string // "" means valid
+	}{
+		{"{type: grpc, url: 'grpc://localhost:50051', grpc: {method: helloworld.Greeter/SayHello}}", ""},
+		{"{type: grpc, url: '${base}', body: '{\"name\": \"x\"}', grpc: {method: /helloworld.Greeter/SayHello, protoset: api.protoset, deadline: 2s, expect_code: NOT_FOUND}}", ""},
+		{"{type: grpc, url: 'unix:///run/api.sock', grpc: {method: a.B/C, expect_code: '5'}}", ""},
+		{"{url: 'http://localhost/', grpc: {method: a.B/C}}", "grpc settings need type: grpc"},
+		{"{type: grpc, url: 'ws://localhost/', grpc: {method: a.B/C}}", "grpc url must start with grpc://"},
+		{"{type: grpc, url: 'grpc://localhost/'}", "grpc.method is required"},
+		{"{type: grpc, url: 'grpc://localhost/', grpc: {method: SayHello}}", "grpc.method must be package.Service/Method"},
+		{"{type: grpc, url: 'grpc://localhost/', grpc: {method: a.B/C, deadline: -1s}}", "grpc.deadline must be >= 0"},
+		{"{type: grpc, url: 'grpc://localhost/', grpc: {method: a.B/C, expect_code: NOPE}}", `unknown grpc status code "NOPE"`},
+		{"{type: grpc, url: 'grpc://localhost/', grpc: {method: '${missing}/C'}}", "missing"},
+	} {
+		path := writeTemp(t, `load: {max_vus: 1, steady_state: 5s}
+variables: {base: 'grpc://localhost:50051'}
+endpoints:
+  - `+tc.endpoint+`
+`)
+		_, err := Load(path)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("endpoint %s: unexpected error: %v", tc.endpoint, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("endpoint %s: err = %v, want %q", tc.endpoint, err, tc.want)
+		}
+	}
+}
+
+func TestValidate_Stream(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		want     string // "" means valid
+	}{
+		{"{url: 'http://localhost/events', stream: {}}", ""},
+		{"{url: 'http://localhost/v1/completions', method: POST, stream: {format: lines, expect: '\"done\":true', max_events: 500, max_duration: 2m}}", ""},
+		{"{type: websocket, url: 'ws://localhost/', stream: {}}", "stream settings need type: http"},
+		{"{url: 'http://localhost/', stream: {format: chunks}}", "stream.format must be one of: sse, lines"},
+		{"{url: 'http://localhost/', stream: {expect: '['}}", "stream.expect: error parsing regexp"},
+		{"{url: 'http://localhost/', stream: {max_events: -1}}", "stream.max_events must be >= 0"},
+		{"{url: 'http://localhost/', stream: {max_duration: -1s}}", "stream.max_duration must be >= 0"},
+	} {
+		path := writeTemp(t, `load: {max_vus: 1, steady_state: 5s}
+endpoints:
+  - `+tc.endpoint+`
+`)
+		cfg, err := Load(path)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("endpoint %s: unexpected error: %v", tc.endpoint, err)
+			} else if st := cfg.Endpoints[0].Stream; st.Format == "" || st.MaxDuration.Duration == 0 {
+				t.Errorf("endpoint %s: format and max_duration not defaulted: %+v", tc.endpoint, st)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("endpoint %s: err = %v, want %q", tc.endpoint, err, tc.want)
+		}
+	}
+}
+
+func TestValidate_GraphQL(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		want     string // "" means valid
+	}{
+		{"{type: graphql, url: 'http://localhost/graphql', graphql: {query: '{ viewer { id } }'}}", ""},
+		{"{type: graphql, url: 'http://localhost/graphql', graphql: {query: 'query Q($id: ID!) { user(id: $id) { id } }', variables: '{\"id\": \"${id}\"}'}}", ""},
+		{"{url: 'http://localhost/', graphql: {query: '{ a }'}}", "graphql settings need type: graphql"},
+		{"{type: graphql, url: 'http://localhost/graphql'}", "graphql.query is required"},
+		{"{type: graphql, url: 'http://localhost/graphql', graphql: {query: 'fragment F on User { id }'}}", "graphql.query defines no query, mutation or subscription"},
+		{"{type: graphql, url: 'http://localhost/graphql', graphql: {query: '{ a }', variables: '[1]'}}", "graphql.variables must be a JSON object"},
+		{"{type: graphql, url: 'http://localhost/graphql', method: GET, graphql: {query: '{ a }'}}", "graphql endpoints are sent with POST"},
+		{"{type: graphql, url: 'http://localhost/graphql', body: '{}', graphql: {query: '{ a }'}}", "build their body from graphql.query"},
+		{"{type: graphql, url: 'http://localhost/graphql', graphql: {query: '{ a }', variables: '{\"x\": \"${missing}\"}'}}", "graphql.variables references undefined variable ${missing}"},
+	} {
+		path := writeTemp(t, `load: {max_vus: 1, steady_state: 5s}
+variables: {id: u-1}
+endpoints:
+  - `+tc.endpoint+`
+`)
+		cfg, err := Load(path)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("endpoint %s: unexpected error: %v", tc.endpoint, err)
+			} else if ep := cfg.Endpoints[0]; ep.Method != "POST" || ep.Expect.Status != 200 || ep.Name == "" {
+				t.Errorf("endpoint %s: method %q, status %d, name %q not defaulted", tc.endpoint, ep.Method, ep.Expect.Status, ep.Name)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("endpoint %s: err = %v, want %q", tc.endpoint, err, tc.want)
+		}
+	}
+}
+
+func TestGraphQLConfig_Operation(t *testing.T) {
+	for _, tc := range []struct {
+		query, operationName, want string
+	}{
+		{"{ viewer { id } }", "", "query"},
+		{"query { viewer { id } }", "", "query"},
+		{"query GetUser($id: ID!) { user(id: $id) { id } }", "", "GetUser"},
+		{"mutation CreatePost($in: PostInput = {title: \"{\"}) @audit { createPost(input: $in) { id } }", "", "CreatePost"},
+		{"# query Commented\nfragment F on User { id }\nsubscription OnPost { post { ...F } }", "", "OnPost"},
+		{"query A { a }\nquery B { b }", "B", "B"},
+		{"fragment F on User { id }", "", ""},
+	} {
+		g := &GraphQLConfig{Query: tc.query, OperationName: tc.operationName}
+		if got := g.Operation(); got != tc.want {
+			t.Errorf("Operation(%q) = %q, want %q", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestValidate_Socket(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		want     string // "" means valid
+	}{
+		{"{type: tcp, url: 'tcp://localhost:6379', socket: {send: \"PING\\r\\n\", until: \"\\r\\n\", expect: '^\\+PONG'}}", ""},
+		{"{type: udp, url: 'udp://${host}:53', socket: {send: 'ab cd ${id}', encoding: hex, read_bytes: 12, read_timeout: 1s}}", ""},
+		{"{type: tcp, url: 'tcp://localhost:7'}", ""},
+		{"{url: 'http://localhost/', socket: {send: x}}", "socket settings need type: tcp or udp"},
+		{"{type: tcp, url: 'udp://localhost:53'}", "tcp url must be tcp://host:port"},
+		{"{type: udp, url: 'udp://localhost'}", "udp url needs a port"},
+		{"{type: tcp, url: 'tcp://localhost:1', socket: {encoding: base64}}", "socket.encoding must be one of: text, hex"},
+		{"{type: tcp, url: 'tcp://localhost:1', socket: {encoding: hex, send: 'zz'}}", "socket.send: invalid hex"},
+		{"{type: tcp, url: 'tcp://localhost:1', socket: {encoding: hex, until: '0'}}", "socket.until: invalid hex"},
+		{"{type: tcp, url: 'tcp://localhost:1', socket: {expect: '('}}", "socket.expect: error parsing regexp"},
+		{"{type: tcp, url: 'tcp://localhost:1', socket: {read_bytes: -1}}", "socket.read_bytes must be >= 0"},
+		{"{type: tcp, url: 'tcp://localhost:1', socket: {read_timeout: -1s}}", "socket.read_timeout must be >= 0"},
+		{"{type: tcp, url: 'tcp://localhost:1', socket: {send: '${missing}'}}", "socket.send references undefined variable ${missing}"},
+	} {
+		path := writeTemp(t, `load: {max_vus: 1, steady_state: 5s}
+variables: {host: localhost, id: ef}
+endpoints:
+  - `+tc.endpoint+`
+`)
+		cfg, err := Load(path)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("endpoint %s: unexpected error: %v", tc.endpoint, err)
+			} else if ep := cfg.Endpoints[0]; ep.Expect.Status != 0 {
+				t.Errorf("endpoint %s: status %d expected", tc.endpoint, ep.Expect.Status)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("endpoint %s: err = %v, want %q", tc.endpoint, err, tc.want)
+		}
+	}
+}
+
+func TestValidate_WebSocketHTTP2(t *testing.T) {
+	path := writeTemp(t, `load: {max_vus: 1, steady_state: 5s}
+http: {protocol: http2}
+endpoints:
+  - {type: websocket, url: 'wss://localhost/'}
+`)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "websocket endpoints need HTTP/1.1") {
+		t.Errorf("err = %v", err)
+	}
+}
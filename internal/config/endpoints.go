@@ -0,0 +1,266 @@
+package config
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jvreagan/perf-test/internal/grpc"
+)
+
+// validateType checks the settings specific to ep's type.
+func (c *Config) validateType(v *validator, i int, ep Endpoint) {
+	at := fmt.Sprintf("endpoints[%d]", i)
+	label := fmt.Sprintf("endpoint[%d] %q", i, ep.Name)
+	if ep.Type != "" && !slices.Contains(EndpointTypes, ep.Type) {
+		v.add(at+".type", "%s: type must be one of: %s (got %q)", label, strings.Join(EndpointTypes, ", "), ep.Type)
+		return
+	}
+	if ep.WebSocket != nil && ep.Type != "websocket" {
+		v.add(at+".websocket", "%s: websocket settings need type: websocket", label)
+	}
+	if ep.GRPC != nil && ep.Type != "grpc" {
+		v.add(at+".grpc", "%s: grpc settings need type: grpc", label)
+	}
+	if ep.GraphQL != nil && ep.Type != "graphql" {
+		v.add(at+".graphql", "%s: graphql settings need type: graphql", label)
+	}
+	if ep.Socket != nil && ep.Type != "tcp" && ep.Type != "udp" {
+		v.add(at+".socket", "%s: socket settings need type: tcp or udp", label)
+	}
+	if ep.Stream != nil {
+		if !ep.IsHTTP() {
+			v.add(at+".stream", "%s: stream settings need type: http", label)
+		}
+		validateStream(v, at, label, ep.Stream)
+	}
+	switch ep.Type {
+	case "websocket":
+		c.validateWebSocket(v, at, label, ep)
+	case "grpc":
+		validateGRPC(v, at, label, ep)
+	case "graphql":
+		validateGraphQL(v, at, label, ep)
+	case "tcp", "udp":
+		validateSocket(v, at, label, ep)
+	}
+}
+
+func (c *Config) validateWebSocket(v *validator, at, label string, ep Endpoint) {
+	if scheme, _, ok := strings.Cut(ep.URL, "://"); ok && !strings.Contains(scheme, "$") {
+		switch scheme {
+		case "ws", "wss", "http", "https", "unix":
+		default:
+			v.add(at+".url", "%s: websocket url must start with ws://, wss://, http:// or https:// (got %q)", label, ep.URL)
+		}
+	}
+	if c.HTTP.Protocol == "http2" || c.HTTP.Protocol == "h2c" {
+		v.add(at+".type", "%s: websocket endpoints need HTTP/1.1 (http.protocol auto or http1)", label)
+	}
+	ws := ep.WebSocket
+	if ws == nil {
+		return
+	}
+	if _, err := regexp.Compile(ws.Expect); err != nil {
+		v.add(at+".websocket.expect", "%s: websocket.expect: %w", label, err)
+	}
+	for _, d := range []struct {
+		name string
+		d    Duration
+	}{{"interval", ws.Interval}, {"timeout", ws.Timeout}, {"hold", ws.Hold}} {
+		if d.d.Duration < 0 {
+			v.add(at+".websocket."+d.name, "%s: websocket.%s must be >= 0", label, d.name)
+		}
+	}
+	if ws.Interval.Duration > 0 && ws.Send == "" {
+		v.add(at+".websocket.interval", "%s: websocket.interval needs a message to send", label)
+	}
+}
+
+func validateGRPC(v *validator, at, label string, ep Endpoint) {
+	if scheme, _, ok := strings.Cut(ep.URL, "://"); ok && !strings.Contains(scheme, "$") {
+		switch scheme {
+		case "grpc", "grpcs", "http", "https", "unix":
+		default:
+			v.add(at+".url", "%s: grpc url must start with grpc://, grpcs://, http:// or https:// (got %q)", label, ep.URL)
+		}
+	}
+	g := ep.GRPC
+	if g == nil || g.Method == "" {
+		v.add(at+".grpc.method", "%s: grpc.method is required", label)
+		return
+	}
+	if service, method, ok := strings.Cut(strings.TrimPrefix(g.Method, "/"), "/"); !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		v.add(at+".grpc.method", "%s: grpc.method must be package.Service/Method (got %q)", label, g.Method)
+	}
+	if g.Deadline.Duration < 0 {
+		v.add(at+".grpc.deadline", "%s: grpc.deadline must be >= 0", label)
+	}
+	if g.ExpectCode != "" {
+		if _, err := grpc.ParseCode(g.ExpectCode); err != nil {
+			v.add(at+".grpc.expect_code", "%s: grpc.expect_code: %w", label, err)
+		}
+	}
+}
+
+func validateStream(v *validator, at, label string, st *StreamConfig) {
+	if st.Format != "" && !slices.Contains(StreamFormats, st.Format) {
+		v.add(at+".stream.format", "%s: stream.format must be one of: %s (got %q)", label, strings.Join(StreamFormats, ", "), st.Format)
+	}
+	if _, err := regexp.Compile(st.Expect); err != nil {
+		v.add(at+".stream.expect", "%s: stream.expect: %w", label, err)
+	}
+	if st.MaxEvents < 0 {
+		v.add(at+".stream.max_events", "%s: stream.max_events must be >= 0", label)
+	}
+	if st.MaxDuration.Duration < 0 {
+		v.add(at+".stream.max_duration", "%s: stream.max_duration must be >= 0", label)
+	}
+}
+
+func validateGraphQL(v *validator, at, label string, ep Endpoint) {
+	if ep.Method != "POST" {
+		v.add(at+".method", "%s: graphql endpoints are sent with POST (got %q)", label, ep.Method)
+	}
+	if ep.Body != "" {
+		v.add(at+".body", "%s: graphql endpoints build their body from graphql.query and graphql.variables", label)
+	}
+	g := ep.GraphQL
+	if g == nil || strings.TrimSpace(g.Query) == "" {
+		v.add(at+".graphql.query", "%s: graphql.query is required", label)
+		return
+	}
+	if kind, _ := firstOperation(g.Query); kind == "" {
+		v.add(at+".graphql.query", "%s: graphql.query defines no query, mutation or subscription", label)
+	}
+	if g.Variables != "" && !strings.Contains(g.Variables, "${") {
+		var vars map[string]any
+		if err := json.Unmarshal([]byte(g.Variables), &vars); err != nil {
+			v.add(at+".graphql.variables", "%s: graphql.variables must be a JSON object: %w", label, err)
+		}
+	}
+}
+
+// Operation returns the name a graphql endpoint's metrics are reported
+// under: OperationName, else the name of the first operation in Query,
+// else that operation's type, such as "query".
+func (g *GraphQLConfig) Operation() string {
+	if g.OperationName != "" {
+		return g.OperationName
+	}
+	kind, name := firstOperation(g.Query)
+	if name != "" {
+		return name
+	}
+	return kind
+}
+
+// firstOperation returns the type and name of the first operation defined
+// in a GraphQL document, skipping fragments. A bare selection set is an
+// anonymous query. kind is empty if the document defines no operation.
+func firstOperation(doc string) (kind, name string) {
+	var words []string
+	depth := 0
+	for i := 0; i < len(doc); i++ {
+		c := doc[i]
+		switch {
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			if strings.HasPrefix(doc[i:], `"""`) {
+				end := strings.Index(doc[i+3:], `"""`)
+				if end < 0 {
+					return "", ""
+				}
+				i += end + 5
+				continue
+			}
+			for i++; i < len(doc) && doc[i] != '"'; i++ {
+				if doc[i] == '\\' {
+					i++
+				}
+			}
+		case c == '{' || c == '(' || c == '[':
+			if depth == 0 && c == '{' {
+				if len(words) == 0 {
+					return "query", ""
+				}
+				switch words[0] {
+				case "query", "mutation", "subscription":
+					if len(words) > 1 {
+						name = words[1]
+					}
+					return words[0], name
+				}
+				words = words[:0] // a fragment's selection set
+			}
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+		case depth == 0 && (c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'):
+			j := i
+			for j < len(doc) && (doc[j] == '_' || 'a' <= doc[j] && doc[j] <= 'z' || 'A' <= doc[j] && doc[j] <= 'Z' || '0' <= doc[j] && doc[j] <= '9') {
+				j++
+			}
+			// Directive names are not operation names.
+			if i == 0 || doc[i-1] != '@' {
+				words = append(words, doc[i:j])
+			}
+			i = j - 1
+		}
+	}
+	return "", ""
+}
+
+func validateSocket(v *validator, at, label string, ep Endpoint) {
+	if !strings.Contains(ep.URL, "${") {
+		u, err := url.Parse(ep.URL)
+		switch {
+		case err != nil || u.Scheme != ep.Type:
+			v.add(at+".url", "%s: %s url must be %s://host:port (got %q)", label, ep.Type, ep.Type, ep.URL)
+		case u.Port() == "":
+			v.add(at+".url", "%s: %s url needs a port (got %q)", label, ep.Type, ep.URL)
+		}
+	}
+	s := ep.Socket
+	if s == nil {
+		return
+	}
+	if s.Encoding != "" && !slices.Contains(SocketEncodings, s.Encoding) {
+		v.add(at+".socket.encoding", "%s: socket.encoding must be one of: %s (got %q)", label, strings.Join(SocketEncodings, ", "), s.Encoding)
+	}
+	if s.Encoding == "hex" {
+		if !strings.Contains(s.Send, "${") {
+			if _, err := DecodeHex(s.Send); err != nil {
+				v.add(at+".socket.send", "%s: socket.send: %w", label, err)
+			}
+		}
+		if _, err := DecodeHex(s.Until); err != nil {
+			v.add(at+".socket.until", "%s: socket.until: %w", label, err)
+		}
+	}
+	if _, err := regexp.Compile(s.Expect); err != nil {
+		v.add(at+".socket.expect", "%s: socket.expect: %w", label, err)
+	}
+	if s.ReadBytes < 0 {
+		v.add(at+".socket.read_bytes", "%s: socket.read_bytes must be >= 0", label)
+	}
+	if s.ReadTimeout.Duration < 0 {
+		v.add(at+".socket.read_timeout", "%s: socket.read_timeout must be >= 0", label)
+	}
+}
+
+// DecodeHex decodes a hex payload, ignoring whitespace between bytes.
+func DecodeHex(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %w", err)
+	}
+	return b, nil
+}
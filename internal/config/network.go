@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ProxyConfig selects an outbound proxy. With neither URL nor FromEnv set,
+// requests go direct.
+type ProxyConfig struct {
+	URL     string   `yaml:"url,omitempty"`      // http://, https://, socks5:// or socks5h:// proxy, with optional user:password@
+	FromEnv bool     `yaml:"from_env,omitempty"` // use HTTP_PROXY, HTTPS_PROXY and NO_PROXY instead of url
+	NoProxy []string `yaml:"no_proxy,omitempty"` // hosts, domains, IPs or CIDRs to reach directly when url is set
+}
+
+// ProxySchemes lists the proxy URL schemes that are supported.
+var ProxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+func (p *ProxyConfig) validate(v *validator) {
+	if p.URL != "" && p.FromEnv {
+		v.add("http.proxy", "http.proxy: set url or from_env, not both")
+	}
+	if p.URL != "" {
+		u, err := url.Parse(p.URL)
+		switch {
+		case err != nil:
+			v.add("http.proxy.url", "http.proxy.url: %w", err)
+		case !slices.Contains(ProxySchemes, u.Scheme) || u.Host == "":
+			v.add("http.proxy.url", "http.proxy.url %q must be scheme://host:port with scheme %s", p.URL, strings.Join(ProxySchemes, ", "))
+		}
+	}
+	if len(p.NoProxy) > 0 && p.URL == "" {
+		v.add("http.proxy.no_proxy", "http.proxy.no_proxy needs http.proxy.url (from_env uses NO_PROXY)")
+	}
+}
+
+// UnixPrefix starts endpoint URLs that target a Unix domain socket.
+const UnixPrefix = "unix://"
+
+// SplitUnixURL splits a URL of the form unix:///path/to.sock:/request/path
+// into the socket path and the request path, which defaults to "/". ok is
+// false for other URLs.
+func SplitUnixURL(raw string) (socket, path string, ok bool) {
+	rest, ok := strings.CutPrefix(raw, UnixPrefix)
+	if !ok {
+		return "", "", false
+	}
+	socket, path, _ = strings.Cut(rest, ":")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return socket, path, true
+}
+
+// validateNetwork checks local_addresses, resolve and unix_socket.
+func (h *HTTPConfig) validateNetwork(v *validator) {
+	if h.UnixSocket != "" && strings.TrimPrefix(h.UnixSocket, UnixPrefix) == "" {
+		v.add("http.unix_socket", "http.unix_socket %q names no socket", h.UnixSocket)
+	}
+	if h.UnixSocket != "" && (h.Proxy.URL != "" || h.Proxy.FromEnv || len(h.LocalAddresses) > 0 || len(h.Resolve) > 0) {
+		v.add("http.unix_socket", "http.unix_socket cannot be combined with proxy, local_addresses or resolve")
+	}
+	for i, a := range h.LocalAddresses {
+		if net.ParseIP(a) == nil {
+			v.add(fmt.Sprintf("http.local_addresses[%d]", i), "http.local_addresses: %q is not an IP address", a)
+		}
+	}
+	hosts := make([]string, 0, len(h.Resolve))
+	for host := range h.Resolve {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		if net.ParseIP(h.Resolve[host]) == nil {
+			v.add("http.resolve."+host, "http.resolve: %s maps to %q, which is not an IP address", host, h.Resolve[host])
+		}
+	}
+}
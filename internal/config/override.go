@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides are command-line changes applied to a config after it is parsed
+// and before defaults are applied and it is validated.
+type Overrides struct {
+	// Set holds "path=value" assignments such as "load.max_vus=200" or
+	// "endpoints[0].url=http://localhost/". Values are parsed as YAML for
+	// the field's type, so durations, numbers and booleans work.
+	Set []string
+	// Vars are merged into the variables section, replacing existing values.
+	Vars map[string]string
+	// Profile names an entry in the config's profiles to merge over load.
+	Profile string
+	// Env supplies values for ${NAME} references in variables that are not
+	// set in the process environment.
+	Env map[string]string
+}
+
+// Apply applies the overrides to c.
+func (o Overrides) Apply(c *Config) error {
+	for _, s := range o.Set {
+		path, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("invalid --set %q (expected path=value)", s)
+		}
+		if err := c.Set(strings.TrimSpace(path), value); err != nil {
+			return fmt.Errorf("--set %s: %w", path, err)
+		}
+	}
+	if len(o.Vars) > 0 && c.Variables == nil {
+		c.Variables = make(map[string]string, len(o.Vars))
+	}
+	for k, v := range o.Vars {
+		c.Variables[k] = v
+	}
+	return nil
+}
+
+// expandEnv resolves ${NAME} and $NAME from the process environment, falling
+// back to o.Env.
+func (o Overrides) expandEnv(s string) string {
+	return os.Expand(s, func(name string) string {
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		return o.Env[name]
+	})
+}
+
+// Set assigns value to the field at path, named by YAML keys separated by
+// dots. List elements are addressed by index: "endpoints[1].weight" or
+// "endpoints.1.weight"; map entries by key: "variables.base_url".
+func (c *Config) Set(path, value string) error {
+	if path == "" {
+		return fmt.Errorf("empty path")
+	}
+	keys := strings.Split(strings.NewReplacer("[", ".", "]", "").Replace(path), ".")
+	return setPath(reflect.ValueOf(c).Elem(), keys, value)
+}
+
+func setPath(v reflect.Value, keys []string, value string) error {
+	if len(keys) == 0 {
+		return setValue(v, value)
+	}
+	key := keys[0]
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setPath(v.Elem(), keys, value)
+
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(Duration{}) {
+			break
+		}
+		for i := 0; i < v.NumField(); i++ {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+			if name == key {
+				return setPath(v.Field(i), keys[1:], value)
+			}
+		}
+		return fmt.Errorf("unknown key %q", key)
+
+	case reflect.Slice:
+		i, err := strconv.Atoi(key)
+		if err != nil {
+			return fmt.Errorf("%q is not a list index", key)
+		}
+		if i < 0 || i > v.Len() {
+			return fmt.Errorf("index %d out of range (list has %d entries)", i, v.Len())
+		}
+		if i == v.Len() {
+			// One past the end appends, so new stages or endpoints can be added.
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		}
+		return setPath(v.Index(i), keys[1:], value)
+
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(reflect.ValueOf(key)); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setPath(elem, keys[1:], value); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key), elem)
+		return nil
+	}
+	return fmt.Errorf("%q has no field %q", v.Type(), key)
+}
+
+// setValue parses value as YAML into v, except that strings are taken
+// literally so URLs and bodies need no quoting.
+func setValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(value)
+		return nil
+	}
+	ptr := reflect.New(v.Type())
+	if err := yaml.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+		return fmt.Errorf("invalid value %q for %s", value, v.Type())
+	}
+	v.Set(ptr.Elem())
+	return nil
+}
+
+// LoadEnvFile reads KEY=VALUE lines from a dotenv-style file. Blank lines,
+// # comments and a leading "export " are ignored, and values may be quoted.
+func LoadEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading env file: %w", err)
+	}
+	defer f.Close()
+
+	env := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		v = strings.TrimSpace(v)
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		} else if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		env[strings.TrimSpace(k)] = v
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading env file: %w", err)
+	}
+	return env, nil
+}
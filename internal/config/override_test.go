@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const overrideBase = `
+name: base
+load:
+  ramp_up: 10s
+  steady_state: 1m
+  ramp_down: 10s
+  max_vus: 5
+variables:
+  base_url: http://localhost:8080
+  token: ${OVERRIDE_TEST_TOKEN}
+endpoints:
+  - name: health
+    url: ${base_url}/health
+`
+
+func TestLoadWithOverrides(t *testing.T) {
+	path := writeTemp(t, overrideBase)
+	cfg, err := LoadWithOverrides(path, Overrides{
+		Set: []string{
+			"load.max_vus=200",
+			"load.steady_state=5m",
+			"endpoints[0].expect.status=204",
+			"endpoints.0.headers.X-Env=ci",
+			"http.insecure_skip_verify=true",
+			"name=ci run",
+		},
+		Vars: map[string]string{"base_url": "https://staging.example.com", "region": "eu"},
+		Env:  map[string]string{"OVERRIDE_TEST_TOKEN": "from-env-file"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Load.MaxVUs != 200 || cfg.Load.SteadyState.Duration != 5*time.Minute {
+		t.Errorf("load = %+v", cfg.Load)
+	}
+	// Overrides apply before NormalizeStages, so the shorthand picks them up.
+	if len(cfg.Load.Stages) != 3 || cfg.Load.Stages[1].Target != 200 || cfg.Load.Stages[1].Duration.Duration != 5*time.Minute {
+		t.Errorf("stages = %+v", cfg.Load.Stages)
+	}
+	ep := cfg.Endpoints[0]
+	if ep.Expect.Status != 204 || ep.Headers["X-Env"] != "ci" {
+		t.Errorf("endpoint = %+v", ep)
+	}
+	if !cfg.HTTP.InsecureSkipVerify || cfg.Name != "ci run" {
+		t.Errorf("http = %+v, name = %q", cfg.HTTP, cfg.Name)
+	}
+	if cfg.Variables["base_url"] != "https://staging.example.com" || cfg.Variables["region"] != "eu" {
+		t.Errorf("variables = %v", cfg.Variables)
+	}
+	if cfg.Variables["token"] != "from-env-file" {
+		t.Errorf("token = %q, want value from Env", cfg.Variables["token"])
+	}
+}
+
+func TestLoadWithOverrides_ProcessEnvWins(t *testing.T) {
+	t.Setenv("OVERRIDE_TEST_TOKEN", "from-process")
+	cfg, err := LoadWithOverrides(writeTemp(t, overrideBase), Overrides{Env: map[string]string{"OVERRIDE_TEST_TOKEN": "from-file"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Variables["token"] != "from-process" {
+		t.Errorf("token = %q, want the process environment to win", cfg.Variables["token"])
+	}
+}
+
+func TestLoadWithOverrides_OverrideFailsValidation(t *testing.T) {
+	_, err := LoadWithOverrides(writeTemp(t, overrideBase), Overrides{Set: []string{"load.mode=bogus"}})
+	if err == nil || !strings.Contains(err.Error(), "validating config") {
+		t.Errorf("err = %v, want a validation error", err)
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	var c Config
+	for _, s := range []struct{ path, value string }{
+		{"load.adaptive.target_latency", "250ms"},
+		{"load.stages[0].target", "10"},
+		{"load.stages[0].duration", "30s"},
+		{"load.stages[1].ramp", "step"},
+		{"variables.url", "http://a:b@host/?x=1"},
+	} {
+		if err := c.Set(s.path, s.value); err != nil {
+			t.Fatalf("Set(%s): %v", s.path, err)
+		}
+	}
+	if c.Load.Adaptive == nil || c.Load.Adaptive.TargetLatency.Duration != 250*time.Millisecond {
+		t.Errorf("adaptive = %+v", c.Load.Adaptive)
+	}
+	if len(c.Load.Stages) != 2 || c.Load.Stages[0].Target != 10 || c.Load.Stages[0].Duration.Duration != 30*time.Second || c.Load.Stages[1].Ramp != "step" {
+		t.Errorf("stages = %+v", c.Load.Stages)
+	}
+	if c.Variables["url"] != "http://a:b@host/?x=1" {
+		t.Errorf("variables = %v", c.Variables)
+	}
+}
+
+func TestConfigSet_Errors(t *testing.T) {
+	var c Config
+	tests := []struct{ path, value, want string }{
+		{"load.nope", "1", `unknown key "nope"`},
+		{"load.max_vus", "lots", "invalid value"},
+		{"load.ramp_up", "soon", "invalid value"},
+		{"endpoints[3].url", "x", "out of range"},
+		{"endpoints.x.url", "x", "not a list index"},
+		{"name.first", "x", "no field"},
+		{"", "x", "empty path"},
+	}
+	for _, tt := range tests {
+		err := c.Set(tt.path, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Set(%q, %q) = %v, want error containing %q", tt.path, tt.value, err, tt.want)
+		}
+	}
+	if err := (Overrides{Set: []string{"load.max_vus"}}).Apply(&c); err == nil {
+		t.Error("expected error for --set without =")
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# comment
+API_TOKEN=abc123
+export REGION=eu-west-1
+QUOTED="hello world"
+SINGLE='x=y # not a comment'
+TRAILING=value # comment
+
+EMPTY=
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"API_TOKEN": "abc123",
+		"REGION":    "eu-west-1",
+		"QUOTED":    "hello world",
+		"SINGLE":    "x=y # not a comment",
+		"TRAILING":  "value",
+		"EMPTY":     "",
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s = %q, want %q", k, env[k], v)
+		}
+	}
+	if len(env) != len(want) {
+		t.Errorf("got %d entries, want %d: %v", len(env), len(want), env)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.env")
+	os.WriteFile(bad, []byte("OK=1\nnot a pair\n"), 0o644)
+	if _, err := LoadEnvFile(bad); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("err = %v, want line 2 error", err)
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// durationPattern matches the strings time.ParseDuration accepts.
+const durationPattern = `^[-+]?([0-9]*(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$|^0$`
+
+// schemaEnums lists the allowed values of string fields, keyed by
+// "Type.Field".
+var schemaEnums = map[string][]string{
+	"LoadConfig.Mode":           {"vu", "arrival_rate"},
+	"Stage.Ramp":                RampShapes,
+	"AdaptiveConfig.Algorithm":  {"aimd", "pid"},
+	"OutputConfig.Format":       {"console", "json", "csv"},
+	"HTTPConfig.Protocol":       Protocols,
+	"HTTPConfig.ConnectionMode": ConnectionModes,
+	"Endpoint.Type":             EndpointTypes,
+	"StreamConfig.Format":       StreamFormats,
+	"SocketConfig.Encoding":     SocketEncodings,
+}
+
+// Schema returns a JSON Schema (draft-07) describing the config file format,
+// for editor validation and autocompletion. Like Load, it rejects unknown keys.
+func Schema() ([]byte, error) {
+	s := schemaFor(reflect.TypeOf(Config{}))
+	s["$schema"] = "http://json-schema.org/draft-07/schema#"
+	s["title"] = "perf-test config"
+
+	props := s["properties"].(map[string]any)
+	paths := map[string]any{
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	props["extends"] = withDescription(paths, "Config files to deep-merge underneath this one.")
+	props["include"] = withDescription(paths, "Fragments holding endpoints and/or variables to merge in.")
+	props["profiles"] = map[string]any{
+		"type":                 "object",
+		"description":          "Named load blocks, selected with --profile.",
+		"additionalProperties": schemaFor(reflect.TypeOf(LoadConfig{})),
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(Duration{}) {
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		props := make(map[string]any)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "-" || !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			p := schemaFor(f.Type)
+			if enum, ok := schemaEnums[t.Name()+"."+f.Name]; ok {
+				p["enum"] = enum
+			}
+			props[name] = p
+		}
+		return map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{}
+}
+
+func withDescription(s map[string]any, desc string) map[string]any {
+	out := map[string]any{"description": desc}
+	for k, v := range s {
+		out[k] = v
+	}
+	return out
+}
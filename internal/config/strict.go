@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// DecodeStrict decodes the YAML document b into v, a pointer to a struct,
+// rejecting unknown keys the way Load does. path is used in messages.
+func DecodeStrict(path string, b []byte, v any) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return yamlError(path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	return typecheck(path, doc.Content[0], v, "")
+}
+
+// checkKeys reports every mapping key in n that does not name a field of t,
+// recursing into nested structs, lists and maps. where is the YAML path of
+// n, used in messages; allow lists extra keys accepted in n itself.
+func checkKeys(path string, n *yaml.Node, t reflect.Type, where string, allow ...string) []error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return nil // type errors are reported by the decoder
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Value == "<<" {
+				continue
+			}
+			f, ok := fields[k.Value]
+			if !ok {
+				if !slices.Contains(allow, k.Value) {
+					errs = append(errs, unknownKey(path, k, where, fields, allow))
+				}
+				continue
+			}
+			errs = append(errs, checkKeys(path, v, f.Type, join(where, k.Value))...)
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range n.Content {
+			errs = append(errs, checkKeys(path, item, t.Elem(), fmt.Sprintf("%s[%d]", where, i))...)
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			errs = append(errs, checkKeys(path, n.Content[i+1], t.Elem(), join(where, n.Content[i].Value))...)
+		}
+	}
+	return errs
+}
+
+func unknownKey(path string, k *yaml.Node, where string, fields map[string]reflect.StructField, allow []string) error {
+	msg := fmt.Sprintf("%s:%d:%d: unknown key %q", path, k.Line, k.Column, k.Value)
+	if where != "" {
+		msg += " in " + where
+	}
+	names := append([]string(nil), allow...)
+	for name := range fields {
+		names = append(names, name)
+	}
+	if s := suggest(k.Value, names); s != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", s)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// yamlFields maps a struct's YAML keys to its fields.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// suggest returns the name closest to key, if it is close enough to be a
+// likely typo.
+func suggest(key string, names []string) string {
+	sort.Strings(names)
+	best, bestDist := "", len(key)/3+1
+	if bestDist > 3 {
+		bestDist = 3
+	}
+	for _, name := range names {
+		if d := editDistance(key, name); d <= bestDist && (best == "" || d < editDistance(key, best)) {
+			best = name
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func join(where, key string) string {
+	if where == "" {
+		return key
+	}
+	return where + "." + key
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLoad_UnknownKeys(t *testing.T) {
+	path := writeTemp(t, `load:
+  max_vus: 2
+  steady_state: 10s
+  think_tme: 1s
+endpoints:
+  - name: create
+    url: http://localhost/
+    expect: {statuss: 201}
+bogus: true
+`)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		path + `:4:3: unknown key "think_tme" in load (did you mean "think_time"?)`,
+		path + `:8:14: unknown key "statuss" in endpoints[0].expect (did you mean "status"?)`,
+		path + `:9:1: unknown key "bogus"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad_UnknownKeysInProfilesAndIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"test.yaml": `include: eps.yaml
+load: {max_vus: 1, steady_state: 10s}
+profiles:
+  smoke: {max_vu: 1}
+`,
+		"eps.yaml": `- name: a
+  url: http://localhost/
+  methd: POST
+`,
+	})
+	_, err := Load(filepath.Join(dir, "test.yaml"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`unknown key "max_vu" in profiles.smoke (did you mean "max_vus"?)`,
+		`eps.yaml:3:3: unknown key "methd" in endpoints[0] (did you mean "method"?)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad_AllValidationErrorsWithPositions(t *testing.T) {
+	path := writeTemp(t, `load:
+  mode: bogus
+  max_vus: 2
+  steady_state: 10s
+variables:
+  base_url: http://localhost
+endpoints:
+  - name: a
+    url: ${base_url}/users/${user_id}
+    headers:
+      Authorization: Bearer ${token}
+    think_time: -1s
+output:
+  format: xml
+`)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"5 problems",
+		path + `:9:10: endpoint[0] "a": url references undefined variable ${user_id}`,
+		path + `:11:22: endpoint[0] "a": header Authorization references undefined variable ${token}`,
+		path + `:12:17: endpoint[0] "a": think_time must be >= 0`,
+		path + `:2:9: load.mode must be`,
+		path + `:14:11: output.format must be one of`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidate_UndefinedVariables(t *testing.T) {
+	cfg := &Config{
+		Load:      LoadConfig{Stages: []Stage{{Duration: Duration{1e9}, Target: 1}}},
+		Variables: map[string]string{"base_url": "http://localhost", "id": "1"},
+		Endpoints: []Endpoint{{
+			Name: "ok",
+			URL:  "${base_url}/items/${var.id}?r=${random.int(1,10)}",
+			Body: `{"id": "${random.uuid}"}`,
+		}, {
+			Name: "bad",
+			URL:  "${base_url}/items",
+			Body: `{"a": "${missing}", "b": "${missing}"}`,
+		}},
+	}
+	cfg.ApplyDefaults()
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Path != "endpoints[1].body" {
+		t.Errorf("err = %v, want a FieldError for endpoints[1].body", err)
+	}
+	if n := strings.Count(err.Error(), "${missing}"); n != 1 {
+		t.Errorf("missing reported %d times, want once:\n%v", n, err)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	names := []string{"think_time", "timeout", "status", "max_vus"}
+	for key, want := range map[string]string{
+		"think_tme": "think_time",
+		"statuss":   "status",
+		"maxvus":    "max_vus",
+		"zzz":       "",
+		"weight":    "",
+	} {
+		if got := suggest(key, names); got != want {
+			t.Errorf("suggest(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	out, err := Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s struct {
+		Properties map[string]struct {
+			Type       string `json:"type"`
+			Properties map[string]struct {
+				Type    string   `json:"type"`
+				Pattern string   `json:"pattern"`
+				Enum    []string `json:"enum"`
+			} `json:"properties"`
+			AdditionalProperties any `json:"additionalProperties"`
+		} `json:"properties"`
+		AdditionalProperties bool `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(out, &s); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if s.AdditionalProperties {
+		t.Error("top level should reject unknown keys")
+	}
+	for _, key := range []string{"load", "http", "variables", "endpoints", "output", "extends", "include", "profiles"} {
+		if _, ok := s.Properties[key]; !ok {
+			t.Errorf("schema has no %q property", key)
+		}
+	}
+	load := s.Properties["load"].Properties
+	if load["think_time"].Type != "string" || load["think_time"].Pattern == "" {
+		t.Errorf("load.think_time = %+v, want a duration string", load["think_time"])
+	}
+	if load["max_vus"].Type != "integer" {
+		t.Errorf("load.max_vus type = %q, want integer", load["max_vus"].Type)
+	}
+	if got := strings.Join(load["mode"].Enum, ","); got != "vu,arrival_rate" {
+		t.Errorf("load.mode enum = %q", got)
+	}
+	if _, ok := s.Properties["variables"].AdditionalProperties.(map[string]any); !ok {
+		t.Error("variables should be a map of strings")
+	}
+	re := regexp.MustCompile(load["think_time"].Pattern)
+	for d, want := range map[string]bool{"1m30s": true, "250ms": true, "1.5h": true, "0": true, "10": false, "soon": false} {
+		if re.MatchString(d) != want {
+			t.Errorf("duration pattern matches %q = %v, want %v", d, !want, want)
+		}
+	}
+}
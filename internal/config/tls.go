@@ -0,0 +1,100 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion parses "1.2", "TLS1.2" or "tls 1.2" into a crypto/tls
+// version. The empty string is 0, meaning the crypto/tls default.
+func ParseTLSVersion(s string) (uint16, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "tls"))
+	if id, ok := tlsVersions[v]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q (expected 1.0, 1.1, 1.2 or 1.3)", s)
+}
+
+// ParseCipherSuites looks up cipher suites by their IANA names, such as
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Insecure suites are allowed, since
+// testing a server that still offers them is a reason to name them.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[s.Name] = s.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Merge returns t with the fields set in over replacing its own.
+func (t TLSConfig) Merge(over *TLSConfig) TLSConfig {
+	if over == nil {
+		return t
+	}
+	if over.CertFile != "" || over.KeyFile != "" {
+		t.CertFile, t.KeyFile = over.CertFile, over.KeyFile
+	}
+	if over.CAFile != "" {
+		t.CAFile = over.CAFile
+	}
+	if over.ServerName != "" {
+		t.ServerName = over.ServerName
+	}
+	if over.MinVersion != "" {
+		t.MinVersion = over.MinVersion
+	}
+	if over.MaxVersion != "" {
+		t.MaxVersion = over.MaxVersion
+	}
+	if over.CipherSuites != nil {
+		t.CipherSuites = over.CipherSuites
+	}
+	if over.SessionResumption != nil {
+		t.SessionResumption = over.SessionResumption
+	}
+	return t
+}
+
+// validate checks t's settings. at is its YAML path and label the name used
+// in messages.
+func (t *TLSConfig) validate(v *validator, at, label string) {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		v.add(at, "%s: cert_file and key_file must be set together", label)
+	}
+	minV, err := ParseTLSVersion(t.MinVersion)
+	if err != nil {
+		v.add(at+".min_version", "%s: min_version: %w", label, err)
+	}
+	maxV, err := ParseTLSVersion(t.MaxVersion)
+	if err != nil {
+		v.add(at+".max_version", "%s: max_version: %w", label, err)
+	}
+	if minV != 0 && maxV != 0 && minV > maxV {
+		v.add(at+".min_version", "%s: min_version %s is above max_version %s", label, t.MinVersion, t.MaxVersion)
+	}
+	if _, err := ParseCipherSuites(t.CipherSuites); err != nil {
+		v.add(at+".cipher_suites", "%s: %w", label, err)
+	}
+}
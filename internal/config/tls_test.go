@@ -0,0 +1,83 @@
+package config
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	for in, want := range map[string]uint16{"": 0, "1.2": tls.VersionTLS12, "TLS1.3": tls.VersionTLS13, "tls 1.0": tls.VersionTLS10} {
+		if got, err := ParseTLSVersion(in); err != nil || got != want {
+			t.Errorf("ParseTLSVersion(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := ParseTLSVersion("1.4"); err == nil {
+		t.Error("expected an error for 1.4")
+	}
+}
+
+func TestTLSConfigMerge(t *testing.T) {
+	off := false
+	base := TLSConfig{CAFile: "ca.pem", CertFile: "a.crt", KeyFile: "a.key", MinVersion: "1.2", CipherSuites: []string{"X"}}
+	got := base.Merge(&TLSConfig{CertFile: "b.crt", KeyFile: "b.key", ServerName: "svc.internal", CipherSuites: []string{}, SessionResumption: &off})
+	if got.CAFile != "ca.pem" || got.MinVersion != "1.2" {
+		t.Errorf("unset fields should be inherited: %+v", got)
+	}
+	if got.CertFile != "b.crt" || got.KeyFile != "b.key" || got.ServerName != "svc.internal" {
+		t.Errorf("set fields should override: %+v", got)
+	}
+	if got.CipherSuites == nil || len(got.CipherSuites) != 0 || got.SessionResumption != &off {
+		t.Errorf("an explicit empty list and false should override: %+v", got)
+	}
+	if base.Merge(nil).CertFile != "a.crt" {
+		t.Error("Merge(nil) should return the base")
+	}
+}
+
+func TestLoad_TLS(t *testing.T) {
+	cfg, err := Load(writeTemp(t, `load: {max_vus: 1, steady_state: 10s}
+http:
+  tls:
+    ca_file: ca.pem
+    min_version: 1.2
+endpoints:
+  - name: internal
+    url: https://svc.internal/
+    tls:
+      cert_file: client.crt
+      key_file: client.key
+      session_resumption: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.TLS.MinVersion != "1.2" || cfg.Endpoints[0].TLS == nil || !*cfg.Endpoints[0].TLS.SessionResumption {
+		t.Errorf("tls not parsed: %+v %+v", cfg.HTTP.TLS, cfg.Endpoints[0].TLS)
+	}
+
+	path := writeTemp(t, `load: {max_vus: 1, steady_state: 10s}
+http:
+  tls:
+    min_version: "1.3"
+    max_version: "1.2"
+    cipher_suites: [TLS_NOT_A_SUITE]
+endpoints:
+  - name: internal
+    url: https://svc.internal/
+    tls: {cert_file: client.crt}
+`)
+	_, err = Load(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		path + `:10:10: endpoint[0] "internal": tls: cert_file and key_file must be set together`,
+		path + ":4:18: http.tls: min_version 1.3 is above max_version 1.2",
+		path + `:6:20: http.tls: unknown cipher suite "TLS_NOT_A_SUITE"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
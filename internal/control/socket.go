@@ -0,0 +1,114 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/engine"
+)
+
+// Response is the JSON line written back for every command received on the socket.
+type Response struct {
+	OK    bool                `json:"ok"`
+	Error string              `json:"error,omitempty"`
+	State engine.ControlState `json:"state"`
+}
+
+// Parse converts a command line such as "target 50" or "extend 30s" into an
+// engine.Command. "status" parses to an empty action.
+func Parse(line string) (engine.Command, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return engine.Command{}, fmt.Errorf("empty command")
+	}
+	cmd := engine.Command{Action: strings.ToLower(fields[0]), Source: "socket"}
+	switch cmd.Action {
+	case "status":
+		cmd.Action = ""
+		return cmd, nil
+	case "pause", "resume", "skip":
+		if len(fields) != 1 {
+			return engine.Command{}, fmt.Errorf("%s takes no arguments", cmd.Action)
+		}
+	case "target", "extend":
+		if len(fields) != 2 {
+			return engine.Command{}, fmt.Errorf("usage: %s <value>", cmd.Action)
+		}
+		cmd.Value = fields[1]
+	default:
+		return engine.Command{}, fmt.Errorf("unknown command %q (want pause, resume, target, skip, extend, status)", fields[0])
+	}
+	return cmd, nil
+}
+
+// Listen creates a Unix domain socket at path, removing a stale socket file
+// left behind by a previous run.
+func Listen(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+// Serve accepts connections on ln until ctx is cancelled. Each line received is
+// parsed as a command, applied to eng, and answered with a JSON Response line.
+func Serve(ctx context.Context, ln net.Listener, eng *engine.Engine) {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go handle(conn, eng)
+	}
+}
+
+func handle(conn net.Conn, eng *engine.Engine) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		resp := Response{OK: true}
+		cmd, err := Parse(scanner.Text())
+		if err == nil && cmd.Action != "" {
+			err = eng.Control(cmd)
+		}
+		if err != nil {
+			resp.OK = false
+			resp.Error = err.Error()
+		}
+		resp.State = eng.ControlState()
+		if enc.Encode(resp) != nil {
+			return
+		}
+	}
+}
+
+// Send connects to the control socket at path, sends a single command line,
+// and returns the decoded response.
+func Send(path, line string) (*Response, error) {
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to control socket: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if _, err := fmt.Fprintln(conn, line); err != nil {
+		return nil, fmt.Errorf("sending command: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,110 @@
+package control
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/engine"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line   string
+		action string
+		value  string
+		ok     bool
+	}{
+		{"pause", "pause", "", true},
+		{"  RESUME ", "resume", "", true},
+		{"target 50", "target", "50", true},
+		{"extend 30s", "extend", "30s", true},
+		{"status", "", "", true},
+		{"skip now", "", "", false},
+		{"target", "", "", false},
+		{"explode", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tc := range tests {
+		cmd, err := Parse(tc.line)
+		if (err == nil) != tc.ok {
+			t.Errorf("Parse(%q): unexpected error state: %v", tc.line, err)
+			continue
+		}
+		if tc.ok && (cmd.Action != tc.action || cmd.Value != tc.value) {
+			t.Errorf("Parse(%q) = %+v, want action=%q value=%q", tc.line, cmd, tc.action, tc.value)
+		}
+	}
+}
+
+func TestServe_ControlsRunningEngine(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Load: config.LoadConfig{
+			Mode:   "vu",
+			Stages: []config.Stage{{Duration: config.Duration{Duration: 10 * time.Second}, Target: 2, Ramp: "linear"}},
+		},
+		HTTP:      config.HTTPConfig{Timeout: config.Duration{Duration: time.Second}},
+		Endpoints: []config.Endpoint{{Name: "ep", Method: "GET", URL: srv.URL, Weight: 1, Expect: config.ExpectConfig{Status: 200}}},
+		Output:    config.OutputConfig{Format: "console", Interval: config.Duration{Duration: time.Second}},
+	}
+	eng := engine.New(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sock := filepath.Join(t.TempDir(), "ctl.sock")
+	ln, err := Listen(sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go Serve(ctx, ln, eng)
+
+	done := make(chan struct{})
+	go func() {
+		eng.Run(ctx, io.Discard)
+		close(done)
+	}()
+	// Wait for Run to install its control handles.
+	for i := 0; i < 100 && !eng.ControlState().Running; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp, err := Send(sock, "pause")
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if !resp.OK || !resp.State.Paused {
+		t.Errorf("expected paused state, got %+v", resp)
+	}
+
+	resp, err = Send(sock, "pause")
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if resp.OK {
+		t.Error("expected error when pausing twice")
+	}
+
+	if resp, _ = Send(sock, "resume"); !resp.OK || resp.State.Paused {
+		t.Errorf("expected resumed state, got %+v", resp)
+	}
+	if resp, _ = Send(sock, "skip"); !resp.OK {
+		t.Errorf("expected skip to succeed, got %+v", resp)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("skipping the only stage should end the run")
+	}
+}
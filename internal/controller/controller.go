@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/metrics"
+)
+
+// Controller drives the load target in a feedback loop. Every interval it
+// reads windowed latency and error rate from the collector, asks its
+// algorithm for a new target, clamps it to the configured bounds, and sends
+// it on the target channel — the same channel the scheduler would feed.
+type Controller struct {
+	cfg       *config.AdaptiveConfig
+	collector *metrics.Collector
+	algo      algorithm
+	target    int
+	override  chan int
+}
+
+// algorithm proposes the next target from the current one and the latest window.
+type algorithm interface {
+	next(current int, latency time.Duration, errRate float64) (target int, reason string)
+}
+
+// New creates a Controller for the given adaptive config, reading metrics from
+// collector, which it sets to keep the recent samples it reads.
+func New(cfg *config.AdaptiveConfig, collector *metrics.Collector) *Controller {
+	collector.KeepWindow()
+	var algo algorithm
+	if cfg.Algorithm == "pid" {
+		algo = &pid{cfg: cfg}
+	} else {
+		algo = &aimd{cfg: cfg}
+	}
+	return &Controller{cfg: cfg, collector: collector, algo: algo, override: make(chan int, 1)}
+}
+
+// Override replaces the current target with v, limited to the configured
+// [min, max] bounds. The controller keeps adjusting from the new value on
+// subsequent intervals. Only the latest pending
+// override is kept if Run hasn't picked up the previous one yet.
+func (c *Controller) Override(v int) {
+	select {
+	case <-c.override:
+	default:
+	}
+	c.override <- min(max(v, c.cfg.Min), c.cfg.Max)
+}
+
+// Run sends the initial target, then adjusts it every interval until duration
+// has elapsed or ctx is cancelled. Every decision, including holds, is recorded
+// in the collector's adjustment series. The channel is NOT closed by Run.
+func (c *Controller) Run(ctx context.Context, duration time.Duration, targetCh chan<- int) {
+	c.target = c.cfg.Initial
+	c.collector.RecordAdjustment(metrics.Adjustment{Target: c.target, Reason: "initial"})
+	select {
+	case targetCh <- c.target:
+	case <-ctx.Done():
+		return
+	}
+
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+	ticker := time.NewTicker(c.cfg.Interval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case v := <-c.override:
+			c.collector.RecordAdjustment(metrics.Adjustment{PrevTarget: c.target, Target: v, Reason: "manual override"})
+			c.target = v
+			select {
+			case targetCh <- c.target:
+			case <-ctx.Done():
+				return
+			}
+		case <-ticker.C:
+			prev := c.target
+			c.step(c.collector.Window(c.cfg.Interval.Duration))
+			if c.target == prev {
+				continue
+			}
+			select {
+			case targetCh <- c.target:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// step evaluates one metrics window and updates the current target.
+func (c *Controller) step(w metrics.Window) {
+	latency := w.Percentile(c.cfg.Percentile)
+	adj := metrics.Adjustment{
+		Latency:    latency,
+		ErrorRate:  w.ErrorRate(),
+		Samples:    w.Count,
+		PrevTarget: c.target,
+	}
+
+	if w.Count == 0 {
+		adj.Target = c.target
+		adj.Reason = "no samples; holding"
+		c.collector.RecordAdjustment(adj)
+		return
+	}
+
+	next, reason := c.algo.next(c.target, latency, adj.ErrorRate)
+	c.target = c.clamp(next)
+	adj.Target = c.target
+	adj.Reason = reason
+	c.collector.RecordAdjustment(adj)
+}
+
+// clamp limits v to the configured step size and [min, max] bounds.
+func (c *Controller) clamp(v int) int {
+	if step := c.cfg.MaxStep; step > 0 {
+		if v > c.target+step {
+			v = c.target + step
+		}
+		if v < c.target-step {
+			v = c.target - step
+		}
+	}
+	if v < c.cfg.Min {
+		v = c.cfg.Min
+	}
+	if v > c.cfg.Max {
+		v = c.cfg.Max
+	}
+	return v
+}
+
+// breach reports why the window violates the SLO, or "" if it doesn't.
+func breach(cfg *config.AdaptiveConfig, latency time.Duration, errRate float64) string {
+	if errRate > *cfg.MaxErrorRate {
+		return fmt.Sprintf("error rate %.1f%% > %.1f%%", errRate*100, *cfg.MaxErrorRate*100)
+	}
+	if latency > cfg.TargetLatency.Duration {
+		return fmt.Sprintf("p%g %s > %s", cfg.Percentile, latency.Round(time.Millisecond), cfg.TargetLatency.Duration)
+	}
+	return ""
+}
+
+// aimd implements additive-increase / multiplicative-decrease.
+type aimd struct {
+	cfg *config.AdaptiveConfig
+}
+
+func (a *aimd) next(current int, latency time.Duration, errRate float64) (int, string) {
+	if reason := breach(a.cfg, latency, errRate); reason != "" {
+		return int(math.Floor(float64(current) * a.cfg.Decrease)), reason
+	}
+	return current + a.cfg.Increase, "healthy"
+}
+
+// pid steers the target proportionally to the normalized latency error.
+type pid struct {
+	cfg      *config.AdaptiveConfig
+	integral float64
+	prevErr  float64
+}
+
+// integralLimit bounds the accumulated integral term to prevent wind-up
+// while the target is pinned at min or max.
+const integralLimit = 5
+
+func (p *pid) next(current int, latency time.Duration, errRate float64) (int, string) {
+	goal := float64(p.cfg.TargetLatency.Duration)
+	e := (goal - float64(latency)) / goal
+	reason := "healthy"
+	if r := breach(p.cfg, latency, errRate); r != "" {
+		reason = r
+	}
+	if errRate > *p.cfg.MaxErrorRate {
+		e = -1
+	}
+	e = math.Max(-1, math.Min(1, e))
+
+	p.integral = math.Max(-integralLimit, math.Min(integralLimit, p.integral+e))
+	deriv := e - p.prevErr
+	p.prevErr = e
+
+	u := p.cfg.Kp*e + p.cfg.Ki*p.integral + p.cfg.Kd*deriv
+	delta := math.Round(u * float64(current))
+	// Ensure small targets can still move when the controller wants them to.
+	if delta == 0 && math.Abs(u) > 0.05 {
+		delta = math.Copysign(1, u)
+	}
+	return current + int(delta), reason
+}
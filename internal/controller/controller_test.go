@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/metrics"
+)
+
+func adaptiveConfig(algo string) *config.AdaptiveConfig {
+	maxErrorRate := 0.01
+	return &config.AdaptiveConfig{
+		Algorithm:     algo,
+		TargetLatency: config.Duration{Duration: 100 * time.Millisecond},
+		Percentile:    99,
+		MaxErrorRate:  &maxErrorRate,
+		Min:           1,
+		Max:           50,
+		Initial:       10,
+		Interval:      config.Duration{Duration: 50 * time.Millisecond},
+		Increase:      2,
+		Decrease:      0.5,
+		Kp:            0.5,
+		Ki:            0.1,
+		Kd:            0.05,
+	}
+}
+
+// window builds a metrics.Window by recording results into a fresh collector.
+func window(t *testing.T, n int, latency time.Duration, errors int) metrics.Window {
+	t.Helper()
+	c := metrics.NewCollector(time.Now())
+	c.KeepWindow()
+	for i := 0; i < n; i++ {
+		c.Record(metrics.Result{EndpointName: "ep", Duration: latency, Success: i >= errors})
+	}
+	return c.Window(time.Minute)
+}
+
+func TestAIMD_IncreasesWhenHealthy(t *testing.T) {
+	c := New(adaptiveConfig("aimd"), metrics.NewCollector(time.Now()))
+	c.target = 10
+	c.step(window(t, 100, 20*time.Millisecond, 0))
+	if c.target != 12 {
+		t.Errorf("expected target 12 after healthy window, got %d", c.target)
+	}
+}
+
+func TestAIMD_ZeroErrorRateToleratesNoErrors(t *testing.T) {
+	cfg := adaptiveConfig("aimd")
+	zero := 0.0
+	cfg.MaxErrorRate = &zero
+	c := New(cfg, metrics.NewCollector(time.Now()))
+	c.target = 10
+	c.step(window(t, 1000, 20*time.Millisecond, 1))
+	if c.target != 5 {
+		t.Errorf("expected target 5 after a single error with max_error_rate 0, got %d", c.target)
+	}
+}
+
+func TestAIMD_DecreasesOnLatencyBreach(t *testing.T) {
+	c := New(adaptiveConfig("aimd"), metrics.NewCollector(time.Now()))
+	c.target = 10
+	c.step(window(t, 100, 300*time.Millisecond, 0))
+	if c.target != 5 {
+		t.Errorf("expected target 5 after latency breach, got %d", c.target)
+	}
+}
+
+func TestAIMD_DecreasesOnErrorRate(t *testing.T) {
+	c := New(adaptiveConfig("aimd"), metrics.NewCollector(time.Now()))
+	c.target = 10
+	c.step(window(t, 100, 20*time.Millisecond, 10))
+	if c.target != 5 {
+		t.Errorf("expected target 5 after error-rate breach, got %d", c.target)
+	}
+}
+
+func TestPID_MovesTowardGoal(t *testing.T) {
+	c := New(adaptiveConfig("pid"), metrics.NewCollector(time.Now()))
+	c.target = 10
+	c.step(window(t, 100, 10*time.Millisecond, 0))
+	if c.target <= 10 {
+		t.Errorf("expected PID to increase target when far below goal, got %d", c.target)
+	}
+
+	c.target = 10
+	c.step(window(t, 100, 500*time.Millisecond, 0))
+	if c.target >= 10 {
+		t.Errorf("expected PID to decrease target when above goal, got %d", c.target)
+	}
+}
+
+func TestClamp_BoundsAndMaxStep(t *testing.T) {
+	cfg := adaptiveConfig("aimd")
+	cfg.MaxStep = 3
+	c := New(cfg, metrics.NewCollector(time.Now()))
+
+	c.target = 10
+	if got := c.clamp(100); got != 13 {
+		t.Errorf("max_step: expected 13, got %d", got)
+	}
+	c.target = 49
+	if got := c.clamp(52); got != 50 {
+		t.Errorf("max bound: expected 50, got %d", got)
+	}
+	c.target = 2
+	if got := c.clamp(-5); got != 1 {
+		t.Errorf("min bound: expected 1, got %d", got)
+	}
+}
+
+func TestStep_NoSamplesHolds(t *testing.T) {
+	collector := metrics.NewCollector(time.Now())
+	c := New(adaptiveConfig("aimd"), collector)
+	c.target = 10
+	c.step(metrics.Window{})
+	if c.target != 10 {
+		t.Errorf("expected target to hold at 10, got %d", c.target)
+	}
+	adj := collector.Snapshot().Adjustments
+	if len(adj) != 1 || adj[0].Reason != "no samples; holding" {
+		t.Errorf("expected a recorded hold decision, got %+v", adj)
+	}
+}
+
+func TestRun_SendsInitialAndRecordsDecisions(t *testing.T) {
+	collector := metrics.NewCollector(time.Now())
+	c := New(adaptiveConfig("aimd"), collector)
+
+	targetCh := make(chan int, 100)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Keep the window healthy so the controller keeps increasing.
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+				collector.Record(metrics.Result{EndpointName: "ep", Duration: time.Millisecond, Success: true})
+			}
+		}
+	}()
+	c.Run(ctx, 300*time.Millisecond, targetCh)
+	close(stop)
+
+	if len(targetCh) < 2 {
+		t.Fatalf("expected initial target plus adjustments, got %d sends", len(targetCh))
+	}
+	if first := <-targetCh; first != 10 {
+		t.Errorf("expected initial target 10, got %d", first)
+	}
+	adj := collector.Snapshot().Adjustments
+	if len(adj) < 2 {
+		t.Fatalf("expected recorded decisions, got %d", len(adj))
+	}
+	if adj[0].Reason != "initial" {
+		t.Errorf("expected first decision to be initial, got %q", adj[0].Reason)
+	}
+}
+
+func TestRun_Override(t *testing.T) {
+	collector := metrics.NewCollector(time.Now())
+	cfg := adaptiveConfig("aimd")
+	cfg.Interval = config.Duration{Duration: time.Hour} // no automatic steps
+	c := New(cfg, collector)
+
+	targetCh := make(chan int, 10)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		c.Override(42)
+	}()
+	c.Run(context.Background(), 200*time.Millisecond, targetCh)
+
+	if <-targetCh != 10 || <-targetCh != 42 {
+		t.Error("expected initial target 10 followed by override 42")
+	}
+	adj := collector.Snapshot().Adjustments
+	if adj[len(adj)-1].Reason != "manual override" {
+		t.Errorf("expected override to be recorded, got %+v", adj[len(adj)-1])
+	}
+}
+
+func TestOverride_Clamped(t *testing.T) {
+	c := New(adaptiveConfig("aimd"), metrics.NewCollector(time.Now()))
+	for _, tt := range []struct{ v, want int }{{500, 50}, {0, 1}, {20, 20}} {
+		c.Override(tt.v)
+		if got := <-c.override; got != tt.want {
+			t.Errorf("Override(%d) = %d, want %d", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,497 @@
+// Package curl parses curl command lines into requests and renders requests
+// back into curl commands.
+package curl
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+)
+
+// Command is a request described by a curl invocation.
+type Command struct {
+	Method          string
+	URL             string
+	Headers         map[string]string
+	Body            string
+	Insecure        bool // -k
+	FollowRedirects bool // -L
+	Warnings        []string
+}
+
+// flagsWithArg are options that take a value we don't use; their value must
+// be skipped rather than mistaken for the URL.
+var flagsWithArg = map[string]bool{
+	"-o": true, "--output": true, "-w": true, "--write-out": true, "-m": true, "--max-time": true,
+	"--connect-timeout": true, "--retry": true, "--retry-delay": true, "--retry-max-time": true,
+	"-x": true, "--proxy": true, "-c": true, "--cookie-jar": true, "--resolve": true,
+	"--cacert": true, "--cert": true, "--key": true, "-E": true, "--limit-rate": true,
+	"-r": true, "--range": true, "-T": true, "--upload-file": true, "-F": true, "--form": true,
+}
+
+// ignoredFlags change only curl's own output or behaviour.
+var ignoredFlags = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true, "-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-f": true, "--fail": true, "--http1.1": true, "--http2": true,
+	"--http2-prior-knowledge": true, "-N": true, "--no-buffer": true, "-#": true, "--progress-bar": true,
+}
+
+// shortWithArg and shortNoArg are the single-letter options we know,
+// grouped by whether they take a value.
+const (
+	shortWithArg = "XHduAebowmxcErTF"
+	shortNoArg   = "sSvifN#kLGI"
+)
+
+// Parse interprets the arguments of one curl invocation. A leading "curl"
+// is optional.
+func Parse(args []string) (*Command, error) {
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+	c := &Command{Headers: make(map[string]string)}
+	var data []string
+	var get bool
+	var socket string
+
+	for i := 0; i < len(args); i++ {
+		if group := splitGroup(args[i]); group != nil {
+			args = slices.Concat(args[:i], group, args[i+1:])
+		}
+		arg := args[i]
+		name, value, inline := splitFlag(arg)
+
+		needValue := func() (string, error) {
+			if inline {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch name {
+		case "-X", "--request":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			c.Method = strings.ToUpper(v)
+		case "-H", "--header":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			k, hv, ok := strings.Cut(v, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid header %q", v)
+			}
+			c.Headers[strings.TrimSpace(k)] = strings.TrimSpace(hv)
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii", "--data-urlencode":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			if name != "--data-raw" && strings.HasPrefix(v, "@") {
+				b, err := os.ReadFile(v[1:])
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", name, v, err)
+				}
+				v = string(b)
+				if name != "--data-binary" {
+					v = strings.NewReplacer("\r", "", "\n", "").Replace(v)
+				}
+			}
+			if name == "--data-urlencode" {
+				v = urlencodeData(v)
+			}
+			data = append(data, v)
+		case "--json":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, v)
+			setDefault(c.Headers, "Content-Type", "application/json")
+			setDefault(c.Headers, "Accept", "application/json")
+		case "-u", "--user":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			c.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(v))
+		case "-A", "--user-agent":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			c.Headers["User-Agent"] = v
+		case "-e", "--referer":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			c.Headers["Referer"] = v
+		case "-b", "--cookie":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			c.Headers["Cookie"] = v
+		case "--url":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			c.URL = v
+		case "--unix-socket":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			socket = v
+		case "--compressed":
+			setDefault(c.Headers, "Accept-Encoding", "gzip, deflate")
+		case "-k", "--insecure":
+			c.Insecure = true
+		case "-L", "--location":
+			c.FollowRedirects = true
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			c.Method = "HEAD"
+		default:
+			switch {
+			case !strings.HasPrefix(arg, "-") || arg == "-":
+				if c.URL != "" {
+					return nil, fmt.Errorf("more than one URL (%s and %s)", c.URL, arg)
+				}
+				c.URL = arg
+			case ignoredFlags[name]:
+			case flagsWithArg[name]:
+				if _, err := needValue(); err != nil {
+					return nil, err
+				}
+				c.Warnings = append(c.Warnings, "ignored "+name)
+			default:
+				c.Warnings = append(c.Warnings, "ignored unknown option "+name)
+			}
+		}
+	}
+
+	if c.URL == "" {
+		return nil, fmt.Errorf("no URL given")
+	}
+	if !strings.Contains(c.URL, "://") {
+		c.URL = "http://" + c.URL
+	}
+	body := strings.Join(data, "&")
+	switch {
+	case get && body != "":
+		sep := "?"
+		if strings.Contains(c.URL, "?") {
+			sep = "&"
+		}
+		c.URL += sep + body
+	case body != "":
+		c.Body = body
+		setDefault(c.Headers, "Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.Method == "" {
+		c.Method = "GET"
+		if c.Body != "" {
+			c.Method = "POST"
+		}
+	}
+	if socket != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing URL: %w", err)
+		}
+		c.URL = config.UnixPrefix + socket + ":" + u.RequestURI()
+	}
+	return c, nil
+}
+
+// splitFlag separates "--name=value" and "-Hvalue" forms. Plain arguments
+// come back unchanged with inline false.
+func splitFlag(arg string) (name, value string, inline bool) {
+	if strings.HasPrefix(arg, "--") {
+		if k, v, ok := strings.Cut(arg, "="); ok {
+			return k, v, true
+		}
+		return arg, "", false
+	}
+	if strings.HasPrefix(arg, "-") && len(arg) > 2 && strings.IndexByte(shortWithArg, arg[1]) >= 0 {
+		return arg[:2], arg[2:], true
+	}
+	return arg, "", false
+}
+
+// splitGroup expands grouped single-letter options such as "-sSk" into
+// separate arguments. Only the last option in a group may take a value,
+// which is the rest of the argument or the next one: "-sXPOST" becomes
+// "-s", "-XPOST". It returns nil if arg is not such a group, or contains
+// an option we don't know.
+func splitGroup(arg string) []string {
+	if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' || strings.IndexByte(shortWithArg, arg[1]) >= 0 {
+		return nil
+	}
+	var group []string
+	for i := 1; i < len(arg); i++ {
+		switch {
+		case strings.IndexByte(shortNoArg, arg[i]) >= 0:
+			group = append(group, "-"+arg[i:i+1])
+		case strings.IndexByte(shortWithArg, arg[i]) >= 0:
+			return append(group, "-"+arg[i:])
+		default:
+			return nil
+		}
+	}
+	return group
+}
+
+func setDefault(h map[string]string, k, v string) {
+	for existing := range h {
+		if strings.EqualFold(existing, k) {
+			return
+		}
+	}
+	h[k] = v
+}
+
+// urlencodeData implements --data-urlencode's "content" and "name=content" forms.
+func urlencodeData(v string) string {
+	if name, content, ok := strings.Cut(v, "="); ok {
+		return name + "=" + url.QueryEscape(content)
+	}
+	return url.QueryEscape(v)
+}
+
+// RenderEndpoint evaluates ep's templates with gen and renders the resulting
+// request as a curl command, applying httpCfg's TLS and redirect settings.
+func RenderEndpoint(ep config.Endpoint, gen *data.Generator, httpCfg config.HTTPConfig) string {
+	headers := make(map[string]string, len(ep.Headers))
+	for k, v := range ep.Headers {
+		headers[k] = gen.Generate(v)
+	}
+	method := ep.Method
+	if method == "" {
+		method = "GET"
+	}
+	return Render(method, gen.Generate(ep.URL), headers, gen.Generate(ep.Body), httpCfg.InsecureSkipVerify, httpCfg.FollowRedirects)
+}
+
+// Render formats a request as a curl command, one option per line.
+func Render(method, rawURL string, headers map[string]string, body string, insecure, followRedirects bool) string {
+	var b strings.Builder
+	b.WriteString("curl")
+	implied := "GET"
+	if body != "" {
+		implied = "POST"
+	}
+	if method != implied {
+		b.WriteString(" -X " + method)
+	}
+	if socket, path, ok := config.SplitUnixURL(rawURL); ok {
+		b.WriteString(" --unix-socket " + Quote(socket))
+		rawURL = "http://localhost" + path
+	}
+	b.WriteString(" " + Quote(rawURL))
+	if insecure {
+		b.WriteString(" -k")
+	}
+	if followRedirects {
+		b.WriteString(" -L")
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		// curl decodes the response itself when asked with --compressed.
+		if strings.EqualFold(k, "Accept-Encoding") {
+			b.WriteString(" --compressed")
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(" \\\n  -H " + Quote(k+": "+headers[k]))
+	}
+	if body != "" {
+		b.WriteString(" \\\n  --data-raw " + Quote(body))
+	}
+	return b.String()
+}
+
+// Quote single-quotes s for a POSIX shell.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Split breaks shell text into the argument lists of the curl commands it
+// contains. It understands single, double and $'...' quoting, backslash
+// escapes and line continuations; commands are separated by unquoted
+// newlines, ';', '&&' or '||'. Lines starting with '#' are comments.
+func Split(text string) ([][]string, error) {
+	var cmds [][]string
+	var cur []string
+	var word strings.Builder
+	inWord := false
+
+	endWord := func() {
+		if inWord {
+			cur = append(cur, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCmd := func() {
+		endWord()
+		if len(cur) > 0 && cur[0] == "curl" {
+			cmds = append(cmds, cur)
+		}
+		cur = nil
+	}
+
+	rs := []rune(text)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\\' && i+1 < len(rs):
+			i++
+			if rs[i] == '\n' || (rs[i] == '\r' && i+1 < len(rs) && rs[i+1] == '\n') {
+				if rs[i] == '\r' {
+					i++
+				}
+				continue // line continuation
+			}
+			word.WriteRune(rs[i])
+			inWord = true
+		case r == '\'':
+			end := indexRune(rs, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(string(rs[i+1 : end]))
+			inWord = true
+			i = end
+		case r == '$' && i+1 < len(rs) && rs[i+1] == '\'':
+			s, end, err := ansiC(rs, i+2)
+			if err != nil {
+				return nil, err
+			}
+			word.WriteString(s)
+			inWord = true
+			i = end
+		case r == '"':
+			i++
+			for ; i < len(rs) && rs[i] != '"'; i++ {
+				if rs[i] == '\\' && i+1 < len(rs) && strings.ContainsRune("\"\\$`\n", rs[i+1]) {
+					i++
+					if rs[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(rs[i])
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		case r == '#' && !inWord:
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+			endCmd()
+		case r == '\n' || r == ';':
+			endCmd()
+		case (r == '&' || r == '|') && i+1 < len(rs) && rs[i+1] == r:
+			i++
+			endCmd()
+		case r == ' ' || r == '\t' || r == '\r':
+			endWord()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	endCmd()
+	return cmds, nil
+}
+
+func indexRune(rs []rune, from int, r rune) int {
+	for i := from; i < len(rs); i++ {
+		if rs[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// ansiC decodes a $'...' string starting after the opening quote, returning
+// the text and the index of the closing quote.
+func ansiC(rs []rune, from int) (string, int, error) {
+	var b strings.Builder
+	for i := from; i < len(rs); i++ {
+		switch rs[i] {
+		case '\'':
+			return b.String(), i, nil
+		case '\\':
+			if i+1 >= len(rs) {
+				break
+			}
+			i++
+			switch rs[i] {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			case 'r':
+				b.WriteRune('\r')
+			case 'u', 'x':
+				n := 4
+				if rs[i] == 'x' {
+					n = 2
+				}
+				var v rune
+				j := i + 1
+				for ; j < len(rs) && j <= i+n; j++ {
+					d := hexVal(rs[j])
+					if d < 0 {
+						break
+					}
+					v = v*16 + rune(d)
+				}
+				b.WriteRune(v)
+				i = j - 1
+			default:
+				b.WriteRune(rs[i])
+			}
+		default:
+			b.WriteRune(rs[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated $' quote")
+}
+
+func hexVal(r rune) int {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0')
+	case r >= 'a' && r <= 'f':
+		return int(r-'a') + 10
+	case r >= 'A' && r <= 'F':
+		return int(r-'A') + 10
+	}
+	return -1
+}
@@ -0,0 +1,219 @@
+package curl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+)
+
+func TestSplit(t *testing.T) {
+	text := `# copied from the browser
+curl 'https://api.example.com/cart' \
+  -H 'accept: application/json' \
+  --compressed
+curl -X POST "https://api.example.com/orders?q=\"x\"" --data-raw $'{"note":"it\'s\n"}'
+echo done; curl localhost:8080 && curl -I example.com
+`
+	cmds, err := Split(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"curl", "https://api.example.com/cart", "-H", "accept: application/json", "--compressed"},
+		{"curl", "-X", "POST", `https://api.example.com/orders?q="x"`, "--data-raw", "{\"note\":\"it's\n\"}"},
+		{"curl", "localhost:8080"},
+		{"curl", "-I", "example.com"},
+	}
+	if !reflect.DeepEqual(cmds, want) {
+		t.Errorf("Split =\n%q\nwant\n%q", cmds, want)
+	}
+}
+
+func TestSplit_Unterminated(t *testing.T) {
+	if _, err := Split(`curl 'https://example.com`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]string{"curl", "-XPUT", "https://example.com/items/5",
+		"-H", "Content-Type: application/json", "-d", `{"a":1}`,
+		"-u", "admin:secret", "--compressed", "-k", "-L", "-s", "-o", "/dev/null"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Method != "PUT" || c.URL != "https://example.com/items/5" || c.Body != `{"a":1}` {
+		t.Errorf("got %s %s %q", c.Method, c.URL, c.Body)
+	}
+	wantHeaders := map[string]string{
+		"Content-Type":    "application/json",
+		"Authorization":   "Basic YWRtaW46c2VjcmV0",
+		"Accept-Encoding": "gzip, deflate",
+	}
+	if !reflect.DeepEqual(c.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", c.Headers, wantHeaders)
+	}
+	if !c.Insecure || !c.FollowRedirects {
+		t.Error("expected -k and -L to be recorded")
+	}
+	if len(c.Warnings) != 1 || c.Warnings[0] != "ignored -o" {
+		t.Errorf("Warnings = %v", c.Warnings)
+	}
+}
+
+func TestParse_GroupedFlags(t *testing.T) {
+	tests := []struct {
+		args                []string
+		method              string
+		insecure, redirects bool
+	}{
+		{[]string{"-sk", "-X", "POST", "https://api.example.com/x", "-d", "a=1"}, "POST", true, false},
+		{[]string{"-kL", "https://api.example.com/x"}, "GET", true, true},
+		{[]string{"-fsSL", "https://api.example.com/x"}, "GET", false, true},
+		{[]string{"-sSk", "https://api.example.com/x"}, "GET", true, false},
+		{[]string{"-sXPUT", "https://api.example.com/x"}, "PUT", false, false},
+		{[]string{"-kX", "DELETE", "https://api.example.com/x"}, "DELETE", true, false},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.args)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.args, err)
+			continue
+		}
+		if c.Method != tt.method || c.Insecure != tt.insecure || c.FollowRedirects != tt.redirects || len(c.Warnings) != 0 {
+			t.Errorf("Parse(%q) = %s, insecure %v, redirects %v, warnings %v", tt.args, c.Method, c.Insecure, c.FollowRedirects, c.Warnings)
+		}
+	}
+
+	// A value that looks like a group is left alone.
+	c, err := Parse([]string{"http://x/a", "-d", "-sk"})
+	if err != nil || c.Body != "-sk" || c.Insecure {
+		t.Errorf("body = %q, insecure = %v, err = %v", c.Body, c.Insecure, err)
+	}
+	// So is a group with an option we don't know.
+	if c, err := Parse([]string{"-sz", "http://x/a"}); err != nil || len(c.Warnings) != 1 || c.Warnings[0] != "ignored unknown option -sz" {
+		t.Errorf("warnings = %v, err = %v", c.Warnings, err)
+	}
+}
+
+func TestParse_Defaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantMethod string
+		wantURL    string
+		wantBody   string
+	}{
+		{"get", []string{"example.com/a"}, "GET", "http://example.com/a", ""},
+		{"data implies post", []string{"--url", "http://x/a", "--data", "a=1", "--data", "b=2"}, "POST", "http://x/a", "a=1&b=2"},
+		{"get with data", []string{"-G", "http://x/a?z=0", "-d", "a=1"}, "GET", "http://x/a?z=0&a=1", ""},
+		{"urlencode", []string{"http://x/a", "--data-urlencode", "q=a b&c"}, "POST", "http://x/a", "q=a+b%26c"},
+		{"head", []string{"-I", "http://x/a"}, "HEAD", "http://x/a", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Method != tt.wantMethod || c.URL != tt.wantURL || c.Body != tt.wantBody {
+				t.Errorf("got %s %s %q, want %s %s %q", c.Method, c.URL, c.Body, tt.wantMethod, tt.wantURL, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestParse_DataFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(path, []byte("{\n\"a\": 1\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Parse([]string{"http://x/a", "-d", "@" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Body != `{"a": 1}` {
+		t.Errorf("Body = %q; -d should strip newlines from files", c.Body)
+	}
+	c, err = Parse([]string{"http://x/a", "--data-raw", "@" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Body != "@"+path {
+		t.Errorf("Body = %q; --data-raw should not read files", c.Body)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-X", "POST"},
+		{"http://x/a", "-H"},
+		{"http://x/a", "-H", "no-colon"},
+		{"http://x/a", "http://x/b"},
+	} {
+		if _, err := Parse(args); err == nil {
+			t.Errorf("Parse(%q): expected error", args)
+		}
+	}
+}
+
+func TestRender_RoundTrip(t *testing.T) {
+	out := Render("POST", "https://example.com/o?a=1", map[string]string{
+		"Content-Type":    "application/json",
+		"Accept-Encoding": "gzip",
+	}, `{"note":"it's"}`, true, false)
+	if strings.Contains(out, "-X") {
+		t.Errorf("POST with a body should not need -X:\n%s", out)
+	}
+	cmds, err := Split(out)
+	if err != nil || len(cmds) != 1 {
+		t.Fatalf("Split(Render) = %v, %v", cmds, err)
+	}
+	c, err := Parse(cmds[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Method != "POST" || c.URL != "https://example.com/o?a=1" || c.Body != `{"note":"it's"}` || !c.Insecure {
+		t.Errorf("round trip = %+v", c)
+	}
+	if c.Headers["Content-Type"] != "application/json" || c.Headers["Accept-Encoding"] == "" {
+		t.Errorf("round trip headers = %v", c.Headers)
+	}
+}
+
+func TestUnixSocket_RoundTrip(t *testing.T) {
+	out := Render("GET", "unix:///run/app.sock:/v1/health?full=1", nil, "", false, false)
+	if want := "curl --unix-socket '/run/app.sock' 'http://localhost/v1/health?full=1'"; out != want {
+		t.Errorf("Render = %q, want %q", out, want)
+	}
+	cmds, err := Split(out)
+	if err != nil || len(cmds) != 1 {
+		t.Fatalf("Split(Render) = %v, %v", cmds, err)
+	}
+	c, err := Parse(cmds[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.URL != "unix:///run/app.sock:/v1/health?full=1" || len(c.Warnings) != 0 {
+		t.Errorf("Parse = %+v", c)
+	}
+}
+
+func TestRenderEndpoint(t *testing.T) {
+	gen := data.NewGenerator(map[string]string{"base_url": "http://localhost:8080", "token": "abc"})
+	ep := config.Endpoint{
+		Method:  "DELETE",
+		URL:     "${base_url}/items/${random.int(7,7)}",
+		Headers: map[string]string{"Authorization": "Bearer ${token}"},
+	}
+	got := RenderEndpoint(ep, gen, config.HTTPConfig{FollowRedirects: true})
+	want := "curl -X DELETE 'http://localhost:8080/items/7' -L \\\n  -H 'Authorization: Bearer abc'"
+	if got != want {
+		t.Errorf("RenderEndpoint =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -34,6 +34,21 @@ func (g *Generator) Generate(tmpl string) string {
 	})
 }
 
+// References returns the names of the variables tmpl refers to, in order of
+// appearance. Random functions are not included; ${var.x} and ${x} both
+// name x.
+func References(tmpl string) []string {
+	var names []string
+	for _, m := range tokenRegex.FindAllStringSubmatch(tmpl, -1) {
+		token := strings.TrimSpace(m[1])
+		if strings.HasPrefix(token, "random.") {
+			continue
+		}
+		names = append(names, strings.TrimPrefix(token, "var."))
+	}
+	return names
+}
+
 func (g *Generator) evaluate(token string) string {
 	switch {
 	case token == "random.uuid":
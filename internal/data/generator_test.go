@@ -150,3 +150,14 @@ func TestGenerate_NoTokens(t *testing.T) {
 		t.Errorf("expected passthrough, got %q", result)
 	}
 }
+
+func TestReferences(t *testing.T) {
+	got := References(`${base_url}/users/${ var.id }?n=${random.int(1,5)}&t=${random.uuid}&k=${key}`)
+	want := []string{"base_url", "id", "key"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("References = %v, want %v", got, want)
+	}
+	if got := References("no tokens"); len(got) != 0 {
+		t.Errorf("References = %v, want none", got)
+	}
+}
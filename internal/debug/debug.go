@@ -0,0 +1,392 @@
+// Package debug renders and sends requests one at a time with full dumps,
+// for checking a config before putting load on it.
+package debug
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+	"github.com/jvreagan/perf-test/internal/httpclient"
+	"github.com/jvreagan/perf-test/internal/worker"
+)
+
+// Options controls debug output.
+type Options struct {
+	Endpoints   []string // only these endpoint names; empty means all
+	ShowSecrets bool     // print credentials instead of redacting them
+	MaxBody     int      // bytes of each body to print; 0 means 4096, negative means unlimited
+}
+
+func (o *Options) applyDefaults() {
+	if o.MaxBody == 0 {
+		o.MaxBody = 4096
+	}
+}
+
+// DryRun prints n requests rendered the way a load test would pick and
+// render them, without sending anything.
+func DryRun(w io.Writer, cfg *config.Config, n int, opts Options) {
+	opts.applyDefaults()
+	exec := worker.NewExecutor(cfg.Endpoints, data.NewGenerator(cfg.Variables), nil)
+	exec.SetFlow(cfg.Load.Flow)
+	red := newRedactor(cfg.Variables, opts.ShowSecrets)
+
+	for i := 0; i < n; i++ {
+		ep := exec.SelectEndpoint()
+		if exec.Flow() {
+			ep = exec.EndpointAt(i)
+		}
+		fmt.Fprintf(w, "=== [%d/%d] %s ===\n", i+1, n, ep.Name)
+		writeRequest(w, exec.Render(ep), red, opts.MaxBody)
+		fmt.Fprintln(w)
+	}
+}
+
+// Run sends each endpoint once, in config order, and prints the request,
+// the response, phase timings and whether expectations held. It returns the
+// number of endpoints that failed.
+func Run(ctx context.Context, w io.Writer, cfg *config.Config, opts Options) (int, error) {
+	opts.applyDefaults()
+	var endpoints []config.Endpoint
+	for _, ep := range cfg.Endpoints {
+		if len(opts.Endpoints) == 0 || slices.Contains(opts.Endpoints, ep.Name) {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	if len(endpoints) == 0 {
+		return 0, fmt.Errorf("no endpoint named %s", strings.Join(opts.Endpoints, ", "))
+	}
+
+	exec := worker.NewExecutor(endpoints, data.NewGenerator(cfg.Variables), nil)
+	clients, err := httpclient.NewSet(cfg.HTTP, cfg.Endpoints)
+	if err != nil {
+		return 0, err
+	}
+	exec.SetClientFor(clients.For)
+	exec.SetDial(clients.Dial)
+	if err := exec.PrepareGRPC(ctx); err != nil {
+		return 0, err
+	}
+	red := newRedactor(cfg.Variables, opts.ShowSecrets)
+
+	failed := 0
+	for i, ep := range endpoints {
+		fmt.Fprintf(w, "=== [%d/%d] %s ===\n", i+1, len(endpoints), ep.Name)
+		if err := send(ctx, w, clients.For(ep), exec, ep, red, opts.MaxBody); err != nil {
+			failed++
+			fmt.Fprintf(w, "  Result:  FAIL: %v\n\n", err)
+		} else {
+			fmt.Fprintf(w, "  Result:  ok\n\n")
+		}
+		if ctx.Err() != nil {
+			return failed, ctx.Err()
+		}
+	}
+	fmt.Fprintf(w, "%d of %d endpoints passed\n", len(endpoints)-failed, len(endpoints))
+	return failed, nil
+}
+
+// send performs one request with tracing and dumps everything to w.
+func send(ctx context.Context, w io.Writer, client *http.Client, exec *worker.Executor, ep config.Endpoint, red *redactor, maxBody int) error {
+	if (!ep.IsHTTP() && ep.Type != "graphql") || ep.Stream != nil {
+		return sendOther(ctx, w, client, exec, ep, red)
+	}
+	r := exec.Render(ep)
+	writeRequest(w, r, red, maxBody)
+	fmt.Fprintln(w)
+
+	var t timings
+	req, err := r.NewHTTPRequest(httptrace.WithClientTrace(ctx, t.trace()))
+	if err != nil {
+		return err
+	}
+	t.start = time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.done = time.Now()
+		fmt.Fprintf(w, "  Timing:  %s\n", t)
+		return err
+	}
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	t.done = time.Now()
+
+	fmt.Fprintf(w, "< %s %s\n", resp.Proto, resp.Status)
+	writeHeaders(w, "< ", resp.Header, red)
+	writeBody(w, "< ", string(body), red, maxBody)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Timing:  %s\n", t)
+	fmt.Fprintf(w, "  Conn:    %s -> %s\n", t.local, t.remote)
+	if resp.TLS != nil {
+		fmt.Fprintf(w, "  TLS:     %s\n", describeTLS(resp.TLS))
+	}
+	if readErr != nil {
+		return fmt.Errorf("reading response body: %w", readErr)
+	}
+
+	var failure error
+	if ep.Expect.Status != 0 {
+		err := worker.CheckExpect(ep, resp.StatusCode)
+		fmt.Fprintf(w, "  Expect:  status %d ... %s\n", ep.Expect.Status, outcome(err))
+		failure = err
+	}
+	if ep.Type == "graphql" {
+		err := worker.CheckGraphQL(body)
+		fmt.Fprintf(w, "  Expect:  no graphql errors ... %s\n", outcome(err))
+		failure = cmp.Or(failure, err)
+	}
+	return failure
+}
+
+// outcome describes the result of checking an expectation.
+func outcome(err error) string {
+	if err != nil {
+		return "FAIL"
+	}
+	return "ok"
+}
+
+// sendOther sends an endpoint that is not plain HTTP: one websocket
+// session, grpc call, streamed response or tcp or udp exchange. It
+// summarizes the outcome.
+func sendOther(ctx context.Context, w io.Writer, client *http.Client, exec *worker.Executor, ep config.Endpoint, red *redactor) error {
+	r := exec.Render(ep)
+	label := strings.ToUpper(ep.Type)
+	if ep.IsHTTP() {
+		label = r.Method
+	}
+	fmt.Fprintf(w, "> %s %s\n\n", label, red.text(r.URL))
+	res := exec.WithClientFor(func(config.Endpoint) *http.Client { return client }).Execute(ctx, ep)
+	if res.Session != nil {
+		fmt.Fprintf(w, "  Connect: %s", res.Session.Connect.Round(time.Microsecond))
+	} else {
+		fmt.Fprintf(w, "  Time:    %s", res.Duration.Round(time.Microsecond))
+	}
+	if res.StatusCode != 0 {
+		fmt.Fprintf(w, " (status %d)", res.StatusCode)
+	}
+	fmt.Fprintln(w)
+	if res.Code != "" {
+		fmt.Fprintf(w, "  Status:  %s\n", res.Code)
+	}
+	if s := res.Session; s != nil {
+		fmt.Fprintf(w, "  Session: sent %d, received %d", s.Sent, s.Received)
+		for _, rtt := range s.RTTs {
+			fmt.Fprintf(w, ", rtt %s", rtt.Round(time.Microsecond))
+		}
+		fmt.Fprintln(w)
+	}
+	if s := res.Stream; s != nil {
+		fmt.Fprintf(w, "  Stream:  %d events, ttfb %s, first event %s", s.Events,
+			s.TTFB.Round(time.Microsecond), s.FirstEvent.Round(time.Microsecond))
+		if len(s.Gaps) > 0 {
+			fmt.Fprintf(w, ", max gap %s", slices.Max(s.Gaps).Round(time.Microsecond))
+		}
+		if s.Capped {
+			fmt.Fprint(w, " (capped)")
+		}
+		fmt.Fprintln(w)
+	}
+	return res.Error
+}
+
+// describeTLS summarizes a negotiated TLS connection: version, cipher, ALPN
+// protocol, SNI, whether the session was resumed and the server certificate.
+func describeTLS(cs *tls.ConnectionState) string {
+	parts := []string{tls.VersionName(cs.Version), tls.CipherSuiteName(cs.CipherSuite)}
+	if cs.NegotiatedProtocol != "" {
+		parts = append(parts, "alpn "+cs.NegotiatedProtocol)
+	}
+	if cs.ServerName != "" {
+		parts = append(parts, "sni "+cs.ServerName)
+	}
+	if cs.DidResume {
+		parts = append(parts, "resumed")
+	}
+	s := strings.Join(parts, ", ")
+	if len(cs.PeerCertificates) > 0 {
+		c := cs.PeerCertificates[0]
+		s += fmt.Sprintf("\n           server cert %q issued by %q, expires %s", certName(c.Subject), certName(c.Issuer), c.NotAfter.Format("2006-01-02"))
+	}
+	return s
+}
+
+func certName(n pkix.Name) string {
+	if n.CommonName != "" {
+		return n.CommonName
+	}
+	return n.String()
+}
+
+func writeRequest(w io.Writer, r worker.Request, red *redactor, maxBody int) {
+	method := r.Method
+	if method == "" {
+		method = "GET"
+	}
+	fmt.Fprintf(w, "> %s %s\n", method, red.text(r.URL))
+	writeHeaders(w, "> ", r.Header, red)
+	writeBody(w, "> ", r.Body, red, maxBody)
+}
+
+func writeHeaders(w io.Writer, prefix string, h http.Header, red *redactor) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, k, red.header(k, v))
+		}
+	}
+}
+
+func writeBody(w io.Writer, prefix, body string, red *redactor, maxBody int) {
+	if body == "" {
+		return
+	}
+	body = red.text(body)
+	truncated := 0
+	if maxBody > 0 && len(body) > maxBody {
+		truncated = len(body) - maxBody
+		body = body[:maxBody]
+	}
+	fmt.Fprintln(w, strings.TrimSuffix(prefix, " "))
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+	if truncated > 0 {
+		fmt.Fprintf(w, "%s... (%d more bytes)\n", prefix, truncated)
+	}
+}
+
+// timings records the phases of one request.
+type timings struct {
+	start, dnsStart, dnsDone, connStart, connDone time.Time
+	tlsStart, tlsDone, wrote, firstByte, done     time.Time
+	reused                                        bool
+	local, remote                                 string // connection endpoints
+}
+
+func (t *timings) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.dnsDone = time.Now() },
+		ConnectStart:         func(string, string) { t.connStart = time.Now() },
+		ConnectDone:          func(string, string, error) { t.connDone = time.Now() },
+		TLSHandshakeStart:    func() { t.tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.tlsDone = time.Now() },
+		GotConn:              t.gotConn,
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.wrote = time.Now() },
+		GotFirstResponseByte: func() { t.firstByte = time.Now() },
+	}
+}
+
+func (t *timings) gotConn(info httptrace.GotConnInfo) {
+	t.reused = info.Reused
+	t.local, t.remote = info.Conn.LocalAddr().String(), info.Conn.RemoteAddr().String()
+}
+
+func (t timings) String() string {
+	phase := func(from, to time.Time) string {
+		if from.IsZero() || to.IsZero() {
+			return "-"
+		}
+		return to.Sub(from).Round(10 * time.Microsecond).String()
+	}
+	s := fmt.Sprintf("dns %s  connect %s  tls %s  ttfb %s  transfer %s  total %s",
+		phase(t.dnsStart, t.dnsDone), phase(t.connStart, t.connDone), phase(t.tlsStart, t.tlsDone),
+		phase(t.wrote, t.firstByte), phase(t.firstByte, t.done), phase(t.start, t.done))
+	if t.reused {
+		s += "  (reused connection)"
+	}
+	return s
+}
+
+const redacted = "[REDACTED]"
+
+// secretName matches header and variable names that usually hold credentials.
+var secretName = regexp.MustCompile(`(?i)(auth|token|secret|password|passwd|api[-_]?key|cookie|session|credential|private)`)
+
+// redactor hides credentials in dumps: the values of secret-looking headers,
+// and the values of secret-looking variables wherever they appear.
+type redactor struct {
+	off    bool
+	values []string
+}
+
+func newRedactor(vars map[string]string, off bool) *redactor {
+	r := &redactor{off: off}
+	for k, v := range vars {
+		if len(v) >= 4 && secretName.MatchString(k) {
+			r.values = append(r.values, v)
+		}
+	}
+	// Longest first, so a secret containing another is replaced whole.
+	sort.Slice(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+	return r
+}
+
+// Redact returns a copy of cfg that is safe to print: secret-looking
+// variables and headers are replaced as in debug output, and secret variable
+// values are hidden wherever they appear in endpoint URLs, headers and bodies.
+func Redact(cfg *config.Config) *config.Config {
+	red := newRedactor(cfg.Variables, false)
+	out := *cfg
+	if cfg.Variables != nil {
+		out.Variables = make(map[string]string, len(cfg.Variables))
+		for k, v := range cfg.Variables {
+			if secretName.MatchString(k) {
+				v = redacted
+			}
+			out.Variables[k] = red.text(v)
+		}
+	}
+	out.Endpoints = make([]config.Endpoint, len(cfg.Endpoints))
+	for i, ep := range cfg.Endpoints {
+		if ep.Headers != nil {
+			headers := make(map[string]string, len(ep.Headers))
+			for k, v := range ep.Headers {
+				headers[k] = red.header(k, v)
+			}
+			ep.Headers = headers
+		}
+		ep.URL, ep.Body = red.text(ep.URL), red.text(ep.Body)
+		out.Endpoints[i] = ep
+	}
+	return &out
+}
+
+func (r *redactor) header(name, value string) string {
+	if r.off || !secretName.MatchString(name) {
+		return r.text(value)
+	}
+	// Keep the scheme of Authorization headers; it helps debugging.
+	if scheme, _, ok := strings.Cut(value, " "); ok && strings.EqualFold(name, "Authorization") {
+		return scheme + " " + redacted
+	}
+	return redacted
+}
+
+func (r *redactor) text(s string) string {
+	if r.off {
+		return s
+	}
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	return s
+}
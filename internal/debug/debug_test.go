@@ -0,0 +1,393 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/websocket"
+)
+
+func testConfig(baseURL string) *config.Config {
+	return &config.Config{
+		Variables: map[string]string{"base_url": baseURL, "api_token": "s3cr3t-value"},
+		Endpoints: []config.Endpoint{
+			{Name: "list", Method: "GET", URL: "${base_url}/items?key=${api_token}", Weight: 1,
+				Headers: map[string]string{"Authorization": "Bearer ${api_token}", "X-Trace": "t-1"},
+				Expect:  config.ExpectConfig{Status: 200}},
+			{Name: "create", Method: "POST", URL: "${base_url}/items", Weight: 1,
+				Body: `{"n":${random.int(4,4)}}`, Expect: config.ExpectConfig{Status: 201}},
+		},
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	cfg := testConfig("http://api.test")
+	cfg.Load.Flow = true
+	var buf bytes.Buffer
+	DryRun(&buf, cfg, 3, Options{})
+	out := buf.String()
+
+	for _, want := range []string{
+		"=== [1/3] list ===", "=== [2/3] create ===", "=== [3/3] list ===",
+		"> GET http://api.test/items?key=[REDACTED]",
+		"> Authorization: Bearer [REDACTED]",
+		"> X-Trace: t-1",
+		"> POST http://api.test/items",
+		`> {"n":4}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("secret leaked:\n%s", out)
+	}
+}
+
+func TestDryRun_ShowSecrets(t *testing.T) {
+	cfg := testConfig("http://api.test")
+	cfg.Load.Flow = true
+	var buf bytes.Buffer
+	DryRun(&buf, cfg, 1, Options{ShowSecrets: true})
+	if !strings.Contains(buf.String(), "> Authorization: Bearer s3cr3t-value") {
+		t.Errorf("secret should be shown:\n%s", buf.String())
+	}
+}
+
+func TestRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+		w.WriteHeader(http.StatusOK) // create expects 201, so it fails
+		w.Write([]byte(`{"items":[]}` + strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	failed, err := Run(context.Background(), &buf, testConfig(srv.URL), Options{MaxBody: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"< HTTP/1.1 200 OK",
+		"< Set-Cookie: [REDACTED]",
+		`< {"items":[]}xxxxxxxx`,
+		"< ... (92 more bytes)",
+		"  Timing:  dns ",
+		"ttfb ",
+		"  Expect:  status 200 ... ok",
+		"  Expect:  status 201 ... FAIL",
+		"  Result:  FAIL: expected status 201, got 200",
+		"1 of 2 endpoints passed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "s3cr3t") || strings.Contains(out, "abc123") {
+		t.Errorf("secret leaked:\n%s", out)
+	}
+}
+
+func TestRun_FilterEndpoints(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	failed, err := Run(context.Background(), &buf, testConfig(srv.URL), Options{Endpoints: []string{"create"}})
+	if err != nil || failed != 0 {
+		t.Fatalf("Run = %d, %v", failed, err)
+	}
+	if strings.Contains(buf.String(), "list") {
+		t.Errorf("unselected endpoint was sent:\n%s", buf.String())
+	}
+	if _, err := Run(context.Background(), &buf, testConfig(srv.URL), Options{Endpoints: []string{"nope"}}); err == nil {
+		t.Error("expected error for unknown endpoint")
+	}
+}
+
+func TestRun_ConnectionError(t *testing.T) {
+	var buf bytes.Buffer
+	failed, err := Run(context.Background(), &buf, testConfig("http://127.0.0.1:1"), Options{Endpoints: []string{"list"}})
+	if err != nil || failed != 1 {
+		t.Fatalf("Run = %d, %v", failed, err)
+	}
+	if !strings.Contains(buf.String(), "Result:  FAIL") {
+		t.Errorf("output:\n%s", buf.String())
+	}
+}
+
+func TestRun_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cfg := testConfig(srv.URL)
+	cfg.HTTP.InsecureSkipVerify = true
+	cfg.Endpoints[0].TLS = &config.TLSConfig{MaxVersion: "1.2", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}}
+
+	var buf bytes.Buffer
+	if _, err := Run(context.Background(), &buf, cfg, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"  TLS:     TLS 1.2, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, alpn http/1.1\n",
+		"  TLS:     TLS 1.3, TLS_AES_",
+		`server cert "O=Acme Co" issued by "O=Acme Co", expires`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRun_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &httptest.Server{Listener: ln, Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + " " + r.URL.Path))
+	})}}
+	srv.Start()
+	defer srv.Close()
+
+	cfg := &config.Config{Endpoints: []config.Endpoint{
+		{Name: "health", Method: "GET", URL: "unix://" + socket + ":/healthz", Expect: config.ExpectConfig{Status: 200}},
+	}}
+	var buf bytes.Buffer
+	if failed, err := Run(context.Background(), &buf, cfg, Options{}); err != nil || failed != 0 {
+		t.Fatalf("failed = %d, err = %v\n%s", failed, err, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"> GET unix://" + socket + ":/healthz",
+		"< localhost /healthz",
+		"-> " + socket + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if !regexp.MustCompile(`connect \d`).MatchString(out) {
+		t.Errorf("no connect timing:\n%s", out)
+	}
+}
+
+func TestRun_WebSocket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(op, data)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Variables: map[string]string{"base_url": "ws" + strings.TrimPrefix(srv.URL, "http")},
+		Endpoints: []config.Endpoint{{Name: "chat", Type: "websocket", URL: "${base_url}/chat",
+			WebSocket: &config.WebSocketConfig{Send: "ping", Expect: "ping", Timeout: config.Duration{Duration: time.Second}}}},
+	}
+	var buf bytes.Buffer
+	if failed, err := Run(context.Background(), &buf, cfg, Options{}); err != nil || failed != 0 {
+		t.Fatalf("failed = %d, err = %v\n%s", failed, err, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"> WEBSOCKET " + cfg.Variables["base_url"] + "/chat",
+		"(status 101)",
+		"  Session: sent 1, received 1, rtt ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cfg := testConfig("http://api.test")
+	cfg.Variables["region"] = "eu"
+	red := Redact(cfg)
+	if red.Variables["api_token"] != redacted || red.Variables["region"] != "eu" || red.Variables["base_url"] != "http://api.test" {
+		t.Errorf("variables = %v", red.Variables)
+	}
+	if h := red.Endpoints[0].Headers; h["Authorization"] != "Bearer "+redacted || h["X-Trace"] != "t-1" {
+		t.Errorf("headers = %v", h)
+	}
+	if cfg.Variables["api_token"] != "s3cr3t-value" || cfg.Endpoints[0].Headers["Authorization"] != "Bearer ${api_token}" {
+		t.Error("Redact modified the original config")
+	}
+
+	cfg.Endpoints[0].URL = "http://api.test/items?key=s3cr3t-value"
+	out, err := config.Marshal(Redact(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "s3cr3t") {
+		t.Errorf("secret in output:\n%s", out)
+	}
+}
+
+func TestRun_GRPC(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", "5")
+		w.Header().Set("Grpc-Message", "no such greeting")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetHTTP1(true)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Variables: map[string]string{"base_url": "grpc://" + strings.TrimPrefix(srv.URL, "http://")},
+		Endpoints: []config.Endpoint{{Name: "greet", Type: "grpc", URL: "${base_url}", Body: `{"name":"ada"}`,
+			GRPC: &config.GRPCConfig{Method: "test.v1.Greeter/SayHello", Protoset: "../grpc/testdata/greeter.protoset",
+				ExpectCode: "NOT_FOUND"}}},
+	}
+	var buf bytes.Buffer
+	if failed, err := Run(context.Background(), &buf, cfg, Options{}); err != nil || failed != 0 {
+		t.Fatalf("failed = %d, err = %v\n%s", failed, err, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"> GRPC " + cfg.Variables["base_url"],
+		"  Time:    ",
+		"(status 200)",
+		"  Status:  NOT_FOUND",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRun_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		conn.Write(append([]byte("+"), buf[:n]...))
+	}()
+
+	url := "tcp://" + ln.Addr().String()
+	cfg := &config.Config{
+		Endpoints: []config.Endpoint{{Name: "ping", Type: "tcp", URL: url,
+			Socket: &config.SocketConfig{Send: "PING\r\n", Until: "\r\n", Expect: `^\+PING`}}},
+	}
+	var buf bytes.Buffer
+	if failed, err := Run(context.Background(), &buf, cfg, Options{}); err != nil || failed != 0 {
+		t.Fatalf("failed = %d, err = %v\n%s", failed, err, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"> TCP " + url,
+		"  Connect: ",
+		"  Session: sent 1, received 1, rtt ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRun_Stream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := range 3 {
+			fmt.Fprintf(w, "data: tok%d\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Variables: map[string]string{"base_url": srv.URL},
+		Endpoints: []config.Endpoint{{Name: "events", Method: "GET", URL: "${base_url}/events", Expect: config.ExpectConfig{Status: 200},
+			Stream: &config.StreamConfig{Format: "sse", Expect: "tok2", MaxEvents: 2}}},
+	}
+	var buf bytes.Buffer
+	failed, err := Run(context.Background(), &buf, cfg, Options{})
+	if err != nil || failed != 1 {
+		t.Fatalf("failed = %d, err = %v, want the expectation to fail\n%s", failed, err, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"> GET " + srv.URL + "/events",
+		"(status 200)",
+		"  Stream:  2 events, ttfb ",
+		", max gap ",
+		"(capped)",
+		`no event matched "tok2"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRun_GraphQL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":null,"errors":[{"message":"not authorized"}]}`)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Variables: map[string]string{"base_url": srv.URL},
+		Endpoints: []config.Endpoint{{Name: "viewer", Type: "graphql", Method: "POST", URL: "${base_url}/graphql",
+			Expect: config.ExpectConfig{Status: 200}, GraphQL: &config.GraphQLConfig{Query: "{ viewer { id } }", Variables: `{"n": 1}`}}},
+	}
+	var buf bytes.Buffer
+	failed, err := Run(context.Background(), &buf, cfg, Options{})
+	if err != nil || failed != 1 {
+		t.Fatalf("failed = %d, err = %v, want the graphql errors to fail it\n%s", failed, err, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"> POST " + srv.URL + "/graphql",
+		"> Content-Type: application/json",
+		`{"query":"{ viewer { id } }","variables":{"n": 1}}`,
+		"  Expect:  status 200 ... ok",
+		"  Expect:  no graphql errors ... FAIL",
+		"graphql error: not authorized",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/controller"
+	"github.com/jvreagan/perf-test/internal/metrics"
+	"github.com/jvreagan/perf-test/internal/scheduler"
+	"github.com/jvreagan/perf-test/internal/worker"
+)
+
+// ErrNotRunning is returned by Control when no test is in progress.
+var ErrNotRunning = errors.New("test is not running")
+
+// Command is a manual intervention on a running test.
+type Command struct {
+	Action string `json:"action"`          // "pause", "resume", "target", "skip" or "extend"
+	Value  string `json:"value,omitempty"` // target count for "target", duration for "extend"
+	Source string `json:"-"`               // recorded with the intervention: web, api, socket
+}
+
+// ControlState describes what can be controlled on a running test.
+type ControlState struct {
+	Running  bool          `json:"running"`
+	Paused   bool          `json:"paused"`
+	Adaptive bool          `json:"adaptive"`
+	Stage    int           `json:"stage"`  // zero-based; not meaningful in adaptive mode
+	Stages   int           `json:"stages"` // zero in adaptive mode
+	Target   int           `json:"target"`
+	Override bool          `json:"override"`
+	Elapsed  time.Duration `json:"elapsed"`
+	Total    time.Duration `json:"total"`
+}
+
+func (e *Engine) setControls(sched *scheduler.Scheduler, ctrl *controller.Controller, gate *worker.Gate) {
+	e.mu.Lock()
+	e.sched, e.ctrl, e.gate = sched, ctrl, gate
+	e.mu.Unlock()
+}
+
+// Control applies cmd to the running test and records it as an intervention.
+//
+//   - pause: idle all workers and stop the arrival dispatcher; the stage clock stops
+//   - resume: undo pause
+//   - target: override the current VU/RPS target until the stage ends
+//     (in adaptive mode, the controller continues from the new value)
+//   - skip: jump to the start of the next stage
+//   - extend: lengthen the current stage by a duration such as "30s"
+func (e *Engine) Control(cmd Command) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.gate == nil {
+		return ErrNotRunning
+	}
+
+	var err error
+	switch cmd.Action {
+	case "pause":
+		if !e.gate.Pause() {
+			return fmt.Errorf("already paused")
+		}
+		if e.sched != nil {
+			err = e.sched.Pause()
+		}
+	case "resume":
+		if !e.gate.Resume() {
+			return fmt.Errorf("not paused")
+		}
+		if e.sched != nil {
+			err = e.sched.Resume()
+		}
+	case "target":
+		n, convErr := strconv.Atoi(cmd.Value)
+		if convErr != nil || n < 0 {
+			return fmt.Errorf("target must be a non-negative integer (got %q)", cmd.Value)
+		}
+		if e.ctrl != nil {
+			e.ctrl.Override(n)
+		} else {
+			err = e.sched.Override(n)
+		}
+	case "skip":
+		if e.sched == nil {
+			return fmt.Errorf("skip is not supported in adaptive mode")
+		}
+		err = e.sched.SkipStage()
+	case "extend":
+		if e.sched == nil {
+			return fmt.Errorf("extend is not supported in adaptive mode")
+		}
+		d, parseErr := time.ParseDuration(cmd.Value)
+		if parseErr != nil {
+			return fmt.Errorf("invalid extend duration %q: %w", cmd.Value, parseErr)
+		}
+		err = e.sched.ExtendStage(d)
+	default:
+		return fmt.Errorf("unknown control action %q", cmd.Action)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.collector.RecordIntervention(metrics.Intervention{
+		Action: cmd.Action,
+		Value:  cmd.Value,
+		Source: cmd.Source,
+	})
+	return nil
+}
+
+// ControlState returns the current control state. Running is false before Run
+// starts and after it returns.
+func (e *Engine) ControlState() ControlState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.gate == nil {
+		return ControlState{}
+	}
+	st := ControlState{Running: true, Paused: e.gate.Paused(), Adaptive: e.ctrl != nil}
+	if e.sched != nil {
+		ss := e.sched.State()
+		st.Stage, st.Stages = ss.Stage, ss.Stages
+		st.Target, st.Override = ss.Target, ss.Override
+		st.Elapsed, st.Total = ss.Elapsed, ss.Total
+	} else if adj, ok := e.collector.LastAdjustment(); ok {
+		st.Target = adj.Target
+	}
+	return st
+}
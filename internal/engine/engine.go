@@ -2,16 +2,17 @@ package engine
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/controller"
 	"github.com/jvreagan/perf-test/internal/data"
+	"github.com/jvreagan/perf-test/internal/httpclient"
 	"github.com/jvreagan/perf-test/internal/metrics"
 	"github.com/jvreagan/perf-test/internal/ratelimit"
 	"github.com/jvreagan/perf-test/internal/reporter"
@@ -23,6 +24,12 @@ import (
 type Engine struct {
 	cfg       *config.Config
 	collector *metrics.Collector
+
+	// Control handles, set while Run is in progress.
+	mu    sync.Mutex
+	sched *scheduler.Scheduler
+	ctrl  *controller.Controller
+	gate  *worker.Gate
 }
 
 // New creates an Engine from the given config.
@@ -31,8 +38,10 @@ func New(cfg *config.Config) *Engine {
 }
 
 type workerEntry struct {
-	cancel context.CancelFunc
-	done   chan struct{}
+	w       *worker.Worker
+	cancel  context.CancelFunc
+	done    chan struct{}
+	clients *httpclient.Set // the VU's own clients in per_vu connection mode, else nil
 }
 
 // Collector returns the metrics collector, available after Run has started.
@@ -43,11 +52,22 @@ func (e *Engine) Collector() *metrics.Collector {
 // Run executes the load test. It writes periodic and summary output to w and
 // returns the final stats snapshot. A non-nil error indicates test failures.
 func (e *Engine) Run(ctx context.Context, w io.Writer) (*metrics.Stats, error) {
-	client := e.buildClient()
+	clients, err := httpclient.NewSet(e.cfg.HTTP, e.cfg.Endpoints)
+	if err != nil {
+		return nil, err
+	}
+	gen := data.NewGenerator(e.cfg.Variables)
+	exec := worker.NewExecutor(e.cfg.Endpoints, gen, clients.Default())
+	exec.SetClientFor(clients.For)
+	exec.SetDial(clients.Dial)
+	exec.SetFlow(e.cfg.Load.Flow)
+	if err := exec.PrepareGRPC(ctx); err != nil {
+		return nil, err
+	}
+
 	startTime := time.Now()
 	collector := metrics.NewCollector(startTime)
 	e.collector = collector
-	gen := data.NewGenerator(e.cfg.Variables)
 
 	resultCh := make(chan metrics.Result, 1000)
 	targetCh := make(chan int, 10)
@@ -64,11 +84,26 @@ func (e *Engine) Run(ctx context.Context, w io.Writer) (*metrics.Stats, error) {
 	}()
 
 	// Scheduler goroutine — closes targetCh when done so the main loop exits.
-	sched := scheduler.New(e.cfg.Load.Stages)
+	// In adaptive mode the controller replaces the stage curve as the target source.
+	gate := worker.NewGate()
+	var sched *scheduler.Scheduler
+	var ctrl *controller.Controller
+	if a := e.cfg.Load.Adaptive; a != nil {
+		ctrl = controller.New(a, collector)
+	} else {
+		sched = scheduler.New(e.cfg.Load.Stages)
+	}
+	e.setControls(sched, ctrl, gate)
+	defer e.setControls(nil, nil, nil)
+
 	schedDone := make(chan struct{})
 	go func() {
 		defer close(schedDone)
-		sched.Run(ctx, targetCh)
+		if ctrl != nil {
+			ctrl.Run(ctx, e.cfg.TotalDuration(), targetCh)
+		} else {
+			sched.Run(ctx, targetCh)
+		}
 		close(targetCh)
 	}()
 
@@ -91,12 +126,16 @@ func (e *Engine) Run(ctx context.Context, w io.Writer) (*metrics.Stats, error) {
 		}
 	}()
 
-	exec := worker.NewExecutor(e.cfg.Endpoints, gen, client)
+	// Requests run on workCtx rather than ctx so that cancelling ctx (SIGINT,
+	// the web Stop button) stops dispatch but lets in-flight requests drain
+	// for up to graceful_stop.
+	workCtx, workCancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer workCancel()
 
 	if e.cfg.Load.Mode == "arrival_rate" {
-		e.runArrivalRate(ctx, exec, collector, resultCh, targetCh)
+		e.runArrivalRate(ctx, workCtx, workCancel, exec, collector, gate, resultCh, targetCh)
 	} else {
-		e.runVU(ctx, exec, collector, resultCh, targetCh)
+		e.runVU(ctx, workCtx, exec, clients, collector, gate, resultCh, targetCh)
 	}
 
 	// Stop reporter
@@ -126,11 +165,15 @@ func (e *Engine) Run(ctx context.Context, w io.Writer) (*metrics.Stats, error) {
 }
 
 // runVU runs the existing VU pool mode, optionally with a global max_rps limiter.
-func (e *Engine) runVU(ctx context.Context, exec *worker.Executor, collector *metrics.Collector, resultCh chan<- metrics.Result, targetCh <-chan int) {
+// Removed VUs finish their current request, waiting up to graceful_stop before
+// being cancelled. In per_vu connection mode each VU gets a clone of clients.
+func (e *Engine) runVU(ctx, workCtx context.Context, exec *worker.Executor, clients *httpclient.Set, collector *metrics.Collector, gate *worker.Gate, resultCh chan<- metrics.Result, targetCh <-chan int) {
 	limiter := ratelimit.NewLimiter(ctx, e.cfg.Load.MaxRPS)
+	grace := e.cfg.Load.GracefulStop.Duration
 
 	var workers []workerEntry
 	var workerMu sync.Mutex
+	var stopping sync.WaitGroup
 
 	setWorkerCount := func(target int) {
 		workerMu.Lock()
@@ -139,23 +182,36 @@ func (e *Engine) runVU(ctx context.Context, exec *worker.Executor, collector *me
 		current := len(workers)
 		if target > current {
 			for i := current; i < target; i++ {
-				wCtx, wCancel := context.WithCancel(ctx)
+				wCtx, wCancel := context.WithCancel(workCtx)
 				done := make(chan struct{})
 				id := i
-				w := worker.New(id, exec, resultCh, e.cfg.Load.ThinkTime.Duration, limiter)
+				we := workerEntry{cancel: wCancel, done: done}
+				vuExec := exec
+				if e.cfg.HTTP.ConnectionMode == "per_vu" {
+					// Clone can only fail if building clients does, which
+					// NewSet already did successfully with the same settings.
+					we.clients, _ = clients.Clone()
+					vuExec = exec.WithClientFor(we.clients.For).WithDial(we.clients.Dial)
+				}
+				we.w = worker.New(id, vuExec, resultCh, e.cfg.Load.ThinkTime.Duration, limiter, gate)
 				go func() {
 					defer close(done)
-					w.Run(wCtx)
+					we.w.Run(wCtx)
 				}()
-				workers = append(workers, workerEntry{cancel: wCancel, done: done})
+				workers = append(workers, we)
 			}
 		} else if target < current {
-			toRemove := workers[target:]
+			toRemove := make([]workerEntry, current-target)
+			copy(toRemove, workers[target:])
 			workers = workers[:target]
 			for _, we := range toRemove {
-				we.cancel()
-				<-we.done
+				we.w.Stop()
 			}
+			stopping.Add(1)
+			go func() {
+				defer stopping.Done()
+				drainWorkers(toRemove, grace, collector)
+			}()
 		}
 		collector.SetActiveVUs(len(workers))
 	}
@@ -164,14 +220,65 @@ func (e *Engine) runVU(ctx context.Context, exec *worker.Executor, collector *me
 		setWorkerCount(target)
 	}
 	setWorkerCount(0)
+	stopping.Wait()
+}
+
+// drainWorkers waits for stopped workers to finish their in-flight requests,
+// cancels any still running after grace, and records the outcome.
+func drainWorkers(entries []workerEntry, grace time.Duration, collector *metrics.Collector) {
+	allDone := make(chan struct{})
+	go func() {
+		defer close(allDone)
+		for _, we := range entries {
+			<-we.done
+		}
+	}()
+	waitGraceful(allDone, grace, func() {
+		for _, we := range entries {
+			we.cancel()
+		}
+	})
+
+	var drained, interrupted int64
+	for _, we := range entries {
+		drained += we.w.Drained()
+		interrupted += we.w.Interrupted()
+		if we.clients != nil {
+			we.clients.CloseIdleConnections()
+		}
+	}
+	collector.RecordStop(drained, interrupted)
+}
+
+// waitGraceful waits up to grace for done to close, then calls cancel and
+// waits for done. A zero grace cancels immediately.
+func waitGraceful(done <-chan struct{}, grace time.Duration, cancel func()) {
+	if grace > 0 {
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case <-done:
+			cancel()
+			return
+		case <-timer.C:
+		}
+	}
+	cancel()
+	<-done
 }
 
 // runArrivalRate dispatches requests at a fixed RPS using a ticker-based dispatcher.
 // Each tick fires one request goroutine (up to 2x target RPS concurrency limit).
-func (e *Engine) runArrivalRate(ctx context.Context, exec *worker.Executor, collector *metrics.Collector, resultCh chan<- metrics.Result, targetCh <-chan int) {
+// Ticks are skipped while gate is paused. When dispatch ends, in-flight requests
+// get up to graceful_stop to finish before workCtx is cancelled.
+func (e *Engine) runArrivalRate(ctx, workCtx context.Context, workCancel context.CancelFunc, exec *worker.Executor, collector *metrics.Collector, gate *worker.Gate, resultCh chan<- metrics.Result, targetCh <-chan int) {
 	var dispatchCancel context.CancelFunc
 	var dispatchDone chan struct{}
 
+	var inFlight sync.WaitGroup
+	var stopped atomic.Bool
+	var drained, interrupted atomic.Int64
+
 	setDispatchRate := func(rps int) {
 		// Stop the previous dispatcher, if any.
 		if dispatchCancel != nil {
@@ -201,20 +308,29 @@ func (e *Engine) runArrivalRate(ctx context.Context, exec *worker.Executor, coll
 				case <-dCtx.Done():
 					return
 				case <-ticker.C:
+					if gate.Paused() {
+						continue
+					}
 					select {
 					case sem <- struct{}{}:
 						collector.SetActiveVUs(len(sem))
+						inFlight.Add(1)
 						go func() {
+							defer inFlight.Done()
 							defer func() { <-sem }()
 							ep := exec.SelectEndpoint()
-							// Use parent ctx so rate changes don't abort in-flight requests.
-							result := exec.Execute(ctx, ep)
-							if result.Error != nil && ctx.Err() != nil {
+							// Use workCtx so rate changes and stop don't abort in-flight requests.
+							result := exec.Execute(workCtx, ep)
+							if result.Error != nil && workCtx.Err() != nil {
+								interrupted.Add(1)
 								return
 							}
+							if stopped.Load() {
+								drained.Add(1)
+							}
 							select {
 							case resultCh <- result:
-							case <-ctx.Done():
+							case <-workCtx.Done():
 							}
 						}()
 					default:
@@ -232,33 +348,14 @@ func (e *Engine) runArrivalRate(ctx context.Context, exec *worker.Executor, coll
 		dispatchCancel()
 		<-dispatchDone
 	}
-}
-
-func (e *Engine) buildClient() *http.Client {
-	transport := &http.Transport{
-		MaxIdleConns:        1000,
-		MaxIdleConnsPerHost: 100,
-		IdleConnTimeout:     90 * time.Second,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: e.cfg.HTTP.InsecureSkipVerify, //nolint:gosec
-		},
-	}
-
-	redirectPolicy := http.ErrUseLastResponse
-	if e.cfg.HTTP.FollowRedirects {
-		redirectPolicy = nil
-	}
-
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   e.cfg.HTTP.Timeout.Duration,
-	}
 
-	if !e.cfg.HTTP.FollowRedirects {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return redirectPolicy
-		}
-	}
-
-	return client
+	stopped.Store(true)
+	allDone := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(allDone)
+	}()
+	waitGraceful(allDone, e.cfg.Load.GracefulStop.Duration, workCancel)
+	collector.RecordStop(drained.Load(), interrupted.Load())
+	collector.SetActiveVUs(0)
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/mock"
 )
 
 func makeConfig(serverURL string) *config.Config {
@@ -190,3 +191,256 @@ func TestEngine_Run_MultipleEndpoints(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestEngine_Run_Adaptive(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg := makeConfig(srv.URL)
+	maxErrorRate := 0.01
+	cfg.Load.Adaptive = &config.AdaptiveConfig{
+		Algorithm:     "aimd",
+		TargetLatency: config.Duration{Duration: time.Second},
+		Percentile:    99,
+		MaxErrorRate:  &maxErrorRate,
+		Min:           1,
+		Max:           5,
+		Initial:       1,
+		Interval:      config.Duration{Duration: 50 * time.Millisecond},
+		Increase:      1,
+		Decrease:      0.5,
+	}
+	e := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := e.Run(ctx, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats.Adjustments) < 2 {
+		t.Fatalf("expected controller decisions in stats, got %d", len(stats.Adjustments))
+	}
+	if last := stats.Adjustments[len(stats.Adjustments)-1]; last.Target <= 1 {
+		t.Errorf("expected controller to raise target against a fast server, got %d", last.Target)
+	}
+}
+
+func TestEngine_Control_NotRunning(t *testing.T) {
+	e := New(makeConfig("http://localhost"))
+	if err := e.Control(Command{Action: "pause"}); err != ErrNotRunning {
+		t.Errorf("expected ErrNotRunning, got %v", err)
+	}
+	if e.ControlState().Running {
+		t.Error("expected Running=false before Run")
+	}
+}
+
+func TestEngine_Control_PauseRecordsInterventions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg := makeConfig(srv.URL)
+	cfg.Load.Stages[0].Duration.Duration = 400 * time.Millisecond
+	cfg.Load.Stages[0].Ramp = "linear"
+	e := New(cfg)
+
+	go func() {
+		for !e.ControlState().Running {
+			time.Sleep(5 * time.Millisecond)
+		}
+		e.Control(Command{Action: "pause", Source: "test"})
+		time.Sleep(100 * time.Millisecond)
+		e.Control(Command{Action: "target", Value: "2", Source: "test"})
+		e.Control(Command{Action: "resume", Source: "test"})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stats, err := e.Run(ctx, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats.Interventions) != 3 {
+		t.Fatalf("expected 3 interventions, got %+v", stats.Interventions)
+	}
+	if iv := stats.Interventions[1]; iv.Action != "target" || iv.Value != "2" || iv.Source != "test" {
+		t.Errorf("unexpected intervention: %+v", iv)
+	}
+	if stats.Elapsed < 500*time.Millisecond {
+		t.Errorf("expected pause to extend the run, elapsed %v", stats.Elapsed)
+	}
+}
+
+func TestEngine_Control_AdaptiveRejectsSkip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg := makeConfig(srv.URL)
+	cfg.Load.Adaptive = &config.AdaptiveConfig{
+		TargetLatency: config.Duration{Duration: time.Second},
+		Min:           1, Max: 3, Initial: 1, Decrease: 0.5, Increase: 1, Percentile: 99,
+		Interval: config.Duration{Duration: time.Second},
+	}
+	e := New(cfg)
+
+	errCh := make(chan error, 1)
+	go func() {
+		for !e.ControlState().Running {
+			time.Sleep(5 * time.Millisecond)
+		}
+		errCh <- e.Control(Command{Action: "skip"})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.Run(ctx, io.Discard)
+	if err := <-errCh; err == nil {
+		t.Error("expected skip to be rejected in adaptive mode")
+	}
+}
+
+func TestEngine_Run_GracefulStopDrainsInFlight(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(250 * time.Millisecond)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg := makeConfig(srv.URL)
+	cfg.Load.Stages[0].Ramp = "step"
+	cfg.Load.Stages = append(cfg.Load.Stages, config.Stage{Duration: config.Duration{Duration: 200 * time.Millisecond}, Target: 3, Ramp: "step"})
+	cfg.Load.GracefulStop = config.Duration{Duration: 2 * time.Second}
+	e := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := e.Run(ctx, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.DrainedRequests == 0 {
+		t.Error("expected in-flight requests to complete during graceful stop")
+	}
+	if stats.InterruptedRequests != 0 {
+		t.Errorf("expected no interrupted requests, got %d", stats.InterruptedRequests)
+	}
+}
+
+func TestEngine_Run_NoGracefulStopInterrupts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg := makeConfig(srv.URL)
+	cfg.Load.Stages[0].Duration.Duration = 5 * time.Second
+	cfg.Load.Stages[0].Ramp = "step"
+	cfg.Load.Stages = append(cfg.Load.Stages, config.Stage{Duration: config.Duration{Duration: time.Second}, Target: 0})
+	e := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	stats, _ := e.Run(ctx, io.Discard)
+	if stats.InterruptedRequests != 3 {
+		t.Errorf("expected 3 interrupted requests (one per VU), got %d", stats.InterruptedRequests)
+	}
+}
+
+func TestEngine_Run_ArrivalRateGracefulStop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg := makeConfig(srv.URL)
+	cfg.Load.Mode = "arrival_rate"
+	cfg.Load.Stages[0].Target = 20
+	cfg.Load.Stages[0].Duration.Duration = 5 * time.Second
+	cfg.Load.Stages[0].Ramp = "step"
+	cfg.Load.Stages = append(cfg.Load.Stages, config.Stage{Duration: config.Duration{Duration: time.Second}, Target: 0})
+	cfg.Load.GracefulStop = config.Duration{Duration: 2 * time.Second}
+	e := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	stats, _ := e.Run(ctx, io.Discard)
+	if stats.DrainedRequests == 0 {
+		t.Error("expected in-flight arrival-rate requests to drain after stop")
+	}
+	if stats.InterruptedRequests != 0 {
+		t.Errorf("expected no interrupted requests, got %d", stats.InterruptedRequests)
+	}
+}
+
+func TestEngine_Run_AgainstMock(t *testing.T) {
+	m, err := mock.New(mock.Config{Seed: 1, Routes: []mock.Route{
+		{Method: "GET", Path: "/health", Latency: mock.Latency{Mean: config.Duration{Duration: 5 * time.Millisecond}}},
+		{Method: "GET", Path: "/flaky", ErrorRate: 0.5, ErrorStatus: 503},
+		{Method: "GET", Path: "/limited", RateLimit: &mock.RateLimit{RPS: 5, Burst: 1}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+
+	cfg := makeConfig(srv.URL)
+	cfg.Endpoints = append(cfg.Endpoints,
+		config.Endpoint{Name: "flaky", Method: "GET", URL: srv.URL + "/flaky", Weight: 1, Expect: config.ExpectConfig{Status: 200}},
+		config.Endpoint{Name: "limited", Method: "GET", URL: srv.URL + "/limited", Weight: 1, Expect: config.ExpectConfig{Status: 200}},
+	)
+	e := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := e.Run(ctx, io.Discard)
+	if err == nil {
+		t.Fatal("expected errors from injected failures and rate limiting")
+	}
+	if s := stats.PerEndpoint["health"]; s == nil || s.ErrorCount != 0 || s.Min < 5*time.Millisecond {
+		t.Errorf("health stats = %+v, want no errors and >= 5ms latency", s)
+	}
+	for _, name := range []string{"flaky", "limited"} {
+		if s := stats.PerEndpoint[name]; s == nil || s.ErrorCount == 0 || s.SuccessCount == 0 {
+			t.Errorf("%s stats = %+v, want both successes and failures", name, s)
+		}
+	}
+}
+
+func TestEngine_Run_PerVUConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg := makeConfig(srv.URL)
+	cfg.HTTP.ConnectionMode = "per_vu"
+	cfg.Load.Stages = []config.Stage{
+		{Duration: config.Duration{Duration: 50 * time.Millisecond}, Target: 3, Ramp: "step"},
+		{Duration: config.Duration{Duration: 300 * time.Millisecond}, Target: 3},
+	}
+	cfg.Load.ThinkTime = config.Duration{Duration: 10 * time.Millisecond}
+
+	stats, err := New(cfg).Run(context.Background(), io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Each of the 3 VUs keeps its own connection for the whole run.
+	if stats.NewConns != 3 || stats.ReusedConns == 0 {
+		t.Errorf("new = %d, reused = %d; want 3 new and the rest reused", stats.NewConns, stats.ReusedConns)
+	}
+}
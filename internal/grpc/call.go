@@ -0,0 +1,205 @@
+// Package grpc makes unary gRPC calls over net/http without generated code.
+// Message types come from a protoset file or from the server's reflection
+// service, and requests are built from JSON.
+package grpc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Code is a gRPC status code.
+type Code int
+
+// Status codes used by name in this package.
+const (
+	OK               Code = 0
+	Unknown          Code = 2
+	DeadlineExceeded Code = 4
+	Unimplemented    Code = 12
+	Internal         Code = 13
+	Unavailable      Code = 14
+)
+
+var codeNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED",
+	"NOT_FOUND", "ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED",
+	"INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+func (c Code) String() string {
+	if c >= 0 && int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+	return "CODE(" + strconv.Itoa(int(c)) + ")"
+}
+
+// ParseCode parses a status code name, such as "NOT_FOUND", or number.
+func ParseCode(s string) (Code, error) {
+	for i, name := range codeNames {
+		if strings.EqualFold(s, name) {
+			return Code(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(codeNames) {
+		return Code(n), nil
+	}
+	return 0, fmt.Errorf("unknown grpc status code %q", s)
+}
+
+// Status is the outcome the server reported for a call.
+type Status struct {
+	Code    Code
+	Message string
+}
+
+func (s *Status) Error() string {
+	if s.Message == "" {
+		return "grpc status " + s.Code.String()
+	}
+	return "grpc status " + s.Code.String() + ": " + s.Message
+}
+
+// HTTPURL maps a grpc:// or grpcs:// URL to the http:// (h2c) or https://
+// URL calls are sent to. Other URLs are returned unchanged.
+func HTTPURL(u string) string {
+	if rest, ok := strings.CutPrefix(u, "grpc://"); ok {
+		return "http://" + rest
+	}
+	if rest, ok := strings.CutPrefix(u, "grpcs://"); ok {
+		return "https://" + rest
+	}
+	return u
+}
+
+// Frame wraps an encoded message in gRPC's length-prefixed framing.
+func Frame(msg []byte) []byte {
+	b := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)))
+	return append(b, msg...)
+}
+
+// Response is the outcome of a unary call.
+type Response struct {
+	HTTP    *http.Response // nil if the deadline passed before a response arrived
+	Status  Status
+	Message []byte // the encoded reply, if Status is OK
+	Size    int64  // bytes of response body received
+}
+
+// Invoke sends req, a POST of a framed message to the method's path, as a
+// unary call. Transport failures are returned as errors; a call the server
+// answered, or whose deadline passed, yields a Response with its status.
+func Invoke(client *http.Client, req *http.Request) (*Response, error) {
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	if deadline, ok := req.Context().Deadline(); ok {
+		req.Header.Set("Grpc-Timeout", encodeTimeout(time.Until(deadline)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return deadlineExceeded(req.Context(), err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return deadlineExceeded(req.Context(), fmt.Errorf("reading response: %w", err))
+	}
+
+	out := &Response{HTTP: resp, Size: int64(len(body))}
+	if resp.StatusCode != http.StatusOK {
+		out.Status = Status{Code: httpStatusCode(resp.StatusCode), Message: "HTTP status " + resp.Status}
+		return out, nil
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/grpc") {
+		out.Status = Status{Code: Unknown, Message: "unexpected content type " + resp.Header.Get("Content-Type")}
+		return out, nil
+	}
+
+	// Trailers-only responses carry the status in the headers.
+	status := resp.Trailer.Get("Grpc-Status")
+	msg := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, msg = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if status == "" {
+		out.Status = Status{Code: Internal, Message: "response has no grpc-status"}
+		return out, nil
+	}
+	n, err := strconv.Atoi(status)
+	if err != nil {
+		out.Status = Status{Code: Unknown, Message: "invalid grpc-status " + status}
+		return out, nil
+	}
+	if m, err := url.PathUnescape(msg); err == nil {
+		msg = m
+	}
+	out.Status = Status{Code: Code(n), Message: msg}
+	if out.Status.Code != OK {
+		return out, nil
+	}
+
+	if len(body) < 5 {
+		out.Status = Status{Code: Internal, Message: "response has no message"}
+		return out, nil
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	switch {
+	case body[0] != 0:
+		out.Status = Status{Code: Internal, Message: "response message is compressed"}
+	case uint64(len(body)-5) < uint64(size):
+		out.Status = Status{Code: Internal, Message: "response message is truncated"}
+	default:
+		out.Message = body[5 : 5+size]
+	}
+	return out, nil
+}
+
+// deadlineExceeded reports err as a DEADLINE_EXCEEDED status if the call's
+// deadline caused it.
+func deadlineExceeded(ctx context.Context, err error) (*Response, error) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &Response{Status: Status{Code: DeadlineExceeded, Message: "deadline exceeded"}}, nil
+	}
+	return nil, err
+}
+
+// encodeTimeout formats d as a grpc-timeout header value.
+func encodeTimeout(d time.Duration) string {
+	ms := (d + time.Millisecond - 1) / time.Millisecond
+	if ms < 1 {
+		ms = 1
+	}
+	if ms < 1e8 {
+		return strconv.FormatInt(int64(ms), 10) + "m"
+	}
+	return strconv.FormatInt(int64(d/time.Second), 10) + "S"
+}
+
+// httpStatusCode maps an HTTP error status to the gRPC code clients report
+// for it.
+func httpStatusCode(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return Internal
+	case http.StatusUnauthorized:
+		return 16 // UNAUTHENTICATED
+	case http.StatusForbidden:
+		return 7 // PERMISSION_DENIED
+	case http.StatusNotFound:
+		return Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return Unavailable
+	}
+	return Unknown
+}
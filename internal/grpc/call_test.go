@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCall(t *testing.T, ctx context.Context, url string, msg []byte) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/test.v1.Greeter/SayHello", strings.NewReader(string(Frame(msg))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestInvoke(t *testing.T) {
+	srv := grpcServer(t, func(r *http.Request, msg []byte) ([]byte, *Status) {
+		if r.ProtoMajor != 2 || r.Header.Get("Content-Type") != "application/grpc" || r.Header.Get("X-Tenant") != "a" {
+			t.Errorf("got %s, content-type %q, metadata %q", r.Proto, r.Header.Get("Content-Type"), r.Header.Get("X-Tenant"))
+		}
+		if r.Header.Get("Grpc-Timeout") == "" {
+			t.Error("no grpc-timeout sent")
+		}
+		switch string(msg) {
+		case "missing":
+			return nil, &Status{Code: 5, Message: "no such user"}
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+		return append([]byte("re:"), msg...), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	for _, tc := range []struct {
+		msg, reply string
+		code       Code
+	}{
+		{"hi", "re:hi", OK},
+		{"missing", "", 5},
+		{"slow", "", DeadlineExceeded},
+	} {
+		req := newCall(t, ctx, srv.URL, []byte(tc.msg))
+		req.Header.Set("X-Tenant", "a")
+		resp, err := Invoke(h2cClient(), req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.msg, err)
+		}
+		if resp.Status.Code != tc.code || string(resp.Message) != tc.reply {
+			t.Errorf("%s: status %v, reply %q; want %v, %q", tc.msg, &resp.Status, resp.Message, tc.code, tc.reply)
+		}
+	}
+}
+
+func TestInvoke_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	resp, err := Invoke(srv.Client(), newCall(t, context.Background(), srv.URL, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status.Code != Unavailable || resp.HTTP.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %v, http %d", &resp.Status, resp.HTTP.StatusCode)
+	}
+
+	if _, err := Invoke(srv.Client(), newCall(t, context.Background(), "http://127.0.0.1:1", nil)); err == nil {
+		t.Error("expected a connection error")
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	for in, want := range map[string]Code{"OK": OK, "not_found": 5, "14": Unavailable, "UNAUTHENTICATED": 16} {
+		if got, err := ParseCode(in); err != nil || got != want {
+			t.Errorf("ParseCode(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "NOPE", "17", "-1"} {
+		if _, err := ParseCode(in); err == nil {
+			t.Errorf("ParseCode(%q): expected error", in)
+		}
+	}
+	if s := Code(5).String(); s != "NOT_FOUND" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestEncodeTimeout(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		1500 * time.Microsecond: "2m",
+		0:                       "1m",
+		30 * time.Hour:          "108000S",
+	} {
+		if got := encodeTimeout(d); got != want {
+			t.Errorf("encodeTimeout(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestHTTPURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"grpc://a:50051":  "http://a:50051",
+		"grpcs://a:443":   "https://a:443",
+		"https://a":       "https://a",
+		"unix:///s.sock:": "unix:///s.sock:",
+	} {
+		if got := HTTPURL(in); got != want {
+			t.Errorf("HTTPURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package grpc
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Field types (google.protobuf.FieldDescriptorProto.Type).
+const (
+	typeDouble   = 1
+	typeFloat    = 2
+	typeInt64    = 3
+	typeUint64   = 4
+	typeInt32    = 5
+	typeFixed64  = 6
+	typeFixed32  = 7
+	typeBool     = 8
+	typeString   = 9
+	typeGroup    = 10
+	typeMessage  = 11
+	typeBytes    = 12
+	typeUint32   = 13
+	typeEnum     = 14
+	typeSfixed32 = 15
+	typeSfixed64 = 16
+	typeSint32   = 17
+	typeSint64   = 18
+)
+
+const labelRepeated = 3
+
+// Message describes a protobuf message type.
+type Message struct {
+	Name     string // fully qualified, e.g. "helloworld.HelloRequest"
+	Fields   []*Field
+	mapEntry bool
+	byName   map[string]*Field // by proto and JSON name
+	byNumber map[int]*Field
+}
+
+// Field describes one field of a Message.
+type Field struct {
+	Name     string
+	JSONName string
+	Number   int
+	Type     int
+	Repeated bool
+	typeName string   // for message and enum fields, fully qualified
+	Message  *Message // resolved for message fields
+	Enum     *Enum    // resolved for enum fields
+}
+
+// Enum describes a protobuf enum type.
+type Enum struct {
+	Name    string
+	numbers map[string]int32
+	names   map[int32]string
+}
+
+// Method describes an RPC method.
+type Method struct {
+	Name            string // "package.Service/Method"
+	Input, Output   *Message
+	ClientStreaming bool
+	ServerStreaming bool
+	input, output   string // type names, until resolved
+}
+
+// isMap reports whether f is a map field.
+func (f *Field) isMap() bool {
+	return f.Repeated && f.Message != nil && f.Message.mapEntry
+}
+
+// Registry holds the message, enum and service types from a set of .proto
+// files.
+type Registry struct {
+	files    map[string]bool
+	deps     []string
+	messages map[string]*Message
+	enums    map[string]*Enum
+	methods  map[string]*Method
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		files:    make(map[string]bool),
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+		methods:  make(map[string]*Method),
+	}
+}
+
+// LoadProtoset reads a FileDescriptorSet, as written by
+// protoc --descriptor_set_out --include_imports.
+func LoadProtoset(path string) (*Registry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading protoset: %w", err)
+	}
+	r := NewRegistry()
+	err = walk(b, func(num, wt int, _ uint64, data []byte) error {
+		if num == 1 && wt == wireBytes {
+			return r.AddFile(data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parsing protoset %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// AddFile adds the types of an encoded FileDescriptorProto. Files already
+// added are skipped.
+func (r *Registry) AddFile(b []byte) error {
+	var name, pkg string
+	var deps, messages, enums, services [][]byte
+	err := walk(b, func(num, wt int, _ uint64, data []byte) error {
+		if wt != wireBytes {
+			return nil
+		}
+		switch num {
+		case 1:
+			name = string(data)
+		case 2:
+			pkg = string(data)
+		case 3:
+			deps = append(deps, data)
+		case 4:
+			messages = append(messages, data)
+		case 5:
+			enums = append(enums, data)
+		case 6:
+			services = append(services, data)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("parsing file descriptor: %w", err)
+	}
+	if r.files[name] {
+		return nil
+	}
+	r.files[name] = true
+	for _, d := range deps {
+		r.deps = append(r.deps, string(d))
+	}
+
+	prefix := ""
+	if pkg != "" {
+		prefix = pkg + "."
+	}
+	for _, m := range messages {
+		if err := r.addMessage(prefix, m); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, e := range enums {
+		if err := r.addEnum(prefix, e); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, s := range services {
+		if err := r.addService(prefix, s); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Missing returns the dependencies of added files that have not been added
+// themselves.
+func (r *Registry) Missing() []string {
+	var missing []string
+	for _, d := range r.deps {
+		if !r.files[d] {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+func (r *Registry) addMessage(prefix string, b []byte) error {
+	m := &Message{byName: make(map[string]*Field), byNumber: make(map[int]*Field)}
+	var nested, enums [][]byte
+	err := walk(b, func(num, wt int, _ uint64, data []byte) error {
+		if wt != wireBytes {
+			return nil
+		}
+		switch num {
+		case 1:
+			m.Name = prefix + string(data)
+		case 2:
+			f, err := parseField(data)
+			if err != nil {
+				return err
+			}
+			m.Fields = append(m.Fields, f)
+		case 3:
+			nested = append(nested, data)
+		case 4:
+			enums = append(enums, data)
+		case 7: // MessageOptions
+			return walk(data, func(num, _ int, v uint64, _ []byte) error {
+				if num == 7 {
+					m.mapEntry = v != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(m.Fields, func(a, b *Field) int { return a.Number - b.Number })
+	for _, f := range m.Fields {
+		m.byName[f.Name] = f
+		m.byName[f.JSONName] = f
+		m.byNumber[f.Number] = f
+	}
+	r.messages[m.Name] = m
+	for _, n := range nested {
+		if err := r.addMessage(m.Name+".", n); err != nil {
+			return err
+		}
+	}
+	for _, e := range enums {
+		if err := r.addEnum(m.Name+".", e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseField(b []byte) (*Field, error) {
+	f := &Field{}
+	err := walk(b, func(num, wt int, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			f.Name = string(data)
+		case 3:
+			f.Number = int(v)
+		case 4:
+			f.Repeated = v == labelRepeated
+		case 5:
+			f.Type = int(v)
+		case 6:
+			f.typeName = strings.TrimPrefix(string(data), ".")
+		case 10:
+			f.JSONName = string(data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f.JSONName == "" {
+		f.JSONName = jsonName(f.Name)
+	}
+	if f.Type == typeGroup {
+		return nil, fmt.Errorf("field %s: groups are not supported", f.Name)
+	}
+	return f, nil
+}
+
+// jsonName converts a field name to lowerCamelCase, as protoc does.
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, c := range name {
+		switch {
+		case c == '_':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			b.WriteRune(c - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteRune(c)
+			upper = false
+		}
+	}
+	return b.String()
+}
+
+func (r *Registry) addEnum(prefix string, b []byte) error {
+	e := &Enum{numbers: make(map[string]int32), names: make(map[int32]string)}
+	err := walk(b, func(num, wt int, _ uint64, data []byte) error {
+		switch {
+		case num == 1 && wt == wireBytes:
+			e.Name = prefix + string(data)
+		case num == 2 && wt == wireBytes:
+			var name string
+			var number int32
+			err := walk(data, func(num, _ int, v uint64, data []byte) error {
+				switch num {
+				case 1:
+					name = string(data)
+				case 2:
+					number = int32(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			e.numbers[name] = number
+			if _, ok := e.names[number]; !ok {
+				e.names[number] = name
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.enums[e.Name] = e
+	return nil
+}
+
+func (r *Registry) addService(prefix string, b []byte) error {
+	var service string
+	var methods []*Method
+	err := walk(b, func(num, wt int, _ uint64, data []byte) error {
+		switch {
+		case num == 1 && wt == wireBytes:
+			service = prefix + string(data)
+		case num == 2 && wt == wireBytes:
+			m := &Method{}
+			err := walk(data, func(num, _ int, v uint64, data []byte) error {
+				switch num {
+				case 1:
+					m.Name = string(data)
+				case 2:
+					m.input = strings.TrimPrefix(string(data), ".")
+				case 3:
+					m.output = strings.TrimPrefix(string(data), ".")
+				case 5:
+					m.ClientStreaming = v != 0
+				case 6:
+					m.ServerStreaming = v != 0
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			methods = append(methods, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range methods {
+		m.Name = service + "/" + m.Name
+		r.methods[m.Name] = m
+	}
+	return nil
+}
+
+// Method looks up a method by its "package.Service/Method" name and
+// resolves the types it uses.
+func (r *Registry) Method(name string) (*Method, error) {
+	m, ok := r.methods[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown grpc method %s", name)
+	}
+	var err error
+	if m.Input, err = r.resolve(m.input); err != nil {
+		return nil, err
+	}
+	if m.Output, err = r.resolve(m.output); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// resolve returns the named message with the types of its fields, and
+// theirs, linked in.
+func (r *Registry) resolve(name string) (*Message, error) {
+	m, ok := r.messages[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown message type %s", name)
+	}
+	seen := make(map[*Message]bool)
+	var link func(m *Message) error
+	link = func(m *Message) error {
+		if seen[m] {
+			return nil
+		}
+		seen[m] = true
+		for _, f := range m.Fields {
+			switch f.Type {
+			case typeMessage:
+				if f.Message = r.messages[f.typeName]; f.Message == nil {
+					return fmt.Errorf("%s.%s: unknown message type %s", m.Name, f.Name, f.typeName)
+				}
+				if err := link(f.Message); err != nil {
+					return err
+				}
+			case typeEnum:
+				if f.Enum = r.enums[f.typeName]; f.Enum == nil {
+					return fmt.Errorf("%s.%s: unknown enum type %s", m.Name, f.Name, f.typeName)
+				}
+			}
+		}
+		return nil
+	}
+	return m, link(m)
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProtoset(t *testing.T) {
+	r, err := LoadProtoset(filepath.Join("testdata", "greeter.protoset"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := r.Method("test.v1.Greeter/SayHello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Input.Name != "test.v1.HelloRequest" || m.Output.Name != "test.v1.HelloReply" {
+		t.Errorf("method types = %s, %s", m.Input.Name, m.Output.Name)
+	}
+	sentAt := m.Input.byName["sentAt"]
+	if sentAt == nil || sentAt.Message == nil || sentAt.Message.Name != "google.protobuf.Timestamp" {
+		t.Errorf("sent_at = %+v, want a resolved Timestamp field", sentAt)
+	}
+	if tags := m.Input.byName["tags"]; tags == nil || !tags.isMap() {
+		t.Errorf("tags = %+v, want a map field", tags)
+	}
+	if missing := r.Missing(); len(missing) != 0 {
+		t.Errorf("missing = %v", missing)
+	}
+}
+
+func TestRegistry_Method_Errors(t *testing.T) {
+	r := NewRegistry()
+	if err := r.AddFile(greeterFile()); err != nil {
+		t.Fatal(err)
+	}
+	if missing := r.Missing(); len(missing) != 1 || missing[0] != "google/protobuf/timestamp.proto" {
+		t.Errorf("missing = %v", missing)
+	}
+	if _, err := r.Method("test.v1.Greeter/SayHello"); err == nil || !strings.Contains(err.Error(), "unknown message type google.protobuf.Timestamp") {
+		t.Errorf("err = %v, want an unresolved type", err)
+	}
+	if _, err := r.Method("test.v1.Greeter/SayBye"); err == nil || !strings.Contains(err.Error(), "unknown grpc method") {
+		t.Errorf("err = %v, want an unknown method", err)
+	}
+}
+
+func TestJSONName(t *testing.T) {
+	for in, want := range map[string]string{"name": "name", "sent_at": "sentAt", "a_b_c": "aBC", "x2_y": "x2Y"} {
+		if got := jsonName(in); got != want {
+			t.Errorf("jsonName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
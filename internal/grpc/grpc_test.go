@@ -0,0 +1,144 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// Builders for the descriptors in testdata/greeter.proto.
+
+func fieldDesc(name string, num, typ int, typeName string, repeated bool) []byte {
+	b := appendBytes(nil, 1, []byte(name))
+	b = appendTag(b, 3, wireVarint)
+	b = appendVarint(b, uint64(num))
+	label := 1
+	if repeated {
+		label = labelRepeated
+	}
+	b = appendTag(b, 4, wireVarint)
+	b = appendVarint(b, uint64(label))
+	b = appendTag(b, 5, wireVarint)
+	b = appendVarint(b, uint64(typ))
+	if typeName != "" {
+		b = appendBytes(b, 6, []byte(typeName))
+	}
+	return b
+}
+
+func messageDesc(name string, fields [][]byte, nested ...[]byte) []byte {
+	b := appendBytes(nil, 1, []byte(name))
+	for _, f := range fields {
+		b = appendBytes(b, 2, f)
+	}
+	for _, n := range nested {
+		b = appendBytes(b, 3, n)
+	}
+	return b
+}
+
+func mapEntryDesc(name string, key, value []byte) []byte {
+	b := messageDesc(name, [][]byte{key, value})
+	opts := appendTag(nil, 7, wireVarint)
+	opts = appendVarint(opts, 1)
+	return appendBytes(b, 7, opts)
+}
+
+func timestampFile() []byte {
+	b := appendBytes(nil, 1, []byte("google/protobuf/timestamp.proto"))
+	b = appendBytes(b, 2, []byte("google.protobuf"))
+	return appendBytes(b, 4, messageDesc("Timestamp", [][]byte{
+		fieldDesc("seconds", 1, typeInt64, "", false),
+		fieldDesc("nanos", 2, typeInt32, "", false),
+	}))
+}
+
+func greeterFile() []byte {
+	b := appendBytes(nil, 1, []byte("greeter.proto"))
+	b = appendBytes(b, 2, []byte("test.v1"))
+	b = appendBytes(b, 3, []byte("google/protobuf/timestamp.proto"))
+	b = appendBytes(b, 4, messageDesc("HelloRequest", [][]byte{
+		fieldDesc("name", 1, typeString, "", false),
+		fieldDesc("count", 2, typeInt64, "", false),
+		fieldDesc("ids", 3, typeInt32, "", true),
+		fieldDesc("kind", 4, typeEnum, ".test.v1.Kind", false),
+		fieldDesc("tags", 5, typeMessage, ".test.v1.HelloRequest.TagsEntry", true),
+		fieldDesc("inner", 6, typeMessage, ".test.v1.HelloRequest.Inner", false),
+		fieldDesc("blob", 7, typeBytes, "", false),
+		fieldDesc("sent_at", 8, typeMessage, ".google.protobuf.Timestamp", false),
+		fieldDesc("delta", 9, typeSint32, "", false),
+		fieldDesc("ratio", 10, typeDouble, "", false),
+		fieldDesc("flag", 11, typeBool, "", false),
+	},
+		mapEntryDesc("TagsEntry", fieldDesc("key", 1, typeString, "", false), fieldDesc("value", 2, typeInt32, "", false)),
+		messageDesc("Inner", [][]byte{fieldDesc("notes", 1, typeString, "", true)}),
+	))
+	b = appendBytes(b, 4, messageDesc("HelloReply", [][]byte{fieldDesc("message", 1, typeString, "", false)}))
+
+	enum := appendBytes(nil, 1, []byte("Kind"))
+	for i, name := range []string{"KIND_UNSPECIFIED", "KIND_A"} {
+		v := appendBytes(nil, 1, []byte(name))
+		v = appendTag(v, 2, wireVarint)
+		v = appendVarint(v, uint64(i))
+		enum = appendBytes(enum, 2, v)
+	}
+	b = appendBytes(b, 5, enum)
+
+	method := appendBytes(nil, 1, []byte("SayHello"))
+	method = appendBytes(method, 2, []byte(".test.v1.HelloRequest"))
+	method = appendBytes(method, 3, []byte(".test.v1.HelloReply"))
+	service := appendBytes(nil, 1, []byte("Greeter"))
+	service = appendBytes(service, 2, method)
+	return appendBytes(b, 6, service)
+}
+
+func greeterRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	for _, f := range [][]byte{timestampFile(), greeterFile()} {
+		if err := r.AddFile(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+// grpcServer serves h over h2c. h gets the request message and returns the
+// reply, or a status to end the call with.
+func grpcServer(t *testing.T, h func(r *http.Request, msg []byte) ([]byte, *Status)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var msg []byte
+		if len(body) >= 5 {
+			msg = body[5 : 5+binary.BigEndian.Uint32(body[1:5])]
+		}
+		reply, st := h(r, msg)
+		w.Header().Set("Content-Type", "application/grpc")
+		if st != nil {
+			// A trailers-only response.
+			w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code)))
+			w.Header().Set("Grpc-Message", st.Message)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Write(Frame(reply))
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetHTTP1(true)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// h2cClient returns a client that speaks HTTP/2 without TLS.
+func h2cClient() *http.Client {
+	tr := &http.Transport{Protocols: new(http.Protocols)}
+	tr.Protocols.SetUnencryptedHTTP2(true)
+	return &http.Client{Transport: tr}
+}
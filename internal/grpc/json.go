@@ -0,0 +1,569 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal encodes a JSON object as a message of type m, following the
+// protobuf JSON mapping: fields by proto or JSON name, 64-bit integers as
+// numbers or strings, bytes as base64, enums by name or number, and
+// Timestamp, Duration and wrapper types in their JSON forms.
+func (m *Message) Marshal(js []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("parsing JSON: unexpected data after the message")
+	}
+	return m.encode(v)
+}
+
+// encode encodes v, a decoded JSON value, as a message of type m.
+func (m *Message) encode(v any) ([]byte, error) {
+	switch m.Name {
+	case "google.protobuf.Timestamp":
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("expected an RFC 3339 timestamp string")
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, err
+		}
+		return appendSecondsNanos(nil, t.Unix(), int32(t.Nanosecond())), nil
+	case "google.protobuf.Duration":
+		s, ok := v.(string)
+		if !ok || !strings.HasSuffix(s, "s") {
+			return nil, errors.New(`expected a duration string such as "1.5s"`)
+		}
+		secs, err := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+		if err != nil {
+			return nil, err
+		}
+		whole, frac := math.Modf(secs)
+		return appendSecondsNanos(nil, int64(whole), int32(math.Round(frac*1e9))), nil
+	}
+	if isWrapper(m.Name) {
+		return m.byNumber[1].encode(nil, v)
+	}
+
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object for %s", m.Name)
+	}
+	for _, k := range sortedKeys(obj) {
+		if m.byName[k] == nil {
+			return nil, fmt.Errorf("%s has no field %q", m.Name, k)
+		}
+	}
+	// Fields are written in field number order, as protobuf encoders do.
+	var b []byte
+	for _, f := range m.Fields {
+		k := f.JSONName
+		v, ok := obj[k]
+		if !ok {
+			k = f.Name
+			v = obj[k]
+		}
+		if v == nil {
+			continue
+		}
+		var err error
+		if b, err = f.encode(b, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return b, nil
+}
+
+func appendSecondsNanos(b []byte, secs int64, nanos int32) []byte {
+	if secs != 0 {
+		b = appendTag(b, 1, wireVarint)
+		b = appendVarint(b, uint64(secs))
+	}
+	if nanos != 0 {
+		b = appendTag(b, 2, wireVarint)
+		b = appendVarint(b, uint64(int64(nanos)))
+	}
+	return b
+}
+
+func isWrapper(name string) bool {
+	switch name {
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return true
+	}
+	return false
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// encode appends field f with value v.
+func (f *Field) encode(b []byte, v any) ([]byte, error) {
+	switch {
+	case f.isMap():
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, errors.New("expected a JSON object")
+		}
+		key, val := f.Message.byNumber[1], f.Message.byNumber[2]
+		for _, k := range sortedKeys(obj) {
+			entry, err := key.encodeOne(nil, k)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k, err)
+			}
+			if obj[k] != nil {
+				if entry, err = val.encodeOne(entry, obj[k]); err != nil {
+					return nil, fmt.Errorf("%s: %w", k, err)
+				}
+			}
+			b = appendBytes(b, f.Number, entry)
+		}
+		return b, nil
+	case f.Repeated:
+		arr, ok := v.([]any)
+		if !ok {
+			return nil, errors.New("expected a JSON array")
+		}
+		if wireTypeOf(f.Type) != wireBytes {
+			var packed []byte
+			for i, e := range arr {
+				var err error
+				if packed, err = f.appendScalar(packed, e); err != nil {
+					return nil, fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+			return appendBytes(b, f.Number, packed), nil
+		}
+		for i, e := range arr {
+			var err error
+			if b, err = f.encodeOne(b, e); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return b, nil
+	}
+	return f.encodeOne(b, v)
+}
+
+// encodeOne appends a single value of f, with its tag.
+func (f *Field) encodeOne(b []byte, v any) ([]byte, error) {
+	switch f.Type {
+	case typeString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("expected a string")
+		}
+		return appendBytes(b, f.Number, []byte(s)), nil
+	case typeBytes:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("expected a base64 string")
+		}
+		data, err := decodeBase64(s)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(b, f.Number, data), nil
+	case typeMessage:
+		data, err := f.Message.encode(v)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(b, f.Number, data), nil
+	}
+	return f.appendScalar(appendTag(b, f.Number, wireTypeOf(f.Type)), v)
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if data, err := enc.DecodeString(s); err == nil {
+			return data, nil
+		}
+	}
+	return nil, errors.New("invalid base64")
+}
+
+func wireTypeOf(typ int) int {
+	switch typ {
+	case typeDouble, typeFixed64, typeSfixed64:
+		return wireFixed64
+	case typeFloat, typeFixed32, typeSfixed32:
+		return wireFixed32
+	case typeString, typeBytes, typeMessage:
+		return wireBytes
+	}
+	return wireVarint
+}
+
+// appendScalar appends a numeric, bool or enum value without a tag.
+func (f *Field) appendScalar(b []byte, v any) ([]byte, error) {
+	switch f.Type {
+	case typeBool:
+		switch v {
+		case true, "true":
+			return appendVarint(b, 1), nil
+		case false, "false":
+			return appendVarint(b, 0), nil
+		}
+		return nil, errors.New("expected true or false")
+	case typeEnum:
+		if name, ok := v.(string); ok {
+			n, ok := f.Enum.numbers[name]
+			if !ok {
+				return nil, fmt.Errorf("%s has no value %q", f.Enum.Name, name)
+			}
+			return appendVarint(b, uint64(int64(n))), nil
+		}
+		n, err := parseInt(v, 32)
+		if err != nil {
+			return nil, err
+		}
+		return appendVarint(b, uint64(n)), nil
+	case typeDouble, typeFloat:
+		s, err := numberText(v)
+		if err != nil {
+			return nil, err
+		}
+		if f.Type == typeFloat {
+			x, err := strconv.ParseFloat(s, 32)
+			if err != nil {
+				return nil, err
+			}
+			return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(x))), nil
+		}
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(x)), nil
+	case typeUint32, typeUint64, typeFixed32, typeFixed64:
+		bits := 64
+		if f.Type == typeUint32 || f.Type == typeFixed32 {
+			bits = 32
+		}
+		n, err := parseUint(v, bits)
+		if err != nil {
+			return nil, err
+		}
+		switch f.Type {
+		case typeFixed32:
+			return binary.LittleEndian.AppendUint32(b, uint32(n)), nil
+		case typeFixed64:
+			return binary.LittleEndian.AppendUint64(b, n), nil
+		}
+		return appendVarint(b, n), nil
+	}
+
+	bits := 64
+	if f.Type == typeInt32 || f.Type == typeSint32 || f.Type == typeSfixed32 {
+		bits = 32
+	}
+	n, err := parseInt(v, bits)
+	if err != nil {
+		return nil, err
+	}
+	switch f.Type {
+	case typeSint32, typeSint64:
+		return appendVarint(b, uint64(n<<1^(n>>63))), nil
+	case typeSfixed32:
+		return binary.LittleEndian.AppendUint32(b, uint32(n)), nil
+	case typeSfixed64:
+		return binary.LittleEndian.AppendUint64(b, uint64(n)), nil
+	}
+	return appendVarint(b, uint64(n)), nil
+}
+
+// numberText returns the text of a JSON number, or of a string holding one.
+func numberText(v any) (string, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return string(v), nil
+	case string:
+		return strings.TrimSpace(v), nil
+	}
+	return "", errors.New("expected a number")
+}
+
+func parseInt(v any, bits int) (int64, error) {
+	s, err := numberText(v)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(s, 10, bits)
+	if err != nil {
+		// Integral values may be written as 1e3 or 5.0.
+		x, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil || x != math.Trunc(x) || x < -math.Ldexp(1, bits-1) || x >= math.Ldexp(1, bits-1) {
+			return 0, fmt.Errorf("%q is not a %d-bit integer", s, bits)
+		}
+		n = int64(x)
+	}
+	return n, nil
+}
+
+func parseUint(v any, bits int) (uint64, error) {
+	s, err := numberText(v)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		x, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil || x != math.Trunc(x) || x < 0 || x >= math.Ldexp(1, bits) {
+			return 0, fmt.Errorf("%q is not an unsigned %d-bit integer", s, bits)
+		}
+		n = uint64(x)
+	}
+	return n, nil
+}
+
+// Unmarshal decodes an encoded message of type m as JSON. Fields are
+// written by JSON name in field order; unknown fields are skipped.
+func (m *Message) Unmarshal(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := m.decode(&buf, b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (m *Message) decode(w *bytes.Buffer, b []byte) error {
+	switch m.Name {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration":
+		var secs, nanos int64
+		err := walk(b, func(num, _ int, v uint64, _ []byte) error {
+			switch num {
+			case 1:
+				secs = int64(v)
+			case 2:
+				nanos = int64(int32(v))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if m.Name == "google.protobuf.Timestamp" {
+			w.WriteString(strconv.Quote(time.Unix(secs, nanos).UTC().Format(time.RFC3339Nano)))
+		} else {
+			w.WriteString(strconv.Quote(strconv.FormatFloat(float64(secs)+float64(nanos)/1e9, 'f', -1, 64) + "s"))
+		}
+		return nil
+	}
+
+	values := make(map[*Field][]string)
+	err := walk(b, func(num, wt int, v uint64, data []byte) error {
+		f := m.byNumber[num]
+		if f == nil {
+			return nil
+		}
+		if f.isMap() {
+			entry, err := f.Message.entryJSON(data)
+			if err != nil {
+				return err
+			}
+			values[f] = append(values[f], entry)
+			return nil
+		}
+		if wt == wireBytes && wireTypeOf(f.Type) != wireBytes {
+			return f.unpack(data, func(v uint64) {
+				values[f] = append(values[f], f.scalarJSON(v))
+			})
+		}
+		if wt != wireTypeOf(f.Type) {
+			return fmt.Errorf("%s.%s: unexpected wire type %d", m.Name, f.Name, wt)
+		}
+		s, err := f.valueJSON(v, data)
+		if err != nil {
+			return err
+		}
+		values[f] = append(values[f], s)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if isWrapper(m.Name) {
+		if vs := values[m.byNumber[1]]; len(vs) > 0 {
+			w.WriteString(vs[len(vs)-1])
+		} else {
+			w.WriteString(m.byNumber[1].zeroJSON())
+		}
+		return nil
+	}
+	w.WriteByte('{')
+	first := true
+	for _, f := range m.Fields {
+		vs, ok := values[f]
+		if !ok {
+			continue
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		w.WriteString(strconv.Quote(f.JSONName) + ":")
+		switch {
+		case f.isMap():
+			w.WriteString("{" + strings.Join(vs, ",") + "}")
+		case f.Repeated:
+			w.WriteString("[" + strings.Join(vs, ",") + "]")
+		default:
+			w.WriteString(vs[len(vs)-1])
+		}
+	}
+	w.WriteByte('}')
+	return nil
+}
+
+// entryJSON decodes a map entry as a "key":value pair.
+func (m *Message) entryJSON(b []byte) (string, error) {
+	key, val := m.byNumber[1], m.byNumber[2]
+	k, v := key.zeroJSON(), val.zeroJSON()
+	err := walk(b, func(num, wt int, x uint64, data []byte) error {
+		var err error
+		switch num {
+		case 1:
+			k, err = key.valueJSON(x, data)
+		case 2:
+			v, err = val.valueJSON(x, data)
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(k, `"`) {
+		k = strconv.Quote(k)
+	}
+	return k + ":" + v, nil
+}
+
+func (f *Field) valueJSON(v uint64, data []byte) (string, error) {
+	switch f.Type {
+	case typeString:
+		return jsonString(string(data)), nil
+	case typeBytes:
+		return strconv.Quote(base64.StdEncoding.EncodeToString(data)), nil
+	case typeMessage:
+		var buf bytes.Buffer
+		if err := f.Message.decode(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	return f.scalarJSON(v), nil
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// zeroJSON is the JSON of f's default value.
+func (f *Field) zeroJSON() string {
+	switch f.Type {
+	case typeString, typeBytes:
+		return `""`
+	case typeMessage:
+		return "{}"
+	}
+	return f.scalarJSON(0)
+}
+
+// unpack calls fn for each value in a packed repeated field.
+func (f *Field) unpack(data []byte, fn func(uint64)) error {
+	for len(data) > 0 {
+		switch wireTypeOf(f.Type) {
+		case wireFixed32:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			fn(uint64(binary.LittleEndian.Uint32(data)))
+			data = data[4:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			fn(binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		default:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			fn(v)
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+// scalarJSON formats a numeric, bool or enum value. 64-bit integers are
+// quoted, as the protobuf JSON mapping specifies.
+func (f *Field) scalarJSON(v uint64) string {
+	switch f.Type {
+	case typeBool:
+		return strconv.FormatBool(v != 0)
+	case typeEnum:
+		if name, ok := f.Enum.names[int32(v)]; ok {
+			return strconv.Quote(name)
+		}
+		return strconv.FormatInt(int64(int32(v)), 10)
+	case typeInt32, typeSfixed32:
+		return strconv.FormatInt(int64(int32(v)), 10)
+	case typeSint32:
+		return strconv.FormatInt(int64(int32(uint32(v)>>1)^-int32(v&1)), 10)
+	case typeUint32, typeFixed32:
+		return strconv.FormatUint(uint64(uint32(v)), 10)
+	case typeInt64, typeSfixed64:
+		return strconv.Quote(strconv.FormatInt(int64(v), 10))
+	case typeSint64:
+		return strconv.Quote(strconv.FormatInt(int64(v>>1)^-int64(v&1), 10))
+	case typeUint64, typeFixed64:
+		return strconv.Quote(strconv.FormatUint(v, 10))
+	case typeFloat:
+		return floatJSON(float64(math.Float32frombits(uint32(v))), 32)
+	case typeDouble:
+		return floatJSON(math.Float64frombits(v), 64)
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+func floatJSON(x float64, bits int) string {
+	switch {
+	case math.IsNaN(x):
+		return `"NaN"`
+	case math.IsInf(x, 1):
+		return `"Infinity"`
+	case math.IsInf(x, -1):
+		return `"-Infinity"`
+	}
+	return strconv.FormatFloat(x, 'g', -1, bits)
+}
@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"strings"
+	"testing"
+)
+
+func helloRequest(t *testing.T) *Message {
+	t.Helper()
+	m, err := greeterRegistry(t).Method("test.v1.Greeter/SayHello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m.Input
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	m := helloRequest(t)
+	b, err := m.Marshal([]byte(`{
+		"name": "ann", "count": "9007199254740993", "ids": [1, -2, 3e2],
+		"kind": "KIND_A", "tags": {"b": 2, "a": 1}, "inner": {"notes": ["x", "y"]},
+		"blob": "aGk=", "sent_at": "2024-05-01T12:00:00.5Z", "delta": -3,
+		"ratio": 0.25, "flag": true
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := m.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"ann","count":"9007199254740993","ids":[1,-2,300],"kind":"KIND_A",` +
+		`"tags":{"a":1,"b":2},"inner":{"notes":["x","y"]},"blob":"aGk=",` +
+		`"sentAt":"2024-05-01T12:00:00.5Z","delta":-3,"ratio":0.25,"flag":true}`
+	if string(js) != want {
+		t.Errorf("round trip:\n got %s\nwant %s", js, want)
+	}
+}
+
+func TestMarshal_Wire(t *testing.T) {
+	m := helloRequest(t)
+	b, err := m.Marshal([]byte(`{"name":"hi","kind":1,"delta":-1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// name = "hi", kind = 1, delta = zigzag(-1) = 1.
+	want := []byte{0x0a, 2, 'h', 'i', 0x20, 1, 0x48, 1}
+	if string(b) != string(want) {
+		t.Errorf("encoded % x, want % x", b, want)
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	m := helloRequest(t)
+	for js, want := range map[string]string{
+		`{"nope":1}`:              `test.v1.HelloRequest has no field "nope"`,
+		`{"name":5}`:              "name: expected a string",
+		`{"ids":[1,"x"]}`:         `ids: [1]: "x" is not a 32-bit integer`,
+		`{"kind":"KIND_Z"}`:       `test.v1.Kind has no value "KIND_Z"`,
+		`{"count":1.5}`:           `"1.5" is not a 64-bit integer`,
+		`{"sent_at":"yesterday"}`: "sent_at: parsing time",
+		`{"inner":{"notes":"x"}}`: "inner: notes: expected a JSON array",
+		`[1]`:                     "expected a JSON object",
+		`{"name":"a"} {}`:         "unexpected data after the message",
+		`{"name":`:                "parsing JSON",
+	} {
+		_, err := m.Marshal([]byte(js))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Marshal(%s): err = %v, want %q", js, err, want)
+		}
+	}
+}
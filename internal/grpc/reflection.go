@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// reflectionServices are the server reflection services, newest first.
+var reflectionServices = []string{
+	"grpc.reflection.v1.ServerReflection",
+	"grpc.reflection.v1alpha.ServerReflection",
+}
+
+// Reflect fetches the descriptors of service, and the files they import,
+// from the server's reflection service. newRequest builds the request for a
+// method with a framed message as its body.
+func Reflect(client *http.Client, newRequest func(method string, body []byte) (*http.Request, error), service string) (*Registry, error) {
+	for _, rs := range reflectionServices {
+		r := NewRegistry()
+		err := reflect(client, newRequest, rs, r, 4, service) // file_containing_symbol
+		var st *Status
+		if errors.As(err, &st) && st.Code == Unimplemented {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		requested := make(map[string]bool)
+		for missing := r.Missing(); len(missing) > 0; missing = r.Missing() {
+			for _, file := range missing {
+				if requested[file] {
+					return nil, fmt.Errorf("server reflection did not return %s", file)
+				}
+				requested[file] = true
+				if err := reflect(client, newRequest, rs, r, 3, file); err != nil { // file_by_filename
+					return nil, err
+				}
+			}
+		}
+		return r, nil
+	}
+	return nil, errors.New("server does not support reflection")
+}
+
+// reflect sends one ServerReflectionRequest, with field set to value, and
+// adds the files in the response to r.
+func reflect(client *http.Client, newRequest func(string, []byte) (*http.Request, error), service string, r *Registry, field int, value string) error {
+	req, err := newRequest(service+"/ServerReflectionInfo", Frame(appendBytes(nil, field, []byte(value))))
+	if err != nil {
+		return err
+	}
+	resp, err := Invoke(client, req)
+	if err != nil {
+		return fmt.Errorf("server reflection: %w", err)
+	}
+	if resp.Status.Code != OK {
+		return &resp.Status
+	}
+	return walk(resp.Message, func(num, wt int, _ uint64, data []byte) error {
+		switch {
+		case num == 4 && wt == wireBytes: // file_descriptor_response
+			return walk(data, func(num, wt int, _ uint64, file []byte) error {
+				if num == 1 && wt == wireBytes {
+					return r.AddFile(file)
+				}
+				return nil
+			})
+		case num == 7 && wt == wireBytes: // error_response
+			var msg string
+			walk(data, func(num, _ int, _ uint64, data []byte) error {
+				if num == 2 {
+					msg = string(data)
+				}
+				return nil
+			})
+			return fmt.Errorf("server reflection: %s: %s", value, msg)
+		}
+		return nil
+	})
+}
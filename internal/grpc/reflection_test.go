@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// reflectionServer serves only v1alpha reflection and returns each file
+// separately, so clients have to ask for imports.
+func reflectionServer(t *testing.T) (url string, requests *[]string) {
+	t.Helper()
+	files := map[string][]byte{
+		"greeter.proto":                   greeterFile(),
+		"google/protobuf/timestamp.proto": timestampFile(),
+	}
+	var seen []string
+	srv := grpcServer(t, func(r *http.Request, msg []byte) ([]byte, *Status) {
+		if !strings.HasPrefix(r.URL.Path, "/grpc.reflection.v1alpha.") {
+			return nil, &Status{Code: Unimplemented}
+		}
+		var file []byte
+		walk(msg, func(num, _ int, _ uint64, data []byte) error {
+			seen = append(seen, string(data))
+			switch {
+			case num == 4 && string(data) == "test.v1.Greeter":
+				file = files["greeter.proto"]
+			case num == 3:
+				file = files[string(data)]
+			}
+			return nil
+		})
+		if file == nil {
+			errResp := appendTag(nil, 1, wireVarint)
+			errResp = appendVarint(errResp, 5)
+			errResp = appendBytes(errResp, 2, []byte("symbol not found"))
+			return appendBytes(nil, 7, errResp), nil
+		}
+		return appendBytes(nil, 4, appendBytes(nil, 1, file)), nil
+	})
+	return srv.URL, &seen
+}
+
+func TestReflect(t *testing.T) {
+	url, requests := reflectionServer(t)
+	newRequest := func(method string, body []byte) (*http.Request, error) {
+		return http.NewRequest(http.MethodPost, url+"/"+method, strings.NewReader(string(body)))
+	}
+
+	r, err := Reflect(h2cClient(), newRequest, "test.v1.Greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Method("test.v1.Greeter/SayHello"); err != nil {
+		t.Error(err)
+	}
+	// After v1 is found unimplemented: the service, then its import.
+	if got := strings.Join(*requests, ","); got != "test.v1.Greeter,google/protobuf/timestamp.proto" {
+		t.Errorf("requests = %s", got)
+	}
+
+	if _, err := Reflect(h2cClient(), newRequest, "test.v1.Nope"); err == nil || !strings.Contains(err.Error(), "test.v1.Nope: symbol not found") {
+		t.Errorf("err = %v, want symbol not found", err)
+	}
+}
@@ -0,0 +1,285 @@
+package httpclient
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+// New builds the HTTP client used to send load, configured from the http
+// block of a test config.
+func New(cfg config.HTTPConfig) (*http.Client, error) {
+	tc, err := TLSConfig(cfg.TLS, cfg.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	n, err := newNetwork(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(cfg, tc, n.dialer(nil))
+}
+
+// Pool defaults, used where the http block leaves them unset.
+const (
+	defaultMaxIdleConns        = 1000
+	defaultMaxIdleConnsPerHost = 100
+	defaultIdleTimeout         = 90 * time.Second
+)
+
+func newClient(cfg config.HTTPConfig, tc *tls.Config, dial func(ctx context.Context, network, addr string) (net.Conn, error)) (*http.Client, error) {
+	proxy, err := proxyFunc(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	newTransport := func() (*http.Transport, error) {
+		t := &http.Transport{
+			Proxy:               proxy,
+			DialContext:         dial,
+			MaxIdleConns:        cmp.Or(cfg.MaxIdleConns, defaultMaxIdleConns),
+			MaxIdleConnsPerHost: cmp.Or(cfg.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost),
+			MaxConnsPerHost:     cfg.MaxConnsPerHost,
+			IdleConnTimeout:     cmp.Or(cfg.IdleTimeout.Duration, defaultIdleTimeout),
+			DisableKeepAlives:   cfg.ConnectionMode == "no_keepalive",
+			TLSClientConfig:     tc.Clone(),
+		}
+		return t, setProtocol(t, cfg.Protocol)
+	}
+
+	var transport http.RoundTripper
+	if cfg.MaxConcurrentStreams > 0 {
+		transport, err = newStreamPool(newTransport, max(cfg.MaxConnsPerHost, 1), cfg.MaxConcurrentStreams)
+	} else {
+		transport, err = newTransport()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout.Duration,
+	}
+
+	if !cfg.FollowRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return client, nil
+}
+
+// grpcProtocol is the protocol of the clients for grpc endpoints: HTTP/2
+// over TLS for https:// URLs and h2c for http:// ones.
+const grpcProtocol = "grpc"
+
+// setProtocol configures t for an http.protocol setting.
+func setProtocol(t *http.Transport, protocol string) error {
+	var p http.Protocols
+	switch protocol {
+	case "", "auto":
+		// A custom TLS config turns off HTTP/2 unless it is forced back on.
+		t.ForceAttemptHTTP2 = true
+		return nil
+	case "http1":
+		p.SetHTTP1(true)
+	case "http2":
+		p.SetHTTP2(true)
+	case "h2c":
+		p.SetUnencryptedHTTP2(true)
+	case grpcProtocol:
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+	default:
+		return fmt.Errorf("unknown http.protocol %q", protocol)
+	}
+	t.Protocols = &p
+	return nil
+}
+
+// TLSConfig builds the crypto/tls settings for t, loading its certificate
+// and CA files.
+func TLSConfig(t config.TLSConfig, insecureSkipVerify bool) (*tls.Config, error) {
+	tc := &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec
+		ServerName:         t.ServerName,
+	}
+	var err error
+	if tc.MinVersion, err = config.ParseTLSVersion(t.MinVersion); err != nil {
+		return nil, err
+	}
+	if tc.MaxVersion, err = config.ParseTLSVersion(t.MaxVersion); err != nil {
+		return nil, err
+	}
+	if tc.CipherSuites, err = config.ParseCipherSuites(t.CipherSuites); err != nil {
+		return nil, err
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", t.CAFile)
+		}
+		tc.RootCAs = pool
+	}
+	if t.SessionResumption != nil && *t.SessionResumption {
+		tc.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	return tc, nil
+}
+
+// Set holds the clients for a test: one for the http block, and one for
+// each endpoint with its own tls settings. Each has an HTTP/2-only twin for
+// grpc endpoints.
+type Set struct {
+	cfg   config.HTTPConfig
+	net   *network
+	raw   func(ctx context.Context, network, addr string) (net.Conn, error)
+	def   *http.Client
+	byTLS map[*config.TLSConfig]*http.Client
+	grpc  map[*config.TLSConfig]*http.Client // keyed by nil for the http block
+
+	// The TLS settings the clients were built from, kept so Clone can
+	// build fresh clients without reading certificate files again.
+	defTLS *tls.Config
+	tlsFor map[*config.TLSConfig]*tls.Config
+}
+
+// NewSet builds the clients for cfg and endpoints.
+func NewSet(cfg config.HTTPConfig, endpoints []config.Endpoint) (*Set, error) {
+	defTLS, err := TLSConfig(cfg.TLS, cfg.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	tlsFor := make(map[*config.TLSConfig]*tls.Config)
+	for _, ep := range endpoints {
+		if ep.TLS == nil || tlsFor[ep.TLS] != nil {
+			continue
+		}
+		tc, err := TLSConfig(cfg.TLS.Merge(ep.TLS), cfg.InsecureSkipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %q: %w", ep.Name, err)
+		}
+		tlsFor[ep.TLS] = tc
+	}
+	n, err := newNetwork(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newSet(cfg, n, false, defTLS, tlsFor)
+}
+
+// newSet builds the clients of a Set. With pin set they all bind to one
+// source address rather than taking the next for each connection.
+func newSet(cfg config.HTTPConfig, n *network, pin bool, defTLS *tls.Config, tlsFor map[*config.TLSConfig]*tls.Config) (*Set, error) {
+	var pinned net.IP
+	if pin {
+		pinned = n.nextLocal()
+	}
+	dial := n.dialer(pinned)
+	def, err := newClient(cfg, defTLS, dial)
+	if err != nil {
+		return nil, err
+	}
+	s := &Set{
+		cfg:    cfg,
+		net:    n,
+		raw:    n.rawDialer(pinned),
+		def:    def,
+		byTLS:  make(map[*config.TLSConfig]*http.Client, len(tlsFor)),
+		grpc:   make(map[*config.TLSConfig]*http.Client, len(tlsFor)+1),
+		defTLS: defTLS,
+		tlsFor: tlsFor,
+	}
+	gcfg := cfg
+	gcfg.Protocol = grpcProtocol
+	if s.grpc[nil], err = newClient(gcfg, defTLS, dial); err != nil {
+		return nil, err
+	}
+	for key, tc := range tlsFor {
+		if s.byTLS[key], err = newClient(cfg, tc, dial); err != nil {
+			return nil, err
+		}
+		if s.grpc[key], err = newClient(gcfg, tc, dial); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Clone returns a Set with the same settings but its own connection pools
+// and TLS session caches, for a VU in per_vu connection mode. Its
+// connections all use the next of http.local_addresses, if any.
+func (s *Set) Clone() (*Set, error) {
+	tlsFor := make(map[*config.TLSConfig]*tls.Config, len(s.tlsFor))
+	for key, tc := range s.tlsFor {
+		tlsFor[key] = ownSessionCache(tc)
+	}
+	return newSet(s.cfg, s.net, true, ownSessionCache(s.defTLS), tlsFor)
+}
+
+// ownSessionCache returns tc with a new, empty session cache if it has one.
+func ownSessionCache(tc *tls.Config) *tls.Config {
+	if tc.ClientSessionCache == nil {
+		return tc
+	}
+	tc = tc.Clone()
+	tc.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	return tc
+}
+
+// Default returns the client built from the http block.
+func (s *Set) Default() *http.Client {
+	return s.def
+}
+
+// For returns the client to send ep's requests with.
+func (s *Set) For(ep config.Endpoint) *http.Client {
+	if ep.Type == "grpc" {
+		if c, ok := s.grpc[ep.TLS]; ok {
+			return c
+		}
+		return s.grpc[nil]
+	}
+	if c, ok := s.byTLS[ep.TLS]; ok {
+		return c
+	}
+	return s.def
+}
+
+// Dial connects to addr over "tcp" or "udp", for tcp and udp endpoints,
+// with the http block's source addresses and resolve overrides. A cloned
+// Set's connections all use its one source address, like its clients'.
+func (s *Set) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	return s.raw(ctx, network, addr)
+}
+
+// CloseIdleConnections closes the idle connections of every client in s.
+func (s *Set) CloseIdleConnections() {
+	s.def.CloseIdleConnections()
+	for _, c := range s.byTLS {
+		c.CloseIdleConnections()
+	}
+	for _, c := range s.grpc {
+		c.CloseIdleConnections()
+	}
+}
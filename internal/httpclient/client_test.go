@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+func mustNew(t *testing.T, cfg config.HTTPConfig) *http.Client {
+	t.Helper()
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func redirectServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/end", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestNew_NoFollowRedirects(t *testing.T) {
+	srv := redirectServer()
+	defer srv.Close()
+
+	resp, err := mustNew(t, config.HTTPConfig{}).Get(srv.URL + "/start")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("expected 302 when not following redirects, got %d", resp.StatusCode)
+	}
+}
+
+func TestNew_FollowRedirects(t *testing.T) {
+	srv := redirectServer()
+	defer srv.Close()
+
+	resp, err := mustNew(t, config.HTTPConfig{FollowRedirects: true}).Get(srv.URL + "/start")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 after following redirect, got %d", resp.StatusCode)
+	}
+}
+
+func TestNew_Timeout(t *testing.T) {
+	c := mustNew(t, config.HTTPConfig{Timeout: config.Duration{Duration: 3 * time.Second}})
+	if c.Timeout != 3*time.Second {
+		t.Errorf("expected timeout 3s, got %v", c.Timeout)
+	}
+}
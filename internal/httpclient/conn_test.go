@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+// countingServer returns a server and a counter of the connections it has
+// accepted.
+func countingServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var conns atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+func get(t *testing.T, c *http.Client, url string) {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func TestNew_ConnectionMode(t *testing.T) {
+	for mode, want := range map[string]int64{"shared": 1, "per_vu": 1, "no_keepalive": 5} {
+		srv, conns := countingServer(t)
+		c := mustNew(t, config.HTTPConfig{ConnectionMode: mode})
+		for range 5 {
+			get(t, c, srv.URL)
+		}
+		if got := conns.Load(); got != want {
+			t.Errorf("%s: %d connections for 5 requests, want %d", mode, got, want)
+		}
+	}
+}
+
+func TestNew_IdleTimeout(t *testing.T) {
+	srv, conns := countingServer(t)
+	c := mustNew(t, config.HTTPConfig{IdleTimeout: config.Duration{Duration: 20 * time.Millisecond}})
+	get(t, c, srv.URL)
+	time.Sleep(100 * time.Millisecond)
+	get(t, c, srv.URL)
+	if got := conns.Load(); got != 2 {
+		t.Errorf("%d connections, want 2 (the idle one should have been closed)", got)
+	}
+}
+
+func TestSet_Clone(t *testing.T) {
+	srv, conns := countingServer(t)
+	s, err := NewSet(config.HTTPConfig{ConnectionMode: "per_vu"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vu1, err := s.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vu2, _ := s.Clone()
+	for range 3 {
+		get(t, vu1.Default(), srv.URL)
+		get(t, vu2.Default(), srv.URL)
+	}
+	if got := conns.Load(); got != 2 {
+		t.Errorf("%d connections for two cloned sets, want one each", got)
+	}
+
+	vu1.CloseIdleConnections()
+	get(t, vu1.Default(), srv.URL)
+	if got := conns.Load(); got != 3 {
+		t.Errorf("%d connections after closing idle ones, want 3", got)
+	}
+}
@@ -0,0 +1,194 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+// proxyFunc returns the Transport.Proxy function for p, or nil to connect
+// directly. Requests to Unix sockets are never proxied.
+func proxyFunc(p config.ProxyConfig) (func(*http.Request) (*url.URL, error), error) {
+	if p.FromEnv {
+		return func(req *http.Request) (*url.URL, error) {
+			if _, ok := socketFor(req.URL.Hostname()); ok {
+				return nil, nil
+			}
+			return http.ProxyFromEnvironment(req)
+		}, nil
+	}
+	if p.URL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing proxy url: %w", err)
+	}
+	bypass, err := parseNoProxy(p.NoProxy)
+	if err != nil {
+		return nil, err
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if _, ok := socketFor(req.URL.Hostname()); ok || bypass(req.URL.Hostname()) {
+			return nil, nil
+		}
+		return u, nil
+	}, nil
+}
+
+// parseNoProxy returns a function reporting whether a host matches one of
+// the entries: "*", a host name (which also matches its subdomains), an IP
+// or a CIDR.
+func parseNoProxy(entries []string) (func(host string) bool, error) {
+	var names []string
+	var nets []*net.IPNet
+	all := false
+	for _, e := range entries {
+		e = strings.ToLower(strings.TrimSpace(e))
+		switch {
+		case e == "*":
+			all = true
+		case strings.Contains(e, "/"):
+			_, n, err := net.ParseCIDR(e)
+			if err != nil {
+				return nil, fmt.Errorf("proxy no_proxy: %w", err)
+			}
+			nets = append(nets, n)
+		default:
+			names = append(names, strings.TrimPrefix(e, "."))
+		}
+	}
+	return func(host string) bool {
+		if all {
+			return true
+		}
+		host = strings.ToLower(host)
+		if ip := net.ParseIP(host); ip != nil {
+			for _, n := range nets {
+				if n.Contains(ip) {
+					return true
+				}
+			}
+		}
+		for _, name := range names {
+			if host == name || strings.HasSuffix(host, "."+name) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// network holds the dial settings shared by the clients of a Set: the
+// source addresses to bind, the resolve overrides and the Unix socket.
+type network struct {
+	local   []net.IP
+	next    atomic.Uint64
+	resolve map[string]string
+	unix    string // socket to send every request over, if set
+}
+
+func newNetwork(cfg config.HTTPConfig) (*network, error) {
+	n := &network{
+		resolve: make(map[string]string, len(cfg.Resolve)),
+		unix:    strings.TrimPrefix(cfg.UnixSocket, config.UnixPrefix),
+	}
+	for _, a := range cfg.LocalAddresses {
+		ip := net.ParseIP(a)
+		if ip == nil {
+			return nil, fmt.Errorf("local address %q is not an IP address", a)
+		}
+		n.local = append(n.local, ip)
+	}
+	for host, ip := range cfg.Resolve {
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("resolve %s: %q is not an IP address", host, ip)
+		}
+		n.resolve[strings.ToLower(host)] = ip
+	}
+	return n, nil
+}
+
+// nextLocal returns the next source address in turn, or nil if none are set.
+func (n *network) nextLocal() net.IP {
+	if len(n.local) == 0 {
+		return nil
+	}
+	return n.local[(n.next.Add(1)-1)%uint64(len(n.local))]
+}
+
+// dialer returns a DialContext function. Every connection it makes uses
+// pinned as its source address if set; otherwise each takes the next one.
+// Hosts made by UnixURL, and every host when http.unix_socket is set, are
+// dialed over a Unix socket instead.
+func (n *network) dialer(pinned net.IP) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		if socket := n.socket(addr); socket != "" {
+			return d.DialContext(ctx, "unix", socket)
+		}
+		local := pinned
+		if local == nil {
+			local = n.nextLocal()
+		}
+		if local != nil {
+			d.LocalAddr = &net.TCPAddr{IP: local}
+		}
+		return d.DialContext(ctx, network, n.target(addr))
+	}
+}
+
+// rawDialer returns a function that connects over "tcp" or "udp" for raw
+// socket endpoints, with source addresses chosen as by dialer and resolve
+// overrides applied. Unix sockets do not apply.
+func (n *network) rawDialer(pinned net.IP) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		local := pinned
+		if local == nil {
+			local = n.nextLocal()
+		}
+		if local != nil {
+			if strings.HasPrefix(network, "udp") {
+				d.LocalAddr = &net.UDPAddr{IP: local}
+			} else {
+				d.LocalAddr = &net.TCPAddr{IP: local}
+			}
+		}
+		return d.DialContext(ctx, network, n.target(addr))
+	}
+}
+
+// socket returns the Unix socket to dial for addr, or "" to use TCP.
+func (n *network) socket(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if socket, ok := socketFor(host); ok {
+		return socket
+	}
+	return n.unix
+}
+
+// target applies the resolve overrides to a "host:port" dial address.
+func (n *network) target(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || len(n.resolve) == 0 {
+		return addr
+	}
+	host = strings.ToLower(host)
+	if ip, ok := n.resolve[net.JoinHostPort(host, port)]; ok {
+		return net.JoinHostPort(ip, port)
+	}
+	if ip, ok := n.resolve[host]; ok {
+		return net.JoinHostPort(ip, port)
+	}
+	return addr
+}
@@ -0,0 +1,285 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+func body(t *testing.T, c *http.Client, url string) string {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return string(b)
+}
+
+func TestNew_Resolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host)
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	for _, key := range []string{"api.perf.test", "API.perf.test:" + port} {
+		c := mustNew(t, config.HTTPConfig{Resolve: map[string]string{key: "127.0.0.1"}})
+		if got := body(t, c, "http://api.perf.test:"+port+"/"); got != "api.perf.test:"+port {
+			t.Errorf("resolve %s: server saw host %q", key, got)
+		}
+	}
+}
+
+// sourceServer returns a server that replies with the client's IP, and the
+// IPs it has seen.
+func sourceServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		io.WriteString(w, host)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNew_LocalAddresses(t *testing.T) {
+	srv := sourceServer(t)
+	c := mustNew(t, config.HTTPConfig{
+		ConnectionMode: "no_keepalive",
+		LocalAddresses: []string{"127.0.0.2", "127.0.0.3"},
+	})
+	var got []string
+	for range 4 {
+		got = append(got, body(t, c, srv.URL))
+	}
+	if strings.Join(got, ",") != "127.0.0.2,127.0.0.3,127.0.0.2,127.0.0.3" {
+		t.Errorf("source addresses = %v, want round-robin", got)
+	}
+}
+
+func TestSet_ClonePinsLocalAddress(t *testing.T) {
+	srv := sourceServer(t)
+	s, err := NewSet(config.HTTPConfig{
+		ConnectionMode: "no_keepalive",
+		LocalAddresses: []string{"127.0.0.2", "127.0.0.3"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"127.0.0.2", "127.0.0.3", "127.0.0.2"} {
+		vu, err := s.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 2 {
+			if got := body(t, vu.Default(), srv.URL); got != want {
+				t.Errorf("VU bound to %s, want %s", got, want)
+			}
+			// Raw socket connections are pinned to the same address.
+			conn, err := vu.Dial(context.Background(), "tcp", strings.TrimPrefix(srv.URL, "http://"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := conn.LocalAddr().(*net.TCPAddr).IP.String(); got != want {
+				t.Errorf("VU's raw connection bound to %s, want %s", got, want)
+			}
+			conn.Close()
+		}
+	}
+}
+
+func TestNew_HTTPProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer target.Close()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "proxied "+r.URL.String()+" "+r.Header.Get("Proxy-Authorization"))
+	}))
+	defer proxy.Close()
+
+	u, _ := url.Parse(proxy.URL)
+	u.User = url.UserPassword("load", "secret")
+	c := mustNew(t, config.HTTPConfig{Proxy: config.ProxyConfig{URL: u.String()}})
+	if got := body(t, c, target.URL+"/x"); got != "proxied "+target.URL+"/x Basic bG9hZDpzZWNyZXQ=" {
+		t.Errorf("got %q", got)
+	}
+
+	c = mustNew(t, config.HTTPConfig{Proxy: config.ProxyConfig{URL: proxy.URL, NoProxy: []string{"127.0.0.0/8"}}})
+	if got := body(t, c, target.URL); got != "direct" {
+		t.Errorf("no_proxy: got %q, want a direct request", got)
+	}
+}
+
+func TestNew_SOCKS5Proxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host)
+	}))
+	defer target.Close()
+	_, port, _ := net.SplitHostPort(target.Listener.Addr().String())
+
+	// The proxy connects "backend.perf.test" to the target, so a request
+	// only succeeds if it went through the proxy.
+	socks, connects := socks5Server(t, map[string]string{"backend.perf.test": "127.0.0.1"})
+	c := mustNew(t, config.HTTPConfig{Proxy: config.ProxyConfig{URL: "socks5h://" + socks}})
+	if got := body(t, c, "http://backend.perf.test:"+port+"/"); got != "backend.perf.test:"+port {
+		t.Errorf("got %q", got)
+	}
+	if got := connects(); len(got) != 1 || got[0] != "backend.perf.test:"+port {
+		t.Errorf("proxy CONNECTs = %v", got)
+	}
+}
+
+// socks5Server runs a minimal SOCKS5 proxy (no auth, CONNECT only) that
+// resolves names through hosts. It returns the proxy's address and a
+// function listing the addresses clients asked it to connect to.
+func socks5Server(t *testing.T, hosts map[string]string) (string, func() []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var mu sync.Mutex
+	var connects []string
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 262)
+				// Greeting: version, methods; reply "no authentication".
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				io.ReadFull(conn, buf[:buf[1]])
+				conn.Write([]byte{5, 0})
+				// Request: version, CONNECT, reserved, address type.
+				if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+					return
+				}
+				var host string
+				switch buf[3] {
+				case 1:
+					io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 3:
+					io.ReadFull(conn, buf[:1])
+					n := int(buf[0])
+					io.ReadFull(conn, buf[:n])
+					host = string(buf[:n])
+				default:
+					return
+				}
+				io.ReadFull(conn, buf[:2])
+				port := binary.BigEndian.Uint16(buf[:2])
+				addr := net.JoinHostPort(host, strconv.Itoa(int(port)))
+				mu.Lock()
+				connects = append(connects, addr)
+				mu.Unlock()
+				if ip, ok := hosts[host]; ok {
+					host = ip
+				}
+				upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+				if err != nil {
+					conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstream.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return ln.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), connects...)
+	}
+}
+
+func TestParseNoProxy(t *testing.T) {
+	bypass, err := parseNoProxy([]string{"internal.example.com", ".corp", "10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for host, want := range map[string]bool{
+		"internal.example.com":     true,
+		"api.internal.example.com": true,
+		"example.com":              false,
+		"db.corp":                  true,
+		"corp.com":                 false,
+		"10.1.2.3":                 true,
+		"192.168.1.5":              true,
+		"192.168.1.6":              false,
+	} {
+		if got := bypass(host); got != want {
+			t.Errorf("bypass(%q) = %v, want %v", host, got, want)
+		}
+	}
+	if _, err := parseNoProxy([]string{"10.0.0.0/99"}); err == nil {
+		t.Error("expected an error for a bad CIDR")
+	}
+}
+
+func TestSet_Dial(t *testing.T) {
+	s, err := NewSet(config.HTTPConfig{
+		Resolve:        map[string]string{"raw.perf.test": "127.0.0.1"},
+		LocalAddresses: []string{"127.0.0.2"},
+		UnixSocket:     "/nonexistent.sock", // applies only to HTTP
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	conn, err := s.Dial(context.Background(), "tcp", "raw.perf.test:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if ip := conn.LocalAddr().(*net.TCPAddr).IP.String(); ip != "127.0.0.2" {
+		t.Errorf("tcp source address = %s, want 127.0.0.2", ip)
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	_, port, _ = net.SplitHostPort(pc.LocalAddr().String())
+	uconn, err := s.Dial(context.Background(), "udp", "raw.perf.test:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer uconn.Close()
+	uconn.Write([]byte("ping"))
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 16)
+	n, from, err := pc.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != "ping" || from.(*net.UDPAddr).IP.String() != "127.0.0.2" {
+		t.Errorf("udp: read %q from %v, err = %v", buf[:n], from, err)
+	}
+}
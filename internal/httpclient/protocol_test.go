@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+func h2cServer(t *testing.T, h http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(h)
+	var p http.Protocols
+	p.SetHTTP1(true)
+	p.SetUnencryptedHTTP2(true)
+	srv.Config.Protocols = &p
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNew_Protocol(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tlsSrv := httptest.NewUnstartedServer(ok)
+	tlsSrv.EnableHTTP2 = true
+	tlsSrv.StartTLS()
+	defer tlsSrv.Close()
+	plain := h2cServer(t, ok)
+
+	for _, tt := range []struct {
+		protocol, url, want string
+	}{
+		{"auto", tlsSrv.URL, "HTTP/2.0"},
+		{"", tlsSrv.URL, "HTTP/2.0"},
+		{"http1", tlsSrv.URL, "HTTP/1.1"},
+		{"http2", tlsSrv.URL, "HTTP/2.0"},
+		{"auto", plain.URL, "HTTP/1.1"},
+		{"h2c", plain.URL, "HTTP/2.0"},
+	} {
+		c := mustNew(t, config.HTTPConfig{Protocol: tt.protocol, InsecureSkipVerify: true})
+		resp, err := c.Get(tt.url)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.protocol, tt.url, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.Proto != tt.want {
+			t.Errorf("%s %s: proto = %s, want %s", tt.protocol, tt.url, resp.Proto, tt.want)
+		}
+	}
+
+	if _, err := New(config.HTTPConfig{Protocol: "spdy"}); err == nil {
+		t.Error("expected an error for an unknown protocol")
+	}
+}
+
+func TestNew_MaxConcurrentStreams(t *testing.T) {
+	const conns, streams = 2, 3
+	var (
+		mu       sync.Mutex
+		inFlight = make(map[string]int)
+		peak     = make(map[string]int)
+		total    int
+	)
+	release := make(chan struct{})
+	srv := h2cServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight[r.RemoteAddr]++
+		peak[r.RemoteAddr] = max(peak[r.RemoteAddr], inFlight[r.RemoteAddr])
+		total++
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		inFlight[r.RemoteAddr]--
+		mu.Unlock()
+	}))
+
+	c := mustNew(t, config.HTTPConfig{Protocol: "h2c", MaxConnsPerHost: conns, MaxConcurrentStreams: streams})
+	var wg sync.WaitGroup
+	for range conns * streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := total
+		mu.Unlock()
+		if n == conns*streams {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d requests reached the server", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Every stream is busy, so one more request waits for a free one.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if resp, err := c.Do(req); err == nil {
+		resp.Body.Close()
+		t.Error("expected a request beyond the stream limit to wait")
+	}
+
+	close(release)
+	wg.Wait()
+	if len(peak) != conns {
+		t.Errorf("requests used %d connections, want %d", len(peak), conns)
+	}
+	for addr, n := range peak {
+		if n != streams {
+			t.Errorf("connection %s peaked at %d streams, want %d", addr, n, streams)
+		}
+	}
+}
+
+func TestSet_GRPC(t *testing.T) {
+	srv := h2cServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	endpoints := []config.Endpoint{{Name: "plain"}, {Name: "greet", Type: "grpc"}}
+	s, err := NewSet(config.HTTPConfig{Protocol: "http1"}, endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		ep   config.Endpoint
+		want string
+	}{
+		{endpoints[0], "HTTP/1.1"},
+		{endpoints[1], "HTTP/2.0"},
+	} {
+		resp, err := s.For(tt.ep).Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.ep.Name, err)
+		}
+		resp.Body.Close()
+		if resp.Proto != tt.want {
+			t.Errorf("%s: proto = %s, want %s", tt.ep.Name, resp.Proto, tt.want)
+		}
+	}
+}
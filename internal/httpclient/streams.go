@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// streamPool caps the HTTP/2 streams in flight on each connection. On its
+// own a transport puts as many requests on one connection as the server
+// allows, often 100 or more; a load test usually wants a set number of
+// connections each carrying a set number of streams. The pool holds one
+// transport per connection, each allowed a single connection per host, and
+// sends each request on the least busy one with a free stream.
+type streamPool struct {
+	transports []*http.Transport
+	streams    int
+
+	mu    sync.Mutex
+	hosts map[string]*hostStreams
+}
+
+// hostStreams tracks the streams in flight to one host.
+type hostStreams struct {
+	free  chan struct{} // one token per free stream across all connections
+	mu    sync.Mutex
+	inUse []int // streams in flight on each transport
+}
+
+func newStreamPool(newTransport func() (*http.Transport, error), conns, streams int) (*streamPool, error) {
+	p := &streamPool{streams: streams, hosts: make(map[string]*hostStreams)}
+	for range conns {
+		t, err := newTransport()
+		if err != nil {
+			return nil, err
+		}
+		t.MaxConnsPerHost = 1
+		p.transports = append(p.transports, t)
+	}
+	return p, nil
+}
+
+func (p *streamPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := p.host(req.URL.Scheme + "://" + req.URL.Host)
+	select {
+	case <-h.free:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	h.mu.Lock()
+	i := 0
+	for j, n := range h.inUse {
+		if n < h.inUse[i] {
+			i = j
+		}
+	}
+	h.inUse[i]++
+	h.mu.Unlock()
+
+	release := sync.OnceFunc(func() {
+		h.mu.Lock()
+		h.inUse[i]--
+		h.mu.Unlock()
+		h.free <- struct{}{}
+	})
+	resp, err := p.transports[i].RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+func (p *streamPool) host(key string) *hostStreams {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h, ok := p.hosts[key]
+	if !ok {
+		h = &hostStreams{
+			free:  make(chan struct{}, len(p.transports)*p.streams),
+			inUse: make([]int, len(p.transports)),
+		}
+		for range cap(h.free) {
+			h.free <- struct{}{}
+		}
+		p.hosts[key] = h
+	}
+	return h
+}
+
+// CloseIdleConnections closes the idle connections of every transport.
+func (p *streamPool) CloseIdleConnections() {
+	for _, t := range p.transports {
+		t.CloseIdleConnections()
+	}
+}
+
+// releaseBody frees its stream when the response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
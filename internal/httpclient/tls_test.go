@@ -0,0 +1,194 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+// writeClientCert creates a self-signed client certificate and returns the
+// paths of its certificate and key files, and the certificate.
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "perf-test client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile, cert
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mtlsServer starts a TLS server that requires a client certificate signed
+// by clientCA, and writes its own certificate to a CA bundle file.
+func mtlsServer(t *testing.T, dir string, clientCA *x509.Certificate) (srv *httptest.Server, caFile string) {
+	t.Helper()
+	srv = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCA)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	caFile = filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", srv.Certificate().Raw)
+	return srv, caFile
+}
+
+func TestNew_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, cert := writeClientCert(t, dir)
+	srv, caFile := mtlsServer(t, dir, cert)
+
+	c := mustNew(t, config.HTTPConfig{TLS: config.TLSConfig{
+		CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "example.com",
+		MinVersion: "1.2",
+	}})
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with client certificate failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS == nil || resp.TLS.ServerName != "example.com" {
+		t.Errorf("server name = %v, want example.com", resp.TLS)
+	}
+
+	// Without the client certificate the handshake is refused.
+	c = mustNew(t, config.HTTPConfig{TLS: config.TLSConfig{CAFile: caFile, ServerName: "example.com"}})
+	if resp, err := c.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("expected the server to reject a client without a certificate")
+	}
+}
+
+func TestNew_TLSVersionAndCiphers(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := mustNew(t, config.HTTPConfig{InsecureSkipVerify: true, TLS: config.TLSConfig{
+		MaxVersion:   "1.2",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+	}})
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.TLS.Version != tls.VersionTLS12 || resp.TLS.CipherSuite != tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384 {
+		t.Errorf("negotiated %s %s", tls.VersionName(resp.TLS.Version), tls.CipherSuiteName(resp.TLS.CipherSuite))
+	}
+}
+
+func TestNew_SessionResumption(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	for _, on := range []bool{true, false} {
+		c := mustNew(t, config.HTTPConfig{InsecureSkipVerify: true, TLS: config.TLSConfig{SessionResumption: &on}})
+		var resumed bool
+		for range 2 {
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			resumed = resp.TLS.DidResume
+			c.CloseIdleConnections() // force a new handshake
+		}
+		if resumed != on {
+			t.Errorf("session_resumption %v: second connection resumed = %v", on, resumed)
+		}
+	}
+}
+
+func TestNew_TLSErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0o600)
+	for _, tt := range []struct {
+		tls  config.TLSConfig
+		want string
+	}{
+		{config.TLSConfig{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: filepath.Join(dir, "missing.key")}, "loading client certificate"},
+		{config.TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}, "reading CA bundle"},
+		{config.TLSConfig{CAFile: notPEM}, "contains no PEM certificates"},
+		{config.TLSConfig{MinVersion: "1.4"}, "unknown TLS version"},
+		{config.TLSConfig{CipherSuites: []string{"TLS_NOPE"}}, "unknown cipher suite"},
+	} {
+		if _, err := New(config.HTTPConfig{TLS: tt.tls}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("New(%+v): err = %v, want %q", tt.tls, err, tt.want)
+		}
+	}
+}
+
+func TestSet_PerEndpointTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, cert := writeClientCert(t, dir)
+	srv, caFile := mtlsServer(t, dir, cert)
+
+	cfg := config.HTTPConfig{TLS: config.TLSConfig{CAFile: caFile, ServerName: "example.com"}}
+	mtls := &config.TLSConfig{CertFile: certFile, KeyFile: keyFile}
+	endpoints := []config.Endpoint{{Name: "plain"}, {Name: "mtls", TLS: mtls}, {Name: "mtls again", TLS: mtls}}
+	s, err := NewSet(cfg, endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.For(endpoints[0]) != s.Default() || s.For(endpoints[1]) == s.Default() || s.For(endpoints[1]) != s.For(endpoints[2]) {
+		t.Fatal("expected one client for the http block and one shared by the mtls endpoints")
+	}
+	resp, err := s.For(endpoints[1]).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("endpoint client should inherit ca_file and server_name and add the certificate: %v", err)
+	}
+	resp.Body.Close()
+
+	_, err = NewSet(cfg, []config.Endpoint{{Name: "bad", TLS: &config.TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}}})
+	if err == nil || !strings.Contains(err.Error(), `endpoint "bad"`) {
+		t.Errorf("err = %v, want it to name the endpoint", err)
+	}
+}
@@ -0,0 +1,30 @@
+package httpclient
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// unixHostSuffix marks the host names UnixURL makes up. The socket path is
+// hex-encoded in front of it, so each socket gets its own connection pool.
+const unixHostSuffix = ".unix.invalid"
+
+// UnixURL returns an http:// URL for path that clients built by this
+// package send over the Unix domain socket at socket. Set the request's Host
+// to the name the server should see.
+func UnixURL(socket, path string) string {
+	return "http://" + hex.EncodeToString([]byte(socket)) + unixHostSuffix + path
+}
+
+// socketFor returns the socket path encoded in a host made by UnixURL.
+func socketFor(host string) (string, bool) {
+	enc, ok := strings.CutSuffix(host, unixHostSuffix)
+	if !ok {
+		return "", false
+	}
+	b, err := hex.DecodeString(enc)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+// unixServer serves on a Unix socket, replying with its name.
+func unixServer(t *testing.T, name string) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), name+".sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &httptest.Server{Listener: ln, Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, name+" "+r.Host+r.URL.Path)
+	})}}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return socket
+}
+
+func TestUnixURL(t *testing.T) {
+	a, b := unixServer(t, "a"), unixServer(t, "b")
+	c := mustNew(t, config.HTTPConfig{Proxy: config.ProxyConfig{URL: "http://127.0.0.1:1"}})
+	for socket, want := range map[string]string{a: "a localhost/x", b: "b localhost/x"} {
+		req, _ := http.NewRequest("GET", UnixURL(socket, "/x"), nil)
+		req.Host = "localhost"
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(got) != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestNew_UnixSocket(t *testing.T) {
+	socket := unixServer(t, "sidecar")
+	for _, setting := range []string{socket, "unix://" + socket} {
+		c := mustNew(t, config.HTTPConfig{UnixSocket: setting})
+		if got := body(t, c, "http://sidecar.local/status"); got != "sidecar sidecar.local/status" {
+			t.Errorf("unix_socket %s: got %q", setting, got)
+		}
+	}
+}
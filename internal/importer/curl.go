@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/curl"
+	"github.com/jvreagan/perf-test/internal/replay"
+)
+
+// CurlOptions controls how curl commands are turned into a config.
+type CurlOptions struct {
+	Name string
+}
+
+// Curl builds a config with one endpoint per curl command. Origins are
+// replaced with ${base_url}-style variables as in HAR; -k and -L on any
+// command turn on the matching global HTTP setting. Warnings lists options
+// that were ignored.
+func Curl(cmds [][]string, opts CurlOptions) (cfg *config.Config, warnings []string, err error) {
+	if len(cmds) == 0 {
+		return nil, nil, fmt.Errorf("no curl commands found")
+	}
+
+	parsed := make([]*curl.Command, len(cmds))
+	urls := make([]*url.URL, len(cmds))
+	vars := newOriginVars()
+	for i, args := range cmds {
+		c, err := curl.Parse(args)
+		if err != nil {
+			return nil, nil, fmt.Errorf("command %d: %w", i+1, err)
+		}
+		for _, w := range c.Warnings {
+			warnings = append(warnings, fmt.Sprintf("command %d: %s", i+1, w))
+		}
+		parsed[i] = c
+		if _, path, ok := config.SplitUnixURL(c.URL); ok {
+			// Unix socket URLs have no origin to turn into a variable.
+			if urls[i], err = url.Parse(path); err != nil {
+				return nil, nil, fmt.Errorf("command %d: invalid URL %q", i+1, c.URL)
+			}
+			continue
+		}
+		u, err := url.Parse(c.URL)
+		if err != nil || u.Host == "" {
+			return nil, nil, fmt.Errorf("command %d: invalid URL %q", i+1, c.URL)
+		}
+		urls[i] = u
+		vars.add(u)
+	}
+
+	cfg = &config.Config{
+		Name:      opts.Name,
+		Variables: vars.values,
+		Load:      defaultLoad(),
+	}
+	names := make(map[string]int)
+	for i, c := range parsed {
+		name := replay.EndpointName(c.Method, urls[i].EscapedPath())
+		if names[name]++; names[name] > 1 {
+			name = fmt.Sprintf("%s #%d", name, names[name])
+		}
+		ep := config.Endpoint{
+			Name:   name,
+			Method: c.Method,
+			URL:    vars.replace(urls[i].String()),
+			Body:   c.Body,
+		}
+		if _, _, ok := config.SplitUnixURL(c.URL); ok {
+			ep.URL = c.URL
+		}
+		for k, v := range c.Headers {
+			if strings.EqualFold(k, "Host") || strings.EqualFold(k, "Content-Length") {
+				continue
+			}
+			if ep.Headers == nil {
+				ep.Headers = make(map[string]string)
+			}
+			ep.Headers[k] = vars.replace(v)
+		}
+		cfg.HTTP.InsecureSkipVerify = cfg.HTTP.InsecureSkipVerify || c.Insecure
+		cfg.HTTP.FollowRedirects = cfg.HTTP.FollowRedirects || c.FollowRedirects
+		cfg.Endpoints = append(cfg.Endpoints, ep)
+	}
+	return cfg, warnings, nil
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCurl(t *testing.T) {
+	cmds := [][]string{
+		{"curl", "https://api.example.com/users/42", "-H", "Authorization: Bearer x", "-H", "Host: api.example.com"},
+		{"curl", "-k", "-X", "POST", "https://api.example.com/orders", "-H", "Referer: https://api.example.com/cart", "--data-raw", `{"a":1}`},
+		{"curl", "https://cdn.example.net/logo.png"},
+		{"curl", "https://api.example.com/users/7", "-v", "--frobnicate"},
+	}
+	cfg, warnings, err := Curl(cmds, CurlOptions{Name: "from-curl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "from-curl" || len(cfg.Endpoints) != 4 {
+		t.Fatalf("got name %q with %d endpoints", cfg.Name, len(cfg.Endpoints))
+	}
+	if cfg.Variables["base_url"] != "https://api.example.com" || cfg.Variables["cdn_url"] != "https://cdn.example.net" {
+		t.Errorf("Variables = %v", cfg.Variables)
+	}
+	if !cfg.HTTP.InsecureSkipVerify {
+		t.Error("-k should enable insecure_skip_verify")
+	}
+
+	users := cfg.Endpoints[0]
+	if users.Name != "GET /users/{id}" || users.URL != "${base_url}/users/42" {
+		t.Errorf("endpoint 0 = %s %s", users.Name, users.URL)
+	}
+	if _, ok := users.Headers["Host"]; ok {
+		t.Error("Host header should be dropped")
+	}
+	order := cfg.Endpoints[1]
+	if order.Method != "POST" || order.Body != `{"a":1}` || order.Headers["Referer"] != "${base_url}/cart" {
+		t.Errorf("endpoint 1 = %+v", order)
+	}
+	if cfg.Endpoints[2].URL != "${cdn_url}/logo.png" {
+		t.Errorf("endpoint 2 URL = %s", cfg.Endpoints[2].URL)
+	}
+	if cfg.Endpoints[3].Name != "GET /users/{id} #2" {
+		t.Errorf("duplicate name = %q", cfg.Endpoints[3].Name)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "command 4") || !strings.Contains(warnings[0], "--frobnicate") {
+		t.Errorf("warnings = %v", warnings)
+	}
+	cfg.ApplyDefaults()
+	cfg.NormalizeStages()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("imported config is invalid: %v", err)
+	}
+}
+
+func TestCurl_UnixSocket(t *testing.T) {
+	cfg, _, err := Curl([][]string{{"curl", "--unix-socket", "/run/app.sock", "http://localhost/v1/items/12"}}, CurlOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := cfg.Endpoints[0]
+	if ep.Name != "GET /v1/items/{id}" || ep.URL != "unix:///run/app.sock:/v1/items/12" || len(cfg.Variables) != 0 {
+		t.Errorf("endpoint = %s %s, variables = %v", ep.Name, ep.URL, cfg.Variables)
+	}
+}
+
+func TestCurl_Errors(t *testing.T) {
+	if _, _, err := Curl(nil, CurlOptions{}); err == nil {
+		t.Error("expected error with no commands")
+	}
+	if _, _, err := Curl([][]string{{"curl", "-s"}}, CurlOptions{}); err == nil || !strings.Contains(err.Error(), "command 1") {
+		t.Errorf("err = %v, want command 1 error", err)
+	}
+}
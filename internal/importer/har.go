@@ -0,0 +1,296 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/replay"
+)
+
+// HAROptions controls how a HAR file is turned into a config.
+type HAROptions struct {
+	Name              string
+	Domains           []string // first-party domains; default: the domain of the first page or request
+	IncludeStatic     bool     // keep images, scripts, stylesheets, fonts and media
+	IncludeThirdParty bool     // keep requests to other domains
+	Flow              bool     // keep recorded order as a flow and recorded gaps as think time
+}
+
+// Summary counts what an import kept and dropped.
+type Summary struct {
+	Kept       int
+	Static     int
+	ThirdParty int
+	Aborted    int // requests with no response (status 0)
+}
+
+// har mirrors the parts of the HAR 1.2 format the importer reads.
+type har struct {
+	Log struct {
+		Pages []struct {
+			Title string `json:"title"`
+		} `json:"pages"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"` // total elapsed ms
+	ResourceType    string    `json:"_resourceType"`
+	Request         struct {
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		Headers  []harHeader `json:"headers"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// skipHeaders are set by the HTTP client itself, or are HTTP/2 pseudo-headers.
+var skipHeaders = map[string]bool{
+	"host": true, "content-length": true, "connection": true, "keep-alive": true,
+	"accept-encoding": true, "transfer-encoding": true, "upgrade": true, "te": true,
+	"proxy-connection": true,
+}
+
+var staticExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".map": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true, ".avif": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mp3": true, ".wav": true,
+}
+
+var staticResourceTypes = map[string]bool{
+	"script": true, "stylesheet": true, "image": true, "font": true, "media": true, "manifest": true,
+}
+
+// HAR builds a config from a HAR recording. Hostnames are replaced with
+// ${base_url}-style variables; headers, bodies and response statuses are kept.
+// Without Flow, identical requests are merged into one endpoint weighted by
+// how often they were recorded.
+func HAR(r io.Reader, opts HAROptions) (*config.Config, Summary, error) {
+	var doc har
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, Summary{}, fmt.Errorf("parsing HAR: %w", err)
+	}
+	entries := doc.Log.Entries
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	domains := opts.Domains
+	if len(domains) == 0 {
+		first := ""
+		if len(doc.Log.Pages) > 0 {
+			first = doc.Log.Pages[0].Title
+		}
+		if u, err := url.Parse(first); err != nil || u.Host == "" {
+			if len(entries) > 0 {
+				first = entries[0].Request.URL
+			}
+		}
+		if u, err := url.Parse(first); err == nil && u.Hostname() != "" {
+			domains = []string{siteDomain(u.Hostname())}
+		}
+	}
+
+	var sum Summary
+	var kept []harEntry
+	var urls []*url.URL
+	for _, e := range entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		switch {
+		case e.Response.Status == 0:
+			sum.Aborted++
+		case !opts.IncludeStatic && isStatic(e, u):
+			sum.Static++
+		case !opts.IncludeThirdParty && !matchesDomain(u.Hostname(), domains):
+			sum.ThirdParty++
+		default:
+			kept = append(kept, e)
+			urls = append(urls, u)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, sum, fmt.Errorf("no requests left after filtering (%d static, %d third-party, %d aborted)", sum.Static, sum.ThirdParty, sum.Aborted)
+	}
+	sum.Kept = len(kept)
+
+	vars := newOriginVars()
+	for _, u := range urls {
+		vars.add(u)
+	}
+
+	cfg := &config.Config{
+		Name:      opts.Name,
+		Variables: vars.values,
+		Load:      defaultLoad(),
+	}
+	cfg.Load.Flow = opts.Flow
+
+	index := make(map[string]int) // merge key -> endpoint index, weighted mode only
+	for i, e := range kept {
+		ep := config.Endpoint{
+			Name:   replay.EndpointName(strings.ToUpper(e.Request.Method), urls[i].EscapedPath()),
+			Method: strings.ToUpper(e.Request.Method),
+			URL:    vars.replace(urls[i].String()),
+			Expect: config.ExpectConfig{Status: e.Response.Status},
+		}
+		for _, h := range e.Request.Headers {
+			if strings.HasPrefix(h.Name, ":") || skipHeaders[strings.ToLower(h.Name)] {
+				continue
+			}
+			if ep.Headers == nil {
+				ep.Headers = make(map[string]string)
+			}
+			ep.Headers[h.Name] = vars.replace(h.Value)
+		}
+		if pd := e.Request.PostData; pd != nil {
+			ep.Body = vars.replace(pd.Text)
+			if pd.MimeType != "" && !hasHeader(ep.Headers, "Content-Type") {
+				if ep.Headers == nil {
+					ep.Headers = make(map[string]string)
+				}
+				ep.Headers["Content-Type"] = pd.MimeType
+			}
+		}
+
+		if opts.Flow {
+			if i+1 < len(kept) {
+				end := e.StartedDateTime.Add(time.Duration(e.Time * float64(time.Millisecond)))
+				if gap := kept[i+1].StartedDateTime.Sub(end).Round(10 * time.Millisecond); gap > 0 {
+					ep.ThinkTime = config.Duration{Duration: gap}
+				}
+			}
+			cfg.Endpoints = append(cfg.Endpoints, ep)
+			continue
+		}
+
+		key := ep.Method + " " + ep.URL + "\x00" + ep.Body
+		if j, ok := index[key]; ok {
+			cfg.Endpoints[j].Weight++
+			continue
+		}
+		ep.Weight = 1
+		index[key] = len(cfg.Endpoints)
+		cfg.Endpoints = append(cfg.Endpoints, ep)
+	}
+	return cfg, sum, nil
+}
+
+func isStatic(e harEntry, u *url.URL) bool {
+	if staticResourceTypes[e.ResourceType] {
+		return true
+	}
+	if staticExtensions[strings.ToLower(path.Ext(u.Path))] {
+		return true
+	}
+	mime := strings.ToLower(e.Response.Content.MimeType)
+	for _, prefix := range []string{"image/", "font/", "video/", "audio/", "text/css", "text/javascript", "application/javascript"} {
+		if strings.HasPrefix(mime, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// siteDomain approximates the registrable domain of host by keeping its last
+// two labels (www.example.com -> example.com). IPs and single-label hosts are
+// returned as-is. Use explicit domains for suffixes like co.uk.
+func siteDomain(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+func matchesDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// originVars assigns a variable to each origin (scheme://host[:port]). The
+// first origin seen becomes base_url; others are named after their host,
+// e.g. api.example.com -> api_url.
+type originVars struct {
+	values map[string]string // variable name -> origin
+	names  map[string]string // origin -> variable name
+	order  []string          // origins, longest first for replacement
+}
+
+func newOriginVars() *originVars {
+	return &originVars{values: make(map[string]string), names: make(map[string]string)}
+}
+
+func (v *originVars) add(u *url.URL) {
+	origin := u.Scheme + "://" + u.Host
+	if _, ok := v.names[origin]; ok {
+		return
+	}
+	name := "base_url"
+	if len(v.names) > 0 {
+		label := strings.Split(u.Hostname(), ".")[0]
+		if label == "www" || net.ParseIP(u.Hostname()) != nil {
+			label = "host"
+		}
+		label = strings.ReplaceAll(label, "-", "_")
+		name = label + "_url"
+		for n := 2; v.values[name] != ""; n++ {
+			name = fmt.Sprintf("%s_%d_url", label, n)
+		}
+	}
+	v.values[name] = origin
+	v.names[origin] = name
+	v.order = append(v.order, origin)
+	sort.Slice(v.order, func(i, j int) bool { return len(v.order[i]) > len(v.order[j]) })
+}
+
+// replace swaps every known origin in s for its ${variable}.
+func (v *originVars) replace(s string) string {
+	for _, origin := range v.order {
+		s = strings.ReplaceAll(s, origin, "${"+v.names[origin]+"}")
+	}
+	return s
+}
@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+const sessionHAR = `{
+  "log": {
+    "pages": [{"id": "page_1", "title": "https://www.shop.example/"}],
+    "entries": [
+      {
+        "startedDateTime": "2024-05-01T10:00:00.000Z", "time": 100,
+        "request": {"method": "GET", "url": "https://www.shop.example/",
+          "headers": [{"name": ":authority", "value": "www.shop.example"}, {"name": "Accept", "value": "text/html"}, {"name": "Accept-Encoding", "value": "gzip"}]},
+        "response": {"status": 200, "content": {"mimeType": "text/html"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:00.150Z", "time": 20, "_resourceType": "script",
+        "request": {"method": "GET", "url": "https://www.shop.example/app.js", "headers": []},
+        "response": {"status": 200, "content": {"mimeType": "application/javascript"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:00.200Z", "time": 30,
+        "request": {"method": "GET", "url": "https://www.google-analytics.com/collect?v=1", "headers": []},
+        "response": {"status": 204, "content": {}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:02.000Z", "time": 250,
+        "request": {"method": "POST", "url": "https://api.shop.example/cart/items",
+          "headers": [{"name": "Origin", "value": "https://www.shop.example"}, {"name": "Content-Length", "value": "30"}],
+          "postData": {"mimeType": "application/json", "text": "{\"sku\":\"A-1\",\"qty\":2}"}},
+        "response": {"status": 201, "content": {"mimeType": "application/json"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:03.250Z", "time": 50,
+        "request": {"method": "GET", "url": "https://www.shop.example/", "headers": [{"name": "Accept", "value": "text/html"}]},
+        "response": {"status": 200, "content": {"mimeType": "text/html"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:04.000Z", "time": 0,
+        "request": {"method": "GET", "url": "https://www.shop.example/cancelled", "headers": []},
+        "response": {"status": 0, "content": {}}
+      }
+    ]
+  }
+}`
+
+func TestHAR_FiltersAndMerges(t *testing.T) {
+	cfg, sum, err := HAR(strings.NewReader(sessionHAR), HAROptions{Name: "shop"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sum.Kept != 3 || sum.Static != 1 || sum.ThirdParty != 1 || sum.Aborted != 1 {
+		t.Errorf("unexpected summary: %+v", sum)
+	}
+	if len(cfg.Endpoints) != 2 {
+		t.Fatalf("expected the repeated page load to be merged into 2 endpoints, got %d", len(cfg.Endpoints))
+	}
+
+	page := cfg.Endpoints[0]
+	if page.URL != "${base_url}/" || page.Weight != 2 {
+		t.Errorf("page endpoint: expected ${base_url}/ with weight 2, got %s weight %d", page.URL, page.Weight)
+	}
+	if _, ok := page.Headers[":authority"]; ok {
+		t.Error("expected pseudo-headers to be dropped")
+	}
+	if _, ok := page.Headers["Accept-Encoding"]; ok {
+		t.Error("expected Accept-Encoding to be dropped")
+	}
+
+	cart := cfg.Endpoints[1]
+	if cart.URL != "${api_url}/cart/items" || cart.Method != "POST" || cart.Expect.Status != 201 {
+		t.Errorf("unexpected cart endpoint: %+v", cart)
+	}
+	if cart.Body != `{"sku":"A-1","qty":2}` || cart.Headers["Content-Type"] != "application/json" {
+		t.Errorf("expected body and content type to be kept, got %q %v", cart.Body, cart.Headers)
+	}
+	if cart.Headers["Origin"] != "${base_url}" {
+		t.Errorf("expected hostnames in headers to use variables, got %q", cart.Headers["Origin"])
+	}
+	if cfg.Variables["base_url"] != "https://www.shop.example" || cfg.Variables["api_url"] != "https://api.shop.example" {
+		t.Errorf("unexpected variables: %v", cfg.Variables)
+	}
+}
+
+func TestHAR_Flow(t *testing.T) {
+	cfg, _, err := HAR(strings.NewReader(sessionHAR), HAROptions{Flow: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Load.Flow || len(cfg.Endpoints) != 3 {
+		t.Fatalf("expected a 3-step flow, got flow=%v with %d endpoints", cfg.Load.Flow, len(cfg.Endpoints))
+	}
+	// Page ends at 0.100s, cart starts at 2.000s; cart ends at 2.250s, next page at 3.250s.
+	want := []time.Duration{1900 * time.Millisecond, time.Second, 0}
+	for i, ep := range cfg.Endpoints {
+		if ep.ThinkTime.Duration != want[i] {
+			t.Errorf("step %d: expected think time %v, got %v", i, want[i], ep.ThinkTime.Duration)
+		}
+	}
+}
+
+func TestHAR_IncludeEverything(t *testing.T) {
+	_, sum, err := HAR(strings.NewReader(sessionHAR), HAROptions{IncludeStatic: true, IncludeThirdParty: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sum.Kept != 5 {
+		t.Errorf("expected 5 kept requests, got %d", sum.Kept)
+	}
+}
+
+func TestHAR_OutputLoads(t *testing.T) {
+	cfg, _, err := HAR(strings.NewReader(sessionHAR), HAROptions{Name: "shop", Flow: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := config.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "shop.yaml")
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(path); err != nil {
+		t.Errorf("generated config failed to load: %v\n%s", err, out)
+	}
+}
+
+func TestHAR_NothingLeft(t *testing.T) {
+	_, _, err := HAR(strings.NewReader(sessionHAR), HAROptions{Domains: []string{"other.example"}})
+	if err == nil {
+		t.Error("expected error when every request is filtered out")
+	}
+}
+
+func TestOriginVars_Names(t *testing.T) {
+	v := newOriginVars()
+	for _, raw := range []string{
+		"https://shop.example",
+		"https://my-api.example.com",
+		"https://my-api.example.org",
+		"http://10.0.0.1:8080",
+		"http://10.0.0.2:8080",
+	} {
+		u, _ := url.Parse(raw)
+		v.add(u)
+	}
+	want := map[string]string{
+		"base_url":     "https://shop.example",
+		"my_api_url":   "https://my-api.example.com",
+		"my_api_2_url": "https://my-api.example.org",
+		"host_url":     "http://10.0.0.1:8080",
+		"host_2_url":   "http://10.0.0.2:8080",
+	}
+	if !reflect.DeepEqual(v.values, want) {
+		t.Errorf("variables = %v, want %v", v.values, want)
+	}
+}
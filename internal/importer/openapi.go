@@ -0,0 +1,665 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+// OpenAPIOptions controls how an OpenAPI spec is turned into a config.
+type OpenAPIOptions struct {
+	Name    string
+	BaseURL string   // overrides the spec's first server URL
+	Tags    []string // only import operations with one of these tags
+}
+
+type oaDoc struct {
+	OpenAPI string `yaml:"openapi"`
+	Swagger string `yaml:"swagger"`
+	Info    struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Paths      map[string]*oaPathItem `yaml:"paths"`
+	Security   []map[string][]string  `yaml:"security"`
+	Components struct {
+		Schemas         map[string]*oaSchema         `yaml:"schemas"`
+		Parameters      map[string]*oaParameter      `yaml:"parameters"`
+		RequestBodies   map[string]*oaRequestBody    `yaml:"requestBodies"`
+		SecuritySchemes map[string]*oaSecurityScheme `yaml:"securitySchemes"`
+	} `yaml:"components"`
+}
+
+type oaPathItem struct {
+	Parameters []*oaParameter `yaml:"parameters"`
+	Get        *oaOperation   `yaml:"get"`
+	Post       *oaOperation   `yaml:"post"`
+	Put        *oaOperation   `yaml:"put"`
+	Patch      *oaOperation   `yaml:"patch"`
+	Delete     *oaOperation   `yaml:"delete"`
+	Head       *oaOperation   `yaml:"head"`
+	Options    *oaOperation   `yaml:"options"`
+}
+
+type oaOperation struct {
+	OperationID string                 `yaml:"operationId"`
+	Tags        []string               `yaml:"tags"`
+	Parameters  []*oaParameter         `yaml:"parameters"`
+	RequestBody *oaRequestBody         `yaml:"requestBody"`
+	Responses   map[string]yaml.Node   `yaml:"responses"`
+	Security    *[]map[string][]string `yaml:"security"`
+}
+
+type oaParameter struct {
+	Ref      string    `yaml:"$ref"`
+	Name     string    `yaml:"name"`
+	In       string    `yaml:"in"`
+	Required bool      `yaml:"required"`
+	Schema   *oaSchema `yaml:"schema"`
+	Example  any       `yaml:"example"`
+}
+
+type oaRequestBody struct {
+	Ref     string `yaml:"$ref"`
+	Content map[string]struct {
+		Schema  *oaSchema `yaml:"schema"`
+		Example any       `yaml:"example"`
+	} `yaml:"content"`
+}
+
+type oaSecurityScheme struct {
+	Type   string `yaml:"type"`   // http, apiKey, oauth2, openIdConnect
+	Scheme string `yaml:"scheme"` // bearer, basic
+	In     string `yaml:"in"`     // header, query, cookie
+	Name   string `yaml:"name"`
+}
+
+type oaSchema struct {
+	Ref        string               `yaml:"$ref"`
+	Type       oaType               `yaml:"type"`
+	Format     string               `yaml:"format"`
+	Enum       []any                `yaml:"enum"`
+	Minimum    *float64             `yaml:"minimum"`
+	Maximum    *float64             `yaml:"maximum"`
+	MinLength  *int                 `yaml:"minLength"`
+	MaxLength  *int                 `yaml:"maxLength"`
+	Properties map[string]*oaSchema `yaml:"properties"`
+	Items      *oaSchema            `yaml:"items"`
+	Example    any                  `yaml:"example"`
+	Default    any                  `yaml:"default"`
+	ReadOnly   bool                 `yaml:"readOnly"`
+	AllOf      []*oaSchema          `yaml:"allOf"`
+	OneOf      []*oaSchema          `yaml:"oneOf"`
+	AnyOf      []*oaSchema          `yaml:"anyOf"`
+}
+
+// oaType accepts both `type: string` and the OpenAPI 3.1 form
+// `type: [string, "null"]`, keeping the first non-null type.
+type oaType string
+
+func (t *oaType) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.SequenceNode {
+		for _, c := range n.Content {
+			if c.Value != "null" {
+				*t = oaType(c.Value)
+				return nil
+			}
+		}
+		return nil
+	}
+	*t = oaType(n.Value)
+	return nil
+}
+
+// maxSchemaDepth stops body synthesis on recursive schemas.
+const maxSchemaDepth = 6
+
+var methodOrder = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// OpenAPI builds a config with one endpoint per operation in an OpenAPI 3
+// spec (YAML or JSON). Parameters and request bodies are filled with
+// generator functions chosen from their schemas.
+func OpenAPI(r io.Reader, opts OpenAPIOptions) (*config.Config, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading spec: %w", err)
+	}
+	var doc oaDoc
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parsing spec: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		if doc.Swagger != "" {
+			return nil, fmt.Errorf("swagger %s specs are not supported; convert to OpenAPI 3 first", doc.Swagger)
+		}
+		return nil, fmt.Errorf("not an OpenAPI 3 spec (missing or unsupported \"openapi\" version %q)", doc.OpenAPI)
+	}
+
+	cfg := &config.Config{
+		Name:      opts.Name,
+		Variables: map[string]string{"base_url": doc.baseURL(opts.BaseURL)},
+		Load:      defaultLoad(),
+	}
+	if cfg.Name == "" {
+		cfg.Name = doc.Info.Title
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		item := doc.Paths[p]
+		if item == nil {
+			continue
+		}
+		ops := map[string]*oaOperation{
+			"GET": item.Get, "POST": item.Post, "PUT": item.Put, "PATCH": item.Patch,
+			"DELETE": item.Delete, "HEAD": item.Head, "OPTIONS": item.Options,
+		}
+		for _, method := range methodOrder {
+			op := ops[method]
+			if op == nil || !hasTag(op.Tags, opts.Tags) {
+				continue
+			}
+			ep, err := doc.endpoint(method, p, item, op, cfg.Variables)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, p, err)
+			}
+			cfg.Endpoints = append(cfg.Endpoints, ep)
+		}
+	}
+	if len(cfg.Endpoints) == 0 {
+		return nil, fmt.Errorf("spec has no operations to import")
+	}
+	return cfg, nil
+}
+
+// defaultLoad is the load block written by importers: a short ramp to a
+// handful of VUs, meant to be edited.
+func defaultLoad() config.LoadConfig {
+	return config.LoadConfig{
+		RampUp:      config.Duration{Duration: 30 * time.Second},
+		SteadyState: config.Duration{Duration: 2 * time.Minute},
+		RampDown:    config.Duration{Duration: 30 * time.Second},
+		MaxVUs:      10,
+	}
+}
+
+func (d *oaDoc) baseURL(override string) string {
+	if override != "" {
+		return strings.TrimRight(override, "/")
+	}
+	if len(d.Servers) == 0 {
+		return "http://localhost:8080"
+	}
+	s := d.Servers[0]
+	u := s.URL
+	for name, v := range s.Variables {
+		u = strings.ReplaceAll(u, "{"+name+"}", v.Default)
+	}
+	if parsed, err := url.Parse(u); err == nil && !parsed.IsAbs() {
+		u = "http://localhost:8080" + u
+	}
+	return strings.TrimRight(u, "/")
+}
+
+func hasTag(tags, want []string) bool {
+	if len(want) == 0 {
+		return true
+	}
+	for _, t := range tags {
+		for _, w := range want {
+			if strings.EqualFold(t, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *oaDoc) endpoint(method, path string, item *oaPathItem, op *oaOperation, vars map[string]string) (config.Endpoint, error) {
+	ep := config.Endpoint{
+		Name:   op.OperationID,
+		Method: method,
+		Expect: config.ExpectConfig{Status: successStatus(op.Responses)},
+	}
+	if ep.Name == "" {
+		ep.Name = method + " " + path
+	}
+
+	// Operation parameters override path-level ones with the same name and location.
+	params := make(map[string]*oaParameter)
+	var order []string
+	for _, list := range [][]*oaParameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			p, err := d.resolveParam(p)
+			if err != nil {
+				return ep, err
+			}
+			key := p.In + ":" + p.Name
+			if _, ok := params[key]; !ok {
+				order = append(order, key)
+			}
+			params[key] = p
+		}
+	}
+
+	// Path parameters the spec forgot to declare still need a value.
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		if key := "path:" + m[1]; params[key] == nil {
+			params[key] = &oaParameter{Name: m[1], In: "path", Schema: &oaSchema{Type: "integer"}}
+			order = append(order, key)
+		}
+	}
+
+	var query []string
+	for _, key := range order {
+		p := params[key]
+		value := d.paramValue(p)
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", value)
+		case "query":
+			if p.Required {
+				query = append(query, url.QueryEscape(p.Name)+"="+value)
+			}
+		case "header":
+			if p.Required {
+				setHeader(&ep, p.Name, value)
+			}
+		}
+	}
+	ep.URL = "${base_url}" + path
+	if len(query) > 0 {
+		ep.URL += "?" + strings.Join(query, "&")
+	}
+
+	if op.RequestBody != nil {
+		if err := d.requestBody(&ep, op.RequestBody); err != nil {
+			return ep, err
+		}
+	}
+	d.applySecurity(&ep, op, vars)
+	return ep, nil
+}
+
+// successStatus returns the lowest explicit 2xx status, defaulting to 200.
+// A 2XX wildcard gives 200 too, but only when no explicit code is listed.
+func successStatus(responses map[string]yaml.Node) int {
+	best := 0
+	for code := range responses {
+		n, err := strconv.Atoi(code)
+		if err == nil && n >= 200 && n < 300 && (best == 0 || n < best) {
+			best = n
+		}
+	}
+	if best == 0 {
+		return 200
+	}
+	return best
+}
+
+func setHeader(ep *config.Endpoint, k, v string) {
+	if ep.Headers == nil {
+		ep.Headers = make(map[string]string)
+	}
+	ep.Headers[k] = v
+}
+
+func (d *oaDoc) requestBody(ep *config.Endpoint, rb *oaRequestBody) error {
+	rb, err := d.resolveBody(rb)
+	if err != nil {
+		return err
+	}
+	types := make([]string, 0, len(rb.Content))
+	for ct := range rb.Content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+
+	// Prefer JSON, then form encoding; other media types are left for the user.
+	for _, want := range []func(string) bool{isJSON, isForm} {
+		for _, ct := range types {
+			if !want(ct) {
+				continue
+			}
+			media := rb.Content[ct]
+			setHeader(ep, "Content-Type", ct)
+			if isJSON(ct) {
+				if media.Example != nil {
+					ep.Body = jsonLiteral(media.Example, "")
+				} else {
+					ep.Body = d.jsonBody(media.Schema, "", 0)
+				}
+			} else {
+				ep.Body = d.formBody(media.Schema)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func isJSON(ct string) bool {
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
+
+func isForm(ct string) bool {
+	return ct == "application/x-www-form-urlencoded"
+}
+
+// applySecurity adds credentials for the operation's (or the spec's default)
+// bearer, basic or API-key header security scheme, as variables read from the
+// environment.
+func (d *oaDoc) applySecurity(ep *config.Endpoint, op *oaOperation, vars map[string]string) {
+	reqs := d.Security
+	if op.Security != nil {
+		reqs = *op.Security
+	}
+	for _, req := range reqs {
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s := d.Components.SecuritySchemes[name]
+			if s == nil {
+				continue
+			}
+			switch {
+			case s.Type == "http" && strings.EqualFold(s.Scheme, "bearer"),
+				s.Type == "oauth2", s.Type == "openIdConnect":
+				vars["token"] = "${API_TOKEN}"
+				setHeader(ep, "Authorization", "Bearer ${token}")
+				return
+			case s.Type == "http" && strings.EqualFold(s.Scheme, "basic"):
+				vars["basic_auth"] = "${API_BASIC_AUTH}"
+				setHeader(ep, "Authorization", "Basic ${basic_auth}")
+				return
+			case s.Type == "apiKey" && s.In == "header":
+				vars["api_key"] = "${API_KEY}"
+				setHeader(ep, s.Name, "${api_key}")
+				return
+			}
+		}
+	}
+}
+
+// paramValue picks a value for a parameter: a generator function from its
+// schema, falling back to its example.
+func (d *oaDoc) paramValue(p *oaParameter) string {
+	s := d.resolveSchema(p.Schema, 0)
+	if s == nil || (s.Type == "" && s.Enum == nil) {
+		if p.Example != nil {
+			return fmt.Sprint(p.Example)
+		}
+	}
+	return d.scalar(s)
+}
+
+// scalar returns an unquoted generator expression for a scalar schema.
+func (d *oaDoc) scalar(s *oaSchema) string {
+	if s == nil {
+		return "${random.string(8)}"
+	}
+	if len(s.Enum) > 0 {
+		choices := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			choices[i] = fmt.Sprint(v)
+			// random.choice has no escaping; use a fixed value instead.
+			if strings.ContainsAny(choices[i], ",}") || strings.TrimSpace(choices[i]) != choices[i] {
+				return choices[0]
+			}
+		}
+		if len(choices) == 1 {
+			return choices[0]
+		}
+		return "${random.choice(" + strings.Join(choices, ",") + ")}"
+	}
+	switch s.Type {
+	case "integer":
+		lo, hi := bounds(s, 1, 1000)
+		return fmt.Sprintf("${random.int(%d,%d)}", int64(lo), int64(hi))
+	case "number":
+		lo, hi := bounds(s, 0, 1000)
+		return fmt.Sprintf("${random.float(%g,%g)}", lo, hi)
+	case "boolean":
+		return "${random.bool}"
+	}
+	switch s.Format {
+	case "uuid":
+		return "${random.uuid}"
+	case "email":
+		return "${random.email}"
+	case "date":
+		return time.Now().UTC().Format("2006-01-02")
+	case "date-time":
+		return time.Now().UTC().Truncate(time.Second).Format(time.RFC3339)
+	}
+	if s.Example != nil {
+		return fmt.Sprint(s.Example)
+	}
+	if s.Default != nil {
+		return fmt.Sprint(s.Default)
+	}
+	n := 8
+	if s.MinLength != nil && *s.MinLength > n {
+		n = *s.MinLength
+	}
+	if s.MaxLength != nil && *s.MaxLength > 0 && *s.MaxLength < n {
+		n = *s.MaxLength
+	}
+	return fmt.Sprintf("${random.string(%d)}", n)
+}
+
+// bounds returns the schema's minimum and maximum, filling in defaults so
+// that an open range stays close to the documented bound.
+func bounds(s *oaSchema, lo, hi float64) (float64, float64) {
+	switch {
+	case s.Minimum != nil && s.Maximum != nil:
+		return *s.Minimum, *s.Maximum
+	case s.Minimum != nil:
+		return *s.Minimum, *s.Minimum + hi - lo
+	case s.Maximum != nil:
+		return min(lo, *s.Maximum), *s.Maximum
+	}
+	return lo, hi
+}
+
+// jsonBody synthesizes an indented JSON document for s, with generator
+// expressions for leaf values. Read-only properties are left out.
+func (d *oaDoc) jsonBody(s *oaSchema, indent string, depth int) string {
+	s = d.resolveSchema(s, 0)
+	if s == nil || depth > maxSchemaDepth {
+		return "null"
+	}
+	if s.Example != nil && s.Type != "object" && s.Type != "array" {
+		return jsonLiteral(s.Example, indent)
+	}
+	inner := indent + "  "
+	switch {
+	case s.Type == "object" || len(s.Properties) > 0:
+		props := d.properties(s, depth)
+		if len(props) == 0 {
+			return "{}"
+		}
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var b strings.Builder
+		b.WriteString("{\n")
+		for i, name := range names {
+			fmt.Fprintf(&b, "%s%q: %s", inner, name, d.jsonBody(props[name], inner, depth+1))
+			if i < len(names)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	case s.Type == "array":
+		return "[\n" + inner + d.jsonBody(s.Items, inner, depth+1) + "\n" + indent + "]"
+	case s.Type == "integer" || s.Type == "number" || s.Type == "boolean":
+		return d.scalar(s)
+	default:
+		return strconv.Quote(d.scalar(s))
+	}
+}
+
+// properties flattens allOf and picks the first oneOf/anyOf branch. Like
+// jsonBody it stops at maxSchemaDepth, so a schema that includes itself
+// through allOf ends.
+func (d *oaDoc) properties(s *oaSchema, depth int) map[string]*oaSchema {
+	props := make(map[string]*oaSchema)
+	if depth > maxSchemaDepth {
+		return props
+	}
+	for name, p := range s.Properties {
+		if rp := d.resolveSchema(p, 0); rp == nil || !rp.ReadOnly {
+			props[name] = p
+		}
+	}
+	parts := slices.Clone(s.AllOf)
+	if len(s.OneOf) > 0 {
+		parts = append(parts, s.OneOf[0])
+	}
+	if len(s.AnyOf) > 0 {
+		parts = append(parts, s.AnyOf[0])
+	}
+	for _, part := range parts {
+		if rp := d.resolveSchema(part, 0); rp != nil {
+			for name, p := range d.properties(rp, depth+1) {
+				props[name] = p
+			}
+		}
+	}
+	return props
+}
+
+func (d *oaDoc) formBody(s *oaSchema) string {
+	s = d.resolveSchema(s, 0)
+	if s == nil {
+		return ""
+	}
+	props := d.properties(s, 0)
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = url.QueryEscape(name) + "=" + d.scalar(d.resolveSchema(props[name], 0))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// jsonLiteral renders a YAML-decoded example value as indented JSON.
+func jsonLiteral(v any, indent string) string {
+	inner := indent + "  "
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s%q: %s", inner, k, jsonLiteral(t[k], inner))
+		}
+		if len(parts) == 0 {
+			return "{}"
+		}
+		return "{\n" + strings.Join(parts, ",\n") + "\n" + indent + "}"
+	case []any:
+		parts := make([]string, len(t))
+		for i, e := range t {
+			parts[i] = inner + jsonLiteral(e, inner)
+		}
+		if len(parts) == 0 {
+			return "[]"
+		}
+		return "[\n" + strings.Join(parts, ",\n") + "\n" + indent + "]"
+	case string:
+		return strconv.Quote(t)
+	case nil:
+		return "null"
+	case time.Time:
+		return strconv.Quote(t.Format(time.RFC3339))
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func refName(ref, prefix string) (string, error) {
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported $ref %q (only local %s... refs are resolved)", ref, prefix)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (d *oaDoc) resolveSchema(s *oaSchema, depth int) *oaSchema {
+	for s != nil && s.Ref != "" && depth < maxSchemaDepth {
+		name, err := refName(s.Ref, "#/components/schemas/")
+		if err != nil {
+			return nil
+		}
+		s = d.Components.Schemas[name]
+		depth++
+	}
+	return s
+}
+
+func (d *oaDoc) resolveParam(p *oaParameter) (*oaParameter, error) {
+	if p == nil || p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "#/components/parameters/")
+	if err != nil {
+		return nil, err
+	}
+	resolved := d.Components.Parameters[name]
+	if resolved == nil {
+		return nil, fmt.Errorf("parameter %q not found in components", name)
+	}
+	return resolved, nil
+}
+
+func (d *oaDoc) resolveBody(rb *oaRequestBody) (*oaRequestBody, error) {
+	if rb.Ref == "" {
+		return rb, nil
+	}
+	name, err := refName(rb.Ref, "#/components/requestBodies/")
+	if err != nil {
+		return nil, err
+	}
+	resolved := d.Components.RequestBodies[name]
+	if resolved == nil {
+		return nil, fmt.Errorf("request body %q not found in components", name)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,215 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+)
+
+func loadPetstore(t *testing.T, opts OpenAPIOptions) *config.Config {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "petstore.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cfg, err := OpenAPI(f, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return cfg
+}
+
+func endpointNamed(t *testing.T, cfg *config.Config, name string) config.Endpoint {
+	t.Helper()
+	for _, ep := range cfg.Endpoints {
+		if ep.Name == name {
+			return ep
+		}
+	}
+	t.Fatalf("no endpoint named %q", name)
+	return config.Endpoint{}
+}
+
+func TestOpenAPI_OneEndpointPerOperation(t *testing.T) {
+	cfg := loadPetstore(t, OpenAPIOptions{})
+	if len(cfg.Endpoints) != 5 {
+		t.Fatalf("expected 5 endpoints, got %d", len(cfg.Endpoints))
+	}
+	if cfg.Name != "Petstore" || cfg.Variables["base_url"] != "https://eu.petstore.example/v1" {
+		t.Errorf("unexpected name or base_url: %q %v", cfg.Name, cfg.Variables)
+	}
+}
+
+func TestOpenAPI_Parameters(t *testing.T) {
+	cfg := loadPetstore(t, OpenAPIOptions{})
+
+	list := endpointNamed(t, cfg, "listPets")
+	if list.URL != "${base_url}/pets?limit=${random.int(1,100)}" {
+		t.Errorf("listPets: expected required query with int range, got %s", list.URL)
+	}
+	if get := endpointNamed(t, cfg, "getPet"); get.URL != "${base_url}/pets/${random.uuid}" {
+		t.Errorf("getPet: expected uuid path param from component, got %s", get.URL)
+	}
+	if owner := endpointNamed(t, cfg, "ownerPets"); owner.URL != "${base_url}/owners/${random.email}/pets" {
+		t.Errorf("ownerPets: expected email path param, got %s", owner.URL)
+	}
+	del := endpointNamed(t, cfg, "DELETE /pets/{petId}")
+	if del.Headers["X-Request-Reason"] != "${random.choice(cleanup,duplicate)}" {
+		t.Errorf("expected enum header as random.choice, got %v", del.Headers)
+	}
+}
+
+func TestOpenAPI_SuccessStatusAndSecurity(t *testing.T) {
+	cfg := loadPetstore(t, OpenAPIOptions{})
+	want := map[string]int{"listPets": 200, "createPet": 201, "getPet": 200, "DELETE /pets/{petId}": 204, "ownerPets": 200}
+	for name, status := range want {
+		if got := endpointNamed(t, cfg, name).Expect.Status; got != status {
+			t.Errorf("%s: expected status %d, got %d", name, status, got)
+		}
+	}
+	if h := endpointNamed(t, cfg, "listPets").Headers["Authorization"]; h != "Bearer ${token}" {
+		t.Errorf("expected bearer auth from global security, got %q", h)
+	}
+	if _, ok := endpointNamed(t, cfg, "DELETE /pets/{petId}").Headers["Authorization"]; ok {
+		t.Error("expected operation-level empty security to disable auth")
+	}
+	if cfg.Variables["token"] != "${API_TOKEN}" {
+		t.Errorf("expected token variable read from the environment, got %q", cfg.Variables["token"])
+	}
+}
+
+func TestOpenAPI_SynthesizedBody(t *testing.T) {
+	cfg := loadPetstore(t, OpenAPIOptions{})
+	create := endpointNamed(t, cfg, "createPet")
+	if create.Headers["Content-Type"] != "application/json" {
+		t.Errorf("expected JSON content type, got %v", create.Headers)
+	}
+	for _, want := range []string{
+		`"name": "${random.string(8)}"`,
+		`"species": "${random.choice(cat,dog)}"`,
+		`"age": ${random.int(0,30)}`,
+		`"vaccinated": ${random.bool}`,
+		`"email": "${random.email}"`,
+		`"phone": "+1-555-0100"`,
+		`"friendly"`,
+	} {
+		if !strings.Contains(create.Body, want) {
+			t.Errorf("expected body to contain %s\n%s", want, create.Body)
+		}
+	}
+	if strings.Contains(create.Body, `"id"`) {
+		t.Errorf("expected readOnly id to be omitted\n%s", create.Body)
+	}
+
+	// Once templates are evaluated the body must be valid JSON.
+	var v map[string]any
+	if err := json.Unmarshal([]byte(data.NewGenerator(nil).Generate(create.Body)), &v); err != nil {
+		t.Errorf("generated body is not valid JSON: %v\n%s", err, create.Body)
+	}
+}
+
+func TestOpenAPI_RecursiveSchemaAndEnums(t *testing.T) {
+	spec := `openapi: 3.0.3
+servers: [{url: "http://api.test"}]
+paths:
+  /nodes:
+    post:
+      operationId: createNode
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/Node"}
+      responses: {"201": {description: created}}
+components:
+  schemas:
+    Node:
+      type: object
+      allOf:
+        - $ref: "#/components/schemas/Base"
+        - type: object
+          properties:
+            sort: {type: string, enum: ["name,asc", "name,desc"]}
+            kind: {type: string, enum: [leaf, branch]}
+      oneOf:
+        - $ref: "#/components/schemas/Node"
+    Base:
+      allOf:
+        - $ref: "#/components/schemas/Node"
+      properties:
+        id: {type: string, format: uuid}
+`
+	cfg, err := OpenAPI(strings.NewReader(spec), OpenAPIOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := cfg.Endpoints[0].Body
+	for _, want := range []string{`"id": "${random.uuid}"`, `"sort": "name,asc"`, `"kind": "${random.choice(leaf,branch)}"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected body to contain %s\n%s", want, body)
+		}
+	}
+}
+
+func TestSuccessStatus(t *testing.T) {
+	tests := []struct {
+		codes []string
+		want  int
+	}{
+		{[]string{"2XX", "201"}, 201},
+		{[]string{"2xx", "204", "400"}, 204},
+		{[]string{"2XX", "default"}, 200},
+		{[]string{"202", "201", "500"}, 201},
+		{[]string{"404"}, 200},
+	}
+	for _, tt := range tests {
+		responses := make(map[string]yaml.Node)
+		for _, c := range tt.codes {
+			responses[c] = yaml.Node{}
+		}
+		// Map order varies; the answer must not.
+		for range 20 {
+			if got := successStatus(responses); got != tt.want {
+				t.Errorf("successStatus(%v) = %d, want %d", tt.codes, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestOpenAPI_TagFilterAndBaseURL(t *testing.T) {
+	cfg := loadPetstore(t, OpenAPIOptions{Tags: []string{"owners"}, BaseURL: "http://localhost:9000/"})
+	if len(cfg.Endpoints) != 1 || cfg.Endpoints[0].Name != "ownerPets" {
+		t.Errorf("expected only the owners operation, got %+v", cfg.Endpoints)
+	}
+	if cfg.Variables["base_url"] != "http://localhost:9000" {
+		t.Errorf("expected base_url override, got %q", cfg.Variables["base_url"])
+	}
+}
+
+func TestOpenAPI_OutputLoads(t *testing.T) {
+	out, err := config.Marshal(loadPetstore(t, OpenAPIOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "petstore.yaml")
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(path); err != nil {
+		t.Errorf("generated config failed to load: %v\n%s", err, out)
+	}
+}
+
+func TestOpenAPI_RejectsSwagger2(t *testing.T) {
+	if _, err := OpenAPI(strings.NewReader("swagger: \"2.0\"\npaths: {}\n"), OpenAPIOptions{}); err == nil {
+		t.Error("expected error for swagger 2.0 spec")
+	}
+}
@@ -0,0 +1,125 @@
+package importer
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/replay"
+)
+
+// Recorded is one request captured by the recording proxy.
+type Recorded struct {
+	Client   string // remote IP; requests from one client form a session
+	Start    time.Time
+	Duration time.Duration
+	Method   string
+	Path     string // path and query, relative to the target
+	Header   http.Header
+	Body     string
+	Status   int
+}
+
+// RecordOptions controls how recorded traffic is turned into a config.
+type RecordOptions struct {
+	Name    string
+	BaseURL string        // the proxied target, becomes ${base_url}
+	IdleGap time.Duration // longer pauses within a session are not think time; default 30s
+}
+
+// Recording builds a config from recorded traffic. Identical requests are
+// merged into one endpoint weighted by how often they were seen. The median
+// pause between a client's requests becomes load.think_time; endpoints whose
+// following pause differs from it by more than 20% get their own think_time.
+func Recording(reqs []Recorded, opts RecordOptions) (*config.Config, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no requests were recorded")
+	}
+	if opts.IdleGap <= 0 {
+		opts.IdleGap = 30 * time.Second
+	}
+	reqs = append([]Recorded(nil), reqs...)
+	sort.SliceStable(reqs, func(i, j int) bool { return reqs[i].Start.Before(reqs[j].Start) })
+
+	cfg := &config.Config{
+		Name:      opts.Name,
+		Variables: map[string]string{"base_url": strings.TrimRight(opts.BaseURL, "/")},
+		Load:      defaultLoad(),
+	}
+
+	index := make(map[string]int) // merge key -> endpoint index
+	keys := make([]int, len(reqs))
+	for i, r := range reqs {
+		key := r.Method + " " + r.Path + "\x00" + r.Body
+		j, ok := index[key]
+		if ok {
+			cfg.Endpoints[j].Weight++
+			keys[i] = j
+			continue
+		}
+		ep := config.Endpoint{
+			Name:   replay.EndpointName(r.Method, r.Path),
+			Method: r.Method,
+			URL:    "${base_url}" + r.Path,
+			Body:   r.Body,
+			Weight: 1,
+			Expect: config.ExpectConfig{Status: r.Status},
+		}
+		for name, values := range r.Header {
+			if skipHeaders[strings.ToLower(name)] || len(values) == 0 {
+				continue
+			}
+			if ep.Headers == nil {
+				ep.Headers = make(map[string]string)
+			}
+			ep.Headers[name] = strings.Join(values, ", ")
+		}
+		index[key] = len(cfg.Endpoints)
+		keys[i] = len(cfg.Endpoints)
+		cfg.Endpoints = append(cfg.Endpoints, ep)
+	}
+
+	// Think time is the gap between the end of one request and the start of
+	// the same client's next one.
+	var all []time.Duration
+	after := make([][]time.Duration, len(cfg.Endpoints))
+	last := make(map[string]int) // client -> index of its previous request
+	for i, r := range reqs {
+		if p, ok := last[r.Client]; ok {
+			gap := r.Start.Sub(reqs[p].Start.Add(reqs[p].Duration))
+			if gap > 0 && gap <= opts.IdleGap {
+				all = append(all, gap)
+				after[keys[p]] = append(after[keys[p]], gap)
+			}
+		}
+		last[r.Client] = i
+	}
+	think := median(all)
+	cfg.Load.ThinkTime = config.Duration{Duration: think}
+	for j, gaps := range after {
+		if len(gaps) == 0 {
+			continue
+		}
+		if m := median(gaps); m < think*8/10 || m > think*12/10 {
+			cfg.Endpoints[j].ThinkTime = config.Duration{Duration: m}
+		}
+	}
+	return cfg, nil
+}
+
+// median returns the median of ds rounded to 10ms, or 0 if ds is empty.
+func median(ds []time.Duration) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	s := append([]time.Duration(nil), ds...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	m := s[len(s)/2]
+	if len(s)%2 == 0 {
+		m = (s[len(s)/2-1] + m) / 2
+	}
+	return m.Round(10 * time.Millisecond)
+}
@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRecording(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	d := 100 * time.Millisecond
+	reqs := []Recorded{
+		{Client: "a", Start: at(0), Duration: d, Method: "GET", Path: "/items", Status: 200,
+			Header: http.Header{"Accept": {"application/json"}, "Connection": {"keep-alive"}}},
+		{Client: "a", Start: at(1100), Duration: d, Method: "GET", Path: "/items/7", Status: 200},
+		{Client: "b", Start: at(500), Duration: d, Method: "GET", Path: "/items", Status: 200},
+		{Client: "b", Start: at(1600), Duration: d, Method: "POST", Path: "/items", Body: `{"n":1}`, Status: 201},
+		{Client: "a", Start: at(4200), Duration: d, Method: "GET", Path: "/items", Status: 200},
+		// After a long break: not think time.
+		{Client: "b", Start: at(120000), Duration: d, Method: "GET", Path: "/items", Status: 200},
+	}
+
+	cfg, err := Recording(reqs, RecordOptions{Name: "rec", BaseURL: "http://localhost:8080/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Variables["base_url"] != "http://localhost:8080" {
+		t.Errorf("base_url = %q", cfg.Variables["base_url"])
+	}
+	if len(cfg.Endpoints) != 3 {
+		t.Fatalf("got %d endpoints, want 3", len(cfg.Endpoints))
+	}
+
+	list := cfg.Endpoints[0]
+	if list.Name != "GET /items" || list.URL != "${base_url}/items" || list.Weight != 4 {
+		t.Errorf("endpoint 0 = %s %s weight %d", list.Name, list.URL, list.Weight)
+	}
+	if list.Headers["Accept"] != "application/json" || list.Headers["Connection"] != "" {
+		t.Errorf("headers = %v", list.Headers)
+	}
+	if post := cfg.Endpoints[2]; post.Method != "POST" || post.Body != `{"n":1}` || post.Expect.Status != 201 {
+		t.Errorf("endpoint 2 = %+v", post)
+	}
+
+	// Gaps: a 1000ms after /items, b 1000ms after /items, a 3000ms after /items/7.
+	if got := cfg.Load.ThinkTime.Duration; got != time.Second {
+		t.Errorf("think_time = %s, want 1s", got)
+	}
+	if got := cfg.Endpoints[1].ThinkTime.Duration; got != 3*time.Second {
+		t.Errorf("endpoint 1 think_time = %s, want 3s", got)
+	}
+	if got := cfg.Endpoints[0].ThinkTime.Duration; got != 0 {
+		t.Errorf("endpoint 0 think_time = %s, want unset (matches the global value)", got)
+	}
+}
+
+func TestRecording_Empty(t *testing.T) {
+	if _, err := Recording(nil, RecordOptions{BaseURL: "http://x"}); err == nil {
+		t.Error("expected error with no requests")
+	}
+}
@@ -15,6 +15,74 @@ type Result struct {
 	Error         error
 	Timestamp     time.Time
 	Success       bool
+	Protocol      string // negotiated protocol, e.g. "HTTP/1.1" or "HTTP/2.0"; empty if none
+	ConnsOpened   int    // new connections the request (and its redirects) opened
+	ConnsReused   int    // pooled connections it reused
+
+	// Code is the status of a call that has its own status codes, such as
+	// gRPC's "NOT_FOUND"; empty for HTTP.
+	Code string
+	// Operation is the GraphQL operation a request ran, if any.
+	Operation string
+
+	// Session is set for connection-oriented endpoints, such as websocket,
+	// tcp and udp.
+	Session *Session
+	// Stream is set for responses read as a stream of events.
+	Stream *Stream
+}
+
+// Session describes one connection-oriented exchange, such as a WebSocket
+// session or a tcp request and response. For a WebSocket session the
+// Result's Duration is the time taken to connect; for tcp and udp it is the
+// whole exchange.
+type Session struct {
+	Connect       time.Duration
+	RTTs          []time.Duration // from each send to its expected reply
+	Sent          int             // messages sent
+	Received      int             // messages received
+	AbnormalClose bool            // the connection dropped without an orderly close
+}
+
+// SessionStats aggregates the Sessions of one endpoint.
+type SessionStats struct {
+	Name           string
+	Sessions       int64
+	ConnectP50     time.Duration
+	ConnectP90     time.Duration
+	ConnectP99     time.Duration
+	RTTP50         time.Duration
+	RTTP90         time.Duration
+	RTTP99         time.Duration
+	Sent           int64
+	Received       int64
+	AbnormalCloses int64
+}
+
+// Stream describes a response read as a stream of events, such as
+// Server-Sent Events. The Result's Duration is the whole stream's.
+type Stream struct {
+	TTFB       time.Duration   // to the first byte of the response
+	FirstEvent time.Duration   // to the first complete event; 0 if none arrived
+	Gaps       []time.Duration // between consecutive events
+	Events     int
+	Capped     bool // cut short by max_events or max_duration
+}
+
+// StreamStats aggregates the Streams of one endpoint.
+type StreamStats struct {
+	Name          string
+	Streams       int64
+	TTFBP50       time.Duration
+	TTFBP99       time.Duration
+	FirstEventP50 time.Duration
+	FirstEventP90 time.Duration
+	FirstEventP99 time.Duration
+	GapP50        time.Duration
+	GapP90        time.Duration
+	GapP99        time.Duration
+	Events        int64
+	Capped        int64
 }
 
 // EndpointStats holds per-endpoint aggregated metrics.
@@ -31,6 +99,7 @@ type EndpointStats struct {
 	Min           time.Duration
 	Max           time.Duration
 	Avg           time.Duration
+	Codes         map[string]int64 // results by Result.Code, if any had one
 }
 
 // Stats is a point-in-time snapshot of all collected metrics.
@@ -47,8 +116,83 @@ type Stats struct {
 	Max           time.Duration
 	Avg           time.Duration
 	PerEndpoint   map[string]*EndpointStats
+	PerProtocol   map[string]*EndpointStats // keyed by Result.Protocol; Name is the protocol
+	PerOperation  map[string]*EndpointStats // keyed by Result.Operation, for GraphQL requests
+	Sessions      map[string]*SessionStats  // per endpoint, for endpoints with sessions
+	Streams       map[string]*StreamStats   // per endpoint, for streamed responses
+	NewConns      int64                     // connections opened
+	ReusedConns   int64                     // requests sent on a reused connection
+	NewConnsRate  float64                   // connections opened per second
+	ConnReuse     float64                   // fraction of requests on a reused connection (0-1)
 	ActiveVUs     int
 	Elapsed       time.Duration
+	Adjustments   []Adjustment
+	Interventions []Intervention
+
+	// Requests in flight when a VU was removed or the test stopped.
+	DrainedRequests     int64 // completed within graceful_stop (and counted above)
+	InterruptedRequests int64 // cancelled; not counted in the totals above
+}
+
+// Intervention records a manual change made to a running test.
+type Intervention struct {
+	Elapsed time.Duration
+	Action  string // pause, resume, target, skip, extend
+	Value   string // argument to the action, if any
+	Source  string // where the command came from: web, api, socket
+}
+
+// Adjustment records a single decision made by the adaptive load controller.
+type Adjustment struct {
+	Elapsed    time.Duration
+	Latency    time.Duration // windowed latency at the controlled percentile
+	ErrorRate  float64       // windowed error fraction (0-1)
+	Samples    int64
+	PrevTarget int
+	Target     int
+	Reason     string
+}
+
+// Window summarizes results recorded within a recent time window.
+type Window struct {
+	Count      int64
+	Errors     int64
+	sorted     []time.Duration
+	windowSize time.Duration
+}
+
+// ErrorRate returns the fraction of failed requests in the window.
+func (w Window) ErrorRate() float64 {
+	if w.Count == 0 {
+		return 0
+	}
+	return float64(w.Errors) / float64(w.Count)
+}
+
+// Percentile returns the p-th percentile latency in the window.
+func (w Window) Percentile(p float64) time.Duration {
+	return percentile(w.sorted, p)
+}
+
+// RPS returns the completed request rate over the window.
+func (w Window) RPS() float64 {
+	if w.windowSize <= 0 {
+		return 0
+	}
+	return float64(w.Count) / w.windowSize.Seconds()
+}
+
+// recentRetention bounds how far back Window can look.
+const recentRetention = 5 * time.Minute
+
+// minCompact is the number of expired samples below which the recent buffer
+// is not compacted.
+const minCompact = 1024
+
+type sample struct {
+	at       time.Time
+	duration time.Duration
+	success  bool
 }
 
 type endpointData struct {
@@ -56,6 +200,25 @@ type endpointData struct {
 	successes int64
 	errors    int64
 	bytes     int64
+	codes     map[string]int64
+}
+
+type sessionData struct {
+	count    int64
+	connects []time.Duration
+	rtts     []time.Duration
+	sent     int64
+	received int64
+	abnormal int64
+}
+
+type streamData struct {
+	count       int64
+	ttfbs       []time.Duration
+	firstEvents []time.Duration
+	gaps        []time.Duration
+	events      int64
+	capped      int64
 }
 
 // Collector gathers Results from concurrent workers thread-safely.
@@ -63,7 +226,21 @@ type Collector struct {
 	mu        sync.Mutex
 	startTime time.Time
 	endpoints map[string]*endpointData
+	protocols map[string]*endpointData
+	ops       map[string]*endpointData
+	sessions  map[string]*sessionData
+	streams   map[string]*streamData
 	activeVUs int
+	window    bool     // keep recent samples for Window
+	recent    []sample // recent[head:] are within recentRetention
+	head      int
+	adjusts   []Adjustment
+	manual    []Intervention
+	opened    int64
+	reused    int64
+
+	drained     int64
+	interrupted int64
 }
 
 // NewCollector creates a Collector with the given start time.
@@ -71,9 +248,21 @@ func NewCollector(start time.Time) *Collector {
 	return &Collector{
 		startTime: start,
 		endpoints: make(map[string]*endpointData),
+		protocols: make(map[string]*endpointData),
+		ops:       make(map[string]*endpointData),
+		sessions:  make(map[string]*sessionData),
+		streams:   make(map[string]*streamData),
 	}
 }
 
+// KeepWindow makes the collector keep the samples Window reads. Call it
+// before recording; without it Window is always empty.
+func (c *Collector) KeepWindow() {
+	c.mu.Lock()
+	c.window = true
+	c.mu.Unlock()
+}
+
 // SetActiveVUs updates the active VU count (called by engine).
 func (c *Collector) SetActiveVUs(n int) {
 	c.mu.Lock()
@@ -86,18 +275,159 @@ func (c *Collector) Record(r Result) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	ep, ok := c.endpoints[r.EndpointName]
+	record(c.endpoints, r.EndpointName, r)
+	c.opened += int64(r.ConnsOpened)
+	c.reused += int64(r.ConnsReused)
+	if r.Protocol != "" {
+		record(c.protocols, r.Protocol, r)
+	}
+	if r.Operation != "" {
+		record(c.ops, r.Operation, r)
+	}
+	if r.Session != nil {
+		c.recordSession(r.EndpointName, r.Session)
+	}
+	if r.Stream != nil {
+		c.recordStream(r.EndpointName, r.Stream)
+	}
+
+	if c.window {
+		now := time.Now()
+		c.recent = append(c.recent, sample{at: now, duration: r.Duration, success: r.Success})
+		c.expire(now)
+	}
+}
+
+// expire drops samples older than recentRetention by advancing head, and
+// compacts the buffer once at least half of it has expired, so the cost per
+// sample stays constant.
+func (c *Collector) expire(now time.Time) {
+	cutoff := now.Add(-recentRetention)
+	for c.head < len(c.recent) && !c.recent[c.head].at.After(cutoff) {
+		c.head++
+	}
+	if c.head >= minCompact && c.head >= len(c.recent)/2 {
+		n := copy(c.recent, c.recent[c.head:])
+		clear(c.recent[n:])
+		c.recent = c.recent[:n]
+		c.head = 0
+	}
+}
+
+func record(m map[string]*endpointData, key string, r Result) {
+	d, ok := m[key]
 	if !ok {
-		ep = &endpointData{}
-		c.endpoints[r.EndpointName] = ep
+		d = &endpointData{}
+		m[key] = d
+	}
+	d.durations = append(d.durations, r.Duration)
+	d.bytes += r.BytesReceived
+	if r.Code != "" {
+		if d.codes == nil {
+			d.codes = make(map[string]int64)
+		}
+		d.codes[r.Code]++
 	}
-	ep.durations = append(ep.durations, r.Duration)
-	ep.bytes += r.BytesReceived
 	if r.Success {
-		ep.successes++
+		d.successes++
 	} else {
-		ep.errors++
+		d.errors++
+	}
+}
+
+func (c *Collector) recordSession(name string, s *Session) {
+	d, ok := c.sessions[name]
+	if !ok {
+		d = &sessionData{}
+		c.sessions[name] = d
+	}
+	d.count++
+	d.connects = append(d.connects, s.Connect)
+	d.rtts = append(d.rtts, s.RTTs...)
+	d.sent += int64(s.Sent)
+	d.received += int64(s.Received)
+	if s.AbnormalClose {
+		d.abnormal++
+	}
+}
+
+func (c *Collector) recordStream(name string, s *Stream) {
+	d, ok := c.streams[name]
+	if !ok {
+		d = &streamData{}
+		c.streams[name] = d
+	}
+	d.count++
+	d.ttfbs = append(d.ttfbs, s.TTFB)
+	if s.Events > 0 {
+		d.firstEvents = append(d.firstEvents, s.FirstEvent)
+	}
+	d.gaps = append(d.gaps, s.Gaps...)
+	d.events += int64(s.Events)
+	if s.Capped {
+		d.capped++
+	}
+}
+
+// Window returns statistics for results recorded within the last d.
+func (c *Collector) Window(d time.Duration) Window {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := time.Now().Add(-d)
+	recent := c.recent[c.head:]
+	i := sort.Search(len(recent), func(i int) bool { return recent[i].at.After(cutoff) })
+	w := Window{windowSize: d}
+	for _, s := range recent[i:] {
+		w.Count++
+		if !s.success {
+			w.Errors++
+		}
+		w.sorted = append(w.sorted, s.duration)
+	}
+	sort.Slice(w.sorted, func(i, j int) bool { return w.sorted[i] < w.sorted[j] })
+	return w
+}
+
+// RecordStop adds to the counts of in-flight requests that completed during a
+// graceful stop or were interrupted by cancellation.
+func (c *Collector) RecordStop(drained, interrupted int64) {
+	c.mu.Lock()
+	c.drained += drained
+	c.interrupted += interrupted
+	c.mu.Unlock()
+}
+
+// LastAdjustment returns the most recent controller decision, if any.
+func (c *Collector) LastAdjustment() (Adjustment, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.adjusts) == 0 {
+		return Adjustment{}, false
+	}
+	return c.adjusts[len(c.adjusts)-1], true
+}
+
+// RecordAdjustment appends a controller decision to the adjustment time series.
+// A zero Elapsed is filled in from the collector's start time.
+func (c *Collector) RecordAdjustment(a Adjustment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if a.Elapsed == 0 {
+		a.Elapsed = time.Since(c.startTime)
+	}
+	c.adjusts = append(c.adjusts, a)
+}
+
+// RecordIntervention appends a manual intervention to the results.
+// A zero Elapsed is filled in from the collector's start time.
+func (c *Collector) RecordIntervention(i Intervention) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i.Elapsed == 0 {
+		i.Elapsed = time.Since(c.startTime)
 	}
+	c.manual = append(c.manual, i)
 }
 
 // Snapshot computes and returns a point-in-time Stats snapshot.
@@ -110,38 +440,46 @@ func (c *Collector) Snapshot() *Stats {
 		Elapsed:     elapsed,
 		ActiveVUs:   c.activeVUs,
 		PerEndpoint: make(map[string]*EndpointStats),
+		NewConns:    c.opened,
+		ReusedConns: c.reused,
+
+		DrainedRequests:     c.drained,
+		InterruptedRequests: c.interrupted,
 	}
 
 	var allDurations []time.Duration
 
 	for name, ep := range c.endpoints {
-		total := ep.successes + ep.errors
-		es := &EndpointStats{
-			Name:          name,
-			TotalRequests: total,
-			SuccessCount:  ep.successes,
-			ErrorCount:    ep.errors,
-			TotalBytes:    ep.bytes,
-		}
-		if len(ep.durations) > 0 {
-			sorted := make([]time.Duration, len(ep.durations))
-			copy(sorted, ep.durations)
-			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
-
-			es.P50 = percentile(sorted, 50)
-			es.P90 = percentile(sorted, 90)
-			es.P95 = percentile(sorted, 95)
-			es.P99 = percentile(sorted, 99)
-			es.Min = sorted[0]
-			es.Max = sorted[len(sorted)-1]
-			es.Avg = average(sorted)
-
-			allDurations = append(allDurations, ep.durations...)
-		}
-		stats.PerEndpoint[name] = es
-		stats.TotalRequests += total
+		stats.PerEndpoint[name] = ep.stats(name)
+		stats.TotalRequests += ep.successes + ep.errors
 		stats.SuccessCount += ep.successes
 		stats.ErrorCount += ep.errors
+		allDurations = append(allDurations, ep.durations...)
+	}
+	if len(c.protocols) > 0 {
+		stats.PerProtocol = make(map[string]*EndpointStats, len(c.protocols))
+		for name, p := range c.protocols {
+			stats.PerProtocol[name] = p.stats(name)
+		}
+	}
+	if len(c.ops) > 0 {
+		stats.PerOperation = make(map[string]*EndpointStats, len(c.ops))
+		for name, op := range c.ops {
+			stats.PerOperation[name] = op.stats(name)
+		}
+	}
+
+	if len(c.sessions) > 0 {
+		stats.Sessions = make(map[string]*SessionStats, len(c.sessions))
+		for name, d := range c.sessions {
+			stats.Sessions[name] = d.stats(name)
+		}
+	}
+	if len(c.streams) > 0 {
+		stats.Streams = make(map[string]*StreamStats, len(c.streams))
+		for name, d := range c.streams {
+			stats.Streams[name] = d.stats(name)
+		}
 	}
 
 	if len(allDurations) > 0 {
@@ -155,13 +493,102 @@ func (c *Collector) Snapshot() *Stats {
 		stats.Avg = average(allDurations)
 	}
 
+	if len(c.adjusts) > 0 {
+		stats.Adjustments = make([]Adjustment, len(c.adjusts))
+		copy(stats.Adjustments, c.adjusts)
+	}
+	if len(c.manual) > 0 {
+		stats.Interventions = make([]Intervention, len(c.manual))
+		copy(stats.Interventions, c.manual)
+	}
+
 	if elapsed.Seconds() > 0 {
 		stats.RPS = float64(stats.TotalRequests) / elapsed.Seconds()
+		stats.NewConnsRate = float64(c.opened) / elapsed.Seconds()
+	}
+	if n := c.opened + c.reused; n > 0 {
+		stats.ConnReuse = float64(c.reused) / float64(n)
 	}
 
 	return stats
 }
 
+// stats summarizes d under the given name.
+func (d *endpointData) stats(name string) *EndpointStats {
+	es := &EndpointStats{
+		Name:          name,
+		TotalRequests: d.successes + d.errors,
+		SuccessCount:  d.successes,
+		ErrorCount:    d.errors,
+		TotalBytes:    d.bytes,
+	}
+	if len(d.codes) > 0 {
+		es.Codes = make(map[string]int64, len(d.codes))
+		for code, n := range d.codes {
+			es.Codes[code] = n
+		}
+	}
+	if len(d.durations) > 0 {
+		sorted := make([]time.Duration, len(d.durations))
+		copy(sorted, d.durations)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		es.P50 = percentile(sorted, 50)
+		es.P90 = percentile(sorted, 90)
+		es.P95 = percentile(sorted, 95)
+		es.P99 = percentile(sorted, 99)
+		es.Min = sorted[0]
+		es.Max = sorted[len(sorted)-1]
+		es.Avg = average(sorted)
+	}
+	return es
+}
+
+// stats summarizes d under the given name.
+func (d *sessionData) stats(name string) *SessionStats {
+	ss := &SessionStats{
+		Name:           name,
+		Sessions:       d.count,
+		Sent:           d.sent,
+		Received:       d.received,
+		AbnormalCloses: d.abnormal,
+	}
+	connects := sorted(d.connects)
+	ss.ConnectP50 = percentile(connects, 50)
+	ss.ConnectP90 = percentile(connects, 90)
+	ss.ConnectP99 = percentile(connects, 99)
+	rtts := sorted(d.rtts)
+	ss.RTTP50 = percentile(rtts, 50)
+	ss.RTTP90 = percentile(rtts, 90)
+	ss.RTTP99 = percentile(rtts, 99)
+	return ss
+}
+
+// stats summarizes d under the given name.
+func (d *streamData) stats(name string) *StreamStats {
+	ss := &StreamStats{Name: name, Streams: d.count, Events: d.events, Capped: d.capped}
+	ttfbs := sorted(d.ttfbs)
+	ss.TTFBP50 = percentile(ttfbs, 50)
+	ss.TTFBP99 = percentile(ttfbs, 99)
+	firsts := sorted(d.firstEvents)
+	ss.FirstEventP50 = percentile(firsts, 50)
+	ss.FirstEventP90 = percentile(firsts, 90)
+	ss.FirstEventP99 = percentile(firsts, 99)
+	gaps := sorted(d.gaps)
+	ss.GapP50 = percentile(gaps, 50)
+	ss.GapP90 = percentile(gaps, 90)
+	ss.GapP99 = percentile(gaps, 99)
+	return ss
+}
+
+// sorted returns a sorted copy of durations.
+func sorted(durations []time.Duration) []time.Duration {
+	s := make([]time.Duration, len(durations))
+	copy(s, durations)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s
+}
+
 func percentile(sorted []time.Duration, p float64) time.Duration {
 	if len(sorted) == 0 {
 		return 0
@@ -134,3 +134,219 @@ func TestSetActiveVUs(t *testing.T) {
 		t.Errorf("expected 42 active VUs, got %d", snap.ActiveVUs)
 	}
 }
+
+func TestWindow_RecentResults(t *testing.T) {
+	c := NewCollector(time.Now())
+	c.KeepWindow()
+	for i := 1; i <= 10; i++ {
+		c.Record(Result{EndpointName: "ep", Duration: time.Duration(i) * time.Millisecond, Success: i != 10})
+	}
+	w := c.Window(time.Minute)
+	if w.Count != 10 {
+		t.Errorf("expected 10 results in window, got %d", w.Count)
+	}
+	if w.ErrorRate() != 0.1 {
+		t.Errorf("expected error rate 0.1, got %v", w.ErrorRate())
+	}
+	if p := w.Percentile(99); p != 9*time.Millisecond {
+		t.Errorf("expected p99 9ms, got %v", p)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if w := c.Window(10 * time.Millisecond); w.Count != 0 {
+		t.Errorf("expected empty window, got %d results", w.Count)
+	}
+}
+
+func TestWindow_NotKept(t *testing.T) {
+	c := NewCollector(time.Now())
+	c.Record(Result{EndpointName: "ep", Duration: time.Millisecond, Success: true})
+	if w := c.Window(time.Minute); w.Count != 0 || len(c.recent) != 0 {
+		t.Errorf("window = %d results, buffer = %d samples; want none without KeepWindow", w.Count, len(c.recent))
+	}
+}
+
+func TestWindow_Expire(t *testing.T) {
+	c := NewCollector(time.Now())
+	c.KeepWindow()
+	old := time.Now().Add(-recentRetention - time.Second)
+	for range 3 * minCompact {
+		c.recent = append(c.recent, sample{at: old, success: true})
+	}
+	c.Record(Result{EndpointName: "ep", Duration: time.Millisecond, Success: true})
+	if len(c.recent) != 1 || c.head != 0 {
+		t.Errorf("buffer = %d samples, head = %d; want expired samples compacted away", len(c.recent), c.head)
+	}
+
+	for range minCompact / 2 {
+		c.recent = append([]sample{{at: old}}, c.recent...)
+	}
+	c.Record(Result{EndpointName: "ep", Duration: time.Millisecond, Success: true})
+	if c.head != minCompact/2 || len(c.recent) != minCompact/2+2 {
+		t.Errorf("buffer = %d samples, head = %d; want expired samples skipped, not yet compacted", len(c.recent), c.head)
+	}
+	if w := c.Window(time.Minute); w.Count != 2 || w.Errors != 0 {
+		t.Errorf("window = %d results, %d errors; want 2, 0", w.Count, w.Errors)
+	}
+}
+
+func TestLastAdjustment(t *testing.T) {
+	c := NewCollector(time.Now())
+	if _, ok := c.LastAdjustment(); ok {
+		t.Error("expected no adjustment before any are recorded")
+	}
+	c.RecordAdjustment(Adjustment{Target: 5, Reason: "initial"})
+	c.RecordAdjustment(Adjustment{PrevTarget: 5, Target: 7, Reason: "healthy"})
+	if a, ok := c.LastAdjustment(); !ok || a.Target != 7 || a.Reason != "healthy" {
+		t.Errorf("LastAdjustment() = %+v, %v", a, ok)
+	}
+}
+
+func TestRecordAdjustment_InSnapshot(t *testing.T) {
+	c := NewCollector(time.Now())
+	c.RecordAdjustment(Adjustment{PrevTarget: 5, Target: 6, Reason: "healthy"})
+	snap := c.Snapshot()
+	if len(snap.Adjustments) != 1 {
+		t.Fatalf("expected 1 adjustment, got %d", len(snap.Adjustments))
+	}
+	if snap.Adjustments[0].Target != 6 || snap.Adjustments[0].Elapsed <= 0 {
+		t.Errorf("unexpected adjustment: %+v", snap.Adjustments[0])
+	}
+}
+
+func TestPerProtocol(t *testing.T) {
+	c := NewCollector(time.Now())
+	if c.Snapshot().PerProtocol != nil {
+		t.Error("expected no protocol stats before any results")
+	}
+	c.Record(Result{EndpointName: "a", Protocol: "HTTP/1.1", Duration: 30 * time.Millisecond, Success: true})
+	c.Record(Result{EndpointName: "a", Protocol: "HTTP/2.0", Duration: 10 * time.Millisecond, Success: true})
+	c.Record(Result{EndpointName: "b", Protocol: "HTTP/2.0", Duration: 20 * time.Millisecond, Success: false})
+	c.Record(Result{EndpointName: "b", Duration: time.Millisecond}) // connection failed
+
+	snap := c.Snapshot()
+	if len(snap.PerProtocol) != 2 {
+		t.Fatalf("expected 2 protocols, got %v", snap.PerProtocol)
+	}
+	h2 := snap.PerProtocol["HTTP/2.0"]
+	if h2.Name != "HTTP/2.0" || h2.TotalRequests != 2 || h2.ErrorCount != 1 || h2.Max != 20*time.Millisecond {
+		t.Errorf("HTTP/2.0 stats = %+v", h2)
+	}
+	if snap.PerProtocol["HTTP/1.1"].TotalRequests != 1 || snap.TotalRequests != 4 {
+		t.Errorf("HTTP/1.1 = %d, total = %d", snap.PerProtocol["HTTP/1.1"].TotalRequests, snap.TotalRequests)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	c := NewCollector(time.Now().Add(-2 * time.Second))
+	c.Record(Result{EndpointName: "a", ConnsOpened: 1, Success: true})
+	for range 3 {
+		c.Record(Result{EndpointName: "a", ConnsReused: 1, Success: true})
+	}
+	c.Record(Result{EndpointName: "a"}) // failed before getting a connection
+
+	snap := c.Snapshot()
+	if snap.NewConns != 1 || snap.ReusedConns != 3 {
+		t.Errorf("new = %d, reused = %d; want 1, 3", snap.NewConns, snap.ReusedConns)
+	}
+	if snap.ConnReuse != 0.75 {
+		t.Errorf("reuse = %v, want 0.75", snap.ConnReuse)
+	}
+	if snap.NewConnsRate <= 0 || snap.NewConnsRate > 0.5 {
+		t.Errorf("new conns/s = %v, want about 0.5", snap.NewConnsRate)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	c := NewCollector(time.Now())
+	c.Record(Result{EndpointName: "plain", Success: true})
+	c.Record(Result{EndpointName: "ws", Success: true, Session: &Session{
+		Connect: 2 * time.Millisecond, RTTs: []time.Duration{time.Millisecond, 3 * time.Millisecond}, Sent: 2, Received: 3}})
+	c.Record(Result{EndpointName: "ws", Session: &Session{
+		Connect: 4 * time.Millisecond, RTTs: []time.Duration{5 * time.Millisecond}, Sent: 1, AbnormalClose: true}})
+
+	snap := c.Snapshot()
+	if len(snap.Sessions) != 1 {
+		t.Fatalf("sessions = %v, want only ws", snap.Sessions)
+	}
+	ss := snap.Sessions["ws"]
+	if ss.Sessions != 2 || ss.Sent != 3 || ss.Received != 3 || ss.AbnormalCloses != 1 {
+		t.Errorf("ws = %+v", ss)
+	}
+	if ss.ConnectP50 != 2*time.Millisecond || ss.ConnectP99 != 2*time.Millisecond {
+		t.Errorf("connect p50/p99 = %v/%v", ss.ConnectP50, ss.ConnectP99)
+	}
+	if ss.RTTP50 != 3*time.Millisecond || ss.RTTP99 != 3*time.Millisecond {
+		t.Errorf("rtt p50/p99 = %v/%v", ss.RTTP50, ss.RTTP99)
+	}
+	if snap.PerEndpoint["ws"].TotalRequests != 2 {
+		t.Errorf("ws requests = %d, want 2", snap.PerEndpoint["ws"].TotalRequests)
+	}
+}
+
+func TestCodes(t *testing.T) {
+	c := NewCollector(time.Now())
+	c.Record(Result{EndpointName: "plain", StatusCode: 200, Success: true})
+	c.Record(Result{EndpointName: "greet", Protocol: "gRPC", Code: "OK", Success: true})
+	c.Record(Result{EndpointName: "greet", Protocol: "gRPC", Code: "OK", Success: true})
+	c.Record(Result{EndpointName: "greet", Protocol: "gRPC", Code: "UNAVAILABLE"})
+
+	snap := c.Snapshot()
+	if snap.PerEndpoint["plain"].Codes != nil {
+		t.Errorf("plain codes = %v, want none", snap.PerEndpoint["plain"].Codes)
+	}
+	codes := snap.PerEndpoint["greet"].Codes
+	if codes["OK"] != 2 || codes["UNAVAILABLE"] != 1 || len(codes) != 2 {
+		t.Errorf("greet codes = %v", codes)
+	}
+	if snap.PerProtocol["gRPC"].ErrorCount != 1 {
+		t.Errorf("gRPC errors = %d, want 1", snap.PerProtocol["gRPC"].ErrorCount)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	c := NewCollector(time.Now())
+	c.Record(Result{EndpointName: "plain", Success: true})
+	c.Record(Result{EndpointName: "sse", Success: true, Stream: &Stream{TTFB: time.Millisecond, FirstEvent: 4 * time.Millisecond,
+		Gaps: []time.Duration{2 * time.Millisecond, 6 * time.Millisecond}, Events: 3}})
+	c.Record(Result{EndpointName: "sse", Success: true, Stream: &Stream{TTFB: 3 * time.Millisecond, FirstEvent: 8 * time.Millisecond,
+		Events: 1, Capped: true}})
+	c.Record(Result{EndpointName: "sse", Stream: &Stream{TTFB: 2 * time.Millisecond}}) // no events
+
+	snap := c.Snapshot()
+	if len(snap.Streams) != 1 {
+		t.Fatalf("streams = %v, want only sse", snap.Streams)
+	}
+	ss := snap.Streams["sse"]
+	if ss.Streams != 3 || ss.Events != 4 || ss.Capped != 1 {
+		t.Errorf("sse = %+v", ss)
+	}
+	if ss.TTFBP50 != 2*time.Millisecond || ss.TTFBP99 != 2*time.Millisecond {
+		t.Errorf("ttfb p50/p99 = %v/%v", ss.TTFBP50, ss.TTFBP99)
+	}
+	if ss.FirstEventP50 != 4*time.Millisecond || ss.FirstEventP99 != 4*time.Millisecond {
+		t.Errorf("first event p50/p99 = %v/%v, want only streams with events counted", ss.FirstEventP50, ss.FirstEventP99)
+	}
+	if ss.GapP50 != 2*time.Millisecond || ss.GapP99 != 2*time.Millisecond {
+		t.Errorf("gap p50/p99 = %v/%v", ss.GapP50, ss.GapP99)
+	}
+}
+
+func TestPerOperation(t *testing.T) {
+	c := NewCollector(time.Now())
+	c.Record(Result{EndpointName: "plain", Success: true})
+	c.Record(Result{EndpointName: "user by id", Operation: "GetUser", Duration: 2 * time.Millisecond, Success: true})
+	c.Record(Result{EndpointName: "user by email", Operation: "GetUser", Duration: 4 * time.Millisecond, Success: true})
+	c.Record(Result{EndpointName: "post", Operation: "CreatePost"})
+
+	snap := c.Snapshot()
+	if len(snap.PerOperation) != 2 {
+		t.Fatalf("operations = %v, want GetUser and CreatePost", snap.PerOperation)
+	}
+	if op := snap.PerOperation["GetUser"]; op.TotalRequests != 2 || op.P99 != 2*time.Millisecond {
+		t.Errorf("GetUser = %+v", op)
+	}
+	if op := snap.PerOperation["CreatePost"]; op.ErrorCount != 1 {
+		t.Errorf("CreatePost errors = %d, want 1", op.ErrorCount)
+	}
+}
@@ -0,0 +1,388 @@
+// Package mock serves a configurable fake HTTP API. It backs the
+// "perf-test mock" command and gives tests a target with realistic latency,
+// errors and rate limiting.
+package mock
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+)
+
+// Config describes a mock API.
+type Config struct {
+	Listen    string     `yaml:"listen,omitempty"`     // default ":8080"
+	Seed      int64      `yaml:"seed,omitempty"`       // fixes latency and error sampling; 0 = random
+	RateLimit *RateLimit `yaml:"rate_limit,omitempty"` // applies to all routes together
+	Routes    []Route    `yaml:"routes"`
+}
+
+// Route is one mocked endpoint. Path segments written {name} match any value
+// and are available to Body as ${path.name}; a trailing /* matches any rest.
+// Query parameters are available as ${query.name}, and the data generator's
+// random functions work too.
+type Route struct {
+	Method      string            `yaml:"method,omitempty"` // empty matches any method
+	Path        string            `yaml:"path"`
+	Status      int               `yaml:"status,omitempty"` // default 200
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Body        string            `yaml:"body,omitempty"`
+	Latency     Latency           `yaml:"latency,omitempty"`
+	ErrorRate   float64           `yaml:"error_rate,omitempty"`   // fraction 0-1 of requests that fail
+	ErrorStatus int               `yaml:"error_status,omitempty"` // default 500
+	ErrorBody   string            `yaml:"error_body,omitempty"`
+	RateLimit   *RateLimit        `yaml:"rate_limit,omitempty"`
+}
+
+// Latency is the delay added before a route responds.
+//
+//	fixed:     always Mean
+//	normal:    Mean ± StdDev, never below zero
+//	long_tail: log-normal with median Mean and 99th percentile P99
+type Latency struct {
+	Distribution string          `yaml:"distribution,omitempty"` // default "fixed"
+	Mean         config.Duration `yaml:"mean,omitempty"`
+	StdDev       config.Duration `yaml:"stddev,omitempty"`
+	P99          config.Duration `yaml:"p99,omitempty"`
+}
+
+// RateLimit rejects requests beyond RPS with 429 Too Many Requests.
+type RateLimit struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst,omitempty"` // default max(1, RPS)
+}
+
+// Load reads and parses a mock config file.
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mock config: %w", err)
+	}
+	var cfg Config
+	if err := config.DecodeStrict(path, b, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing mock config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Default is the API served by "perf-test mock" without a config file.
+func Default() *Config {
+	ms := func(n int) config.Duration { return config.Duration{Duration: time.Duration(n) * time.Millisecond} }
+	return &Config{Routes: []Route{
+		{Method: "GET", Path: "/health", Body: `{"status":"ok"}`},
+		{Method: "GET", Path: "/users", Body: `[{"id":1},{"id":2},{"id":3}]`,
+			Latency: Latency{Distribution: "normal", Mean: ms(20), StdDev: ms(5)}},
+		{Method: "GET", Path: "/users/{id}", Body: `{"id":${path.id},"email":"${random.email}"}`,
+			Latency: Latency{Distribution: "long_tail", Mean: ms(15), P99: ms(200)}},
+		{Method: "POST", Path: "/users", Status: 201, Body: `{"id":"${random.uuid}"}`,
+			Latency: Latency{Distribution: "normal", Mean: ms(40), StdDev: ms(10)}, ErrorRate: 0.01},
+		{Method: "GET", Path: "/slow", Body: `{"status":"ok"}`, Latency: Latency{Mean: ms(500)}},
+		{Method: "GET", Path: "/flaky", Body: `{"status":"ok"}`, ErrorRate: 0.2, ErrorStatus: 503},
+		{Method: "GET", Path: "/limited", Body: `{"status":"ok"}`, RateLimit: &RateLimit{RPS: 10}},
+	}}
+}
+
+// ApplyDefaults fills unset fields.
+func (c *Config) ApplyDefaults() {
+	if c.Listen == "" {
+		c.Listen = ":8080"
+	}
+	for i := range c.Routes {
+		r := &c.Routes[i]
+		r.Method = strings.ToUpper(r.Method)
+		if r.Status == 0 {
+			r.Status = http.StatusOK
+		}
+		if r.ErrorStatus == 0 {
+			r.ErrorStatus = http.StatusInternalServerError
+		}
+		if r.Latency.Distribution == "" {
+			r.Latency.Distribution = "fixed"
+		}
+	}
+}
+
+// Validate checks the config for errors.
+func (c *Config) Validate() error {
+	if len(c.Routes) == 0 {
+		return fmt.Errorf("at least one route is required")
+	}
+	if err := c.RateLimit.validate(); err != nil {
+		return fmt.Errorf("rate_limit: %w", err)
+	}
+	for i, r := range c.Routes {
+		if !strings.HasPrefix(r.Path, "/") {
+			return fmt.Errorf("routes[%d]: path must start with / (got %q)", i, r.Path)
+		}
+		if r.ErrorRate < 0 || r.ErrorRate > 1 {
+			return fmt.Errorf("routes[%d]: error_rate must be between 0 and 1", i)
+		}
+		l := r.Latency
+		switch l.Distribution {
+		case "fixed", "normal":
+		case "long_tail":
+			if l.P99.Duration < l.Mean.Duration {
+				return fmt.Errorf("routes[%d]: long_tail latency needs p99 >= mean", i)
+			}
+		default:
+			return fmt.Errorf("routes[%d]: latency.distribution must be fixed, normal or long_tail (got %q)", i, l.Distribution)
+		}
+		if l.Mean.Duration < 0 || l.StdDev.Duration < 0 {
+			return fmt.Errorf("routes[%d]: latency must not be negative", i)
+		}
+		if err := r.RateLimit.validate(); err != nil {
+			return fmt.Errorf("routes[%d].rate_limit: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (rl *RateLimit) validate() error {
+	if rl != nil && rl.RPS <= 0 {
+		return fmt.Errorf("rps must be > 0")
+	}
+	return nil
+}
+
+// Server is an http.Handler serving a mock API. It is safe for concurrent use.
+type Server struct {
+	routes []*route
+	limit  *bucket
+
+	mu  sync.Mutex // guards rng
+	rng *rand.Rand
+}
+
+type route struct {
+	Route
+	segments []string
+	limit    *bucket
+}
+
+// New creates a Server from cfg, applying defaults and validating it.
+func New(cfg Config) (*Server, error) {
+	cfg.Routes = append([]Route(nil), cfg.Routes...)
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s := &Server{
+		limit: newBucket(cfg.RateLimit),
+		rng:   rand.New(rand.NewSource(seed)),
+	}
+	for _, r := range cfg.Routes {
+		s.routes = append(s.routes, &route{
+			Route:    r,
+			segments: strings.Split(strings.Trim(r.Path, "/"), "/"),
+			limit:    newBucket(r.RateLimit),
+		})
+	}
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, params, methodMatched := s.match(r.Method, r.URL.Path)
+	if rt == nil {
+		if methodMatched {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if !take(s.limit, rt.limit) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+
+	delay, fail := s.sample(rt)
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	vars := make(map[string]string, len(params))
+	for k, v := range params {
+		vars["path."+k] = v
+	}
+	for k, v := range r.URL.Query() {
+		vars["query."+k] = v[0]
+	}
+	gen := data.NewGenerator(vars)
+
+	for k, v := range rt.Headers {
+		w.Header().Set(k, gen.Generate(v))
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if fail {
+		body := rt.ErrorBody
+		if body == "" {
+			body = fmt.Sprintf(`{"error":"injected failure","status":%d}`, rt.ErrorStatus)
+		}
+		w.WriteHeader(rt.ErrorStatus)
+		fmt.Fprint(w, gen.Generate(body))
+		return
+	}
+	w.WriteHeader(rt.Status)
+	fmt.Fprint(w, gen.Generate(rt.Body))
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error":%q}`+"\n", msg)
+}
+
+// match finds the first route for method and path. methodMatched reports
+// whether some route matched the path under a different method.
+func (s *Server) match(method, path string) (rt *route, params map[string]string, methodMatched bool) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for _, r := range s.routes {
+		p, ok := matchPath(r.segments, segs)
+		if !ok {
+			continue
+		}
+		if r.Method != "" && r.Method != method {
+			methodMatched = true
+			continue
+		}
+		return r, p, false
+	}
+	return nil, nil, methodMatched
+}
+
+func matchPath(pattern, segs []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, p := range pattern {
+		if p == "*" && i == len(pattern)-1 {
+			return params, true
+		}
+		if i >= len(segs) {
+			return nil, false
+		}
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			params[p[1:len(p)-1]] = segs[i]
+			continue
+		}
+		if p != segs[i] {
+			return nil, false
+		}
+	}
+	return params, len(segs) == len(pattern)
+}
+
+// sample draws the route's latency and whether this request fails.
+func (s *Server) sample(rt *route) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := rt.Latency
+	var d time.Duration
+	switch l.Distribution {
+	case "normal":
+		d = l.Mean.Duration + time.Duration(s.rng.NormFloat64()*float64(l.StdDev.Duration))
+	case "long_tail":
+		// Log-normal: the median is Mean, and P99 sits 2.326 sigmas above it.
+		if l.Mean.Duration > 0 {
+			sigma := 0.0
+			if l.P99.Duration > l.Mean.Duration {
+				sigma = math.Log(float64(l.P99.Duration)/float64(l.Mean.Duration)) / 2.326
+			}
+			d = time.Duration(float64(l.Mean.Duration) * math.Exp(sigma*s.rng.NormFloat64()))
+		}
+	default:
+		d = l.Mean.Duration
+	}
+	return max(d, 0), rt.ErrorRate > 0 && s.rng.Float64() < rt.ErrorRate
+}
+
+// bucket is a token bucket that starts full. A nil bucket allows everything.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rl *RateLimit) *bucket {
+	if rl == nil {
+		return nil
+	}
+	burst := float64(rl.Burst)
+	if burst <= 0 {
+		burst = max(1, math.Floor(rl.RPS))
+	}
+	return &bucket{rate: rl.RPS, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take removes a token from each bucket if every one of them has a token,
+// so a request that one limit rejects costs the others nothing.
+func take(buckets ...*bucket) bool {
+	now := time.Now()
+	held := make([]*bucket, 0, len(buckets))
+	defer func() {
+		for _, b := range held {
+			b.mu.Unlock()
+		}
+	}()
+	for _, b := range buckets {
+		if b == nil {
+			continue
+		}
+		b.mu.Lock()
+		held = append(held, b)
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens < 1 {
+			return false
+		}
+	}
+	for _, b := range held {
+		b.tokens--
+	}
+	return true
+}
+
+// String summarizes the route for startup output.
+func (r Route) String() string {
+	method := r.Method
+	if method == "" {
+		method = "*"
+	}
+	var extras []string
+	if r.Latency.Mean.Duration > 0 {
+		extras = append(extras, r.Latency.Distribution+" "+r.Latency.Mean.Duration.String())
+	}
+	if r.ErrorRate > 0 {
+		extras = append(extras, strconv.FormatFloat(r.ErrorRate*100, 'g', -1, 64)+"% errors")
+	}
+	if r.RateLimit != nil {
+		extras = append(extras, strconv.FormatFloat(r.RateLimit.RPS, 'g', -1, 64)+" rps limit")
+	}
+	s := fmt.Sprintf("%-6s %s -> %d", method, r.Path, r.Status)
+	if len(extras) > 0 {
+		s += " (" + strings.Join(extras, ", ") + ")"
+	}
+	return s
+}
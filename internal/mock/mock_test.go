@@ -0,0 +1,274 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+func get(t *testing.T, h http.Handler, method, path string) (int, string, http.Header) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w.Code, w.Body.String(), w.Header()
+}
+
+func TestServer_Routes(t *testing.T) {
+	s, err := New(Config{Routes: []Route{
+		{Method: "get", Path: "/users/{id}", Body: `{"id":${path.id},"q":"${query.q}"}`, Headers: map[string]string{"X-User": "${path.id}"}},
+		{Method: "POST", Path: "/users", Status: 201, Body: `{"id":"${random.uuid}"}`},
+		{Path: "/files/*", Body: "file"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, body, h := get(t, s, "GET", "/users/42?q=x")
+	if code != 200 || body != `{"id":42,"q":"x"}` || h.Get("X-User") != "42" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("GET /users/42 = %d %s %v", code, body, h)
+	}
+	if code, body, _ := get(t, s, "POST", "/users"); code != 201 || len(body) != len(`{"id":""}`)+36 {
+		t.Errorf("POST /users = %d %s", code, body)
+	}
+	if code, _, _ := get(t, s, "DELETE", "/files/a/b/c"); code != 200 {
+		t.Errorf("wildcard route = %d, want 200", code)
+	}
+	if code, _, _ := get(t, s, "DELETE", "/users/42"); code != http.StatusMethodNotAllowed {
+		t.Errorf("wrong method = %d, want 405", code)
+	}
+	if code, _, _ := get(t, s, "GET", "/nope"); code != http.StatusNotFound {
+		t.Errorf("unknown path = %d, want 404", code)
+	}
+	if code, _, _ := get(t, s, "GET", "/users/42/extra"); code != http.StatusNotFound {
+		t.Errorf("longer path = %d, want 404", code)
+	}
+}
+
+func TestServer_ErrorInjection(t *testing.T) {
+	s, err := New(Config{Seed: 1, Routes: []Route{
+		{Path: "/flaky", ErrorRate: 0.3, ErrorStatus: 503},
+		{Path: "/broken", ErrorRate: 1, ErrorBody: "down"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := 0
+	for range 1000 {
+		if code, _, _ := get(t, s, "GET", "/flaky"); code == 503 {
+			failed++
+		}
+	}
+	if failed < 250 || failed > 350 {
+		t.Errorf("%d/1000 requests failed, want about 300", failed)
+	}
+	if code, body, _ := get(t, s, "GET", "/broken"); code != 500 || body != "down" {
+		t.Errorf("GET /broken = %d %q", code, body)
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	s, err := New(Config{Routes: []Route{
+		{Path: "/limited", RateLimit: &RateLimit{RPS: 5, Burst: 3}},
+		{Path: "/free"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []int
+	for range 5 {
+		code, _, h := get(t, s, "GET", "/limited")
+		codes = append(codes, code)
+		if code == 429 && h.Get("Retry-After") == "" {
+			t.Error("429 without Retry-After")
+		}
+	}
+	want := []int{200, 200, 200, 429, 429}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Fatalf("codes = %v, want %v", codes, want)
+		}
+	}
+	if code, _, _ := get(t, s, "GET", "/free"); code != 200 {
+		t.Errorf("unlimited route = %d", code)
+	}
+	time.Sleep(250 * time.Millisecond) // one token at 5 rps
+	if code, _, _ := get(t, s, "GET", "/limited"); code != 200 {
+		t.Errorf("after refill = %d, want 200", code)
+	}
+}
+
+func TestServer_GlobalRateLimit(t *testing.T) {
+	s, err := New(Config{RateLimit: &RateLimit{RPS: 1}, Routes: []Route{{Path: "/a"}, {Path: "/b"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _, _ := get(t, s, "GET", "/a"); code != 200 {
+		t.Errorf("first = %d", code)
+	}
+	if code, _, _ := get(t, s, "GET", "/b"); code != 429 {
+		t.Errorf("second route shares the server limit, got %d", code)
+	}
+}
+
+func TestServer_RouteLimitKeepsGlobalTokens(t *testing.T) {
+	s, err := New(Config{RateLimit: &RateLimit{RPS: 1, Burst: 2}, Routes: []Route{
+		{Path: "/limited", RateLimit: &RateLimit{RPS: 0.1}},
+		{Path: "/other"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []int
+	for _, path := range []string{"/limited", "/limited", "/limited", "/other"} {
+		code, _, _ := get(t, s, "GET", path)
+		codes = append(codes, code)
+	}
+	if want := []int{200, 429, 429, 200}; !slices.Equal(codes, want) {
+		t.Errorf("codes = %v, want %v; requests the route limit rejects must not use the server's tokens", codes, want)
+	}
+}
+
+func TestServer_Latency(t *testing.T) {
+	ms := func(n int) config.Duration { return config.Duration{Duration: time.Duration(n) * time.Millisecond} }
+	tests := []struct {
+		name     string
+		latency  Latency
+		min, max time.Duration
+	}{
+		{"fixed", Latency{Mean: ms(30)}, 30 * time.Millisecond, 30 * time.Millisecond},
+		{"normal", Latency{Distribution: "normal", Mean: ms(30), StdDev: ms(5)}, 0, 60 * time.Millisecond},
+		{"long_tail", Latency{Distribution: "long_tail", Mean: ms(10), P99: ms(100)}, 0, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(Config{Seed: 7, Routes: []Route{{Path: "/", Latency: tt.latency}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sum time.Duration
+			var over int
+			const n = 2000
+			for range n {
+				d, _ := s.sample(s.routes[0])
+				if d < tt.min || d > tt.max {
+					t.Fatalf("sample %s outside [%s, %s]", d, tt.min, tt.max)
+				}
+				if d > tt.latency.P99.Duration && tt.latency.P99.Duration > 0 {
+					over++
+				}
+				sum += d
+			}
+			if tt.name == "long_tail" {
+				if over < 5 || over > 50 {
+					t.Errorf("%d/%d samples above p99, want about 1%%", over, n)
+				}
+				return
+			}
+			if avg := sum / n; avg < 28*time.Millisecond || avg > 32*time.Millisecond {
+				t.Errorf("mean %s, want about 30ms", avg)
+			}
+		})
+	}
+}
+
+func TestServer_LatencyDelaysResponse(t *testing.T) {
+	s, err := New(Config{Routes: []Route{{Path: "/slow", Latency: Latency{Mean: config.Duration{Duration: 50 * time.Millisecond}}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("responded after %s, want >= 50ms", d)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"no routes", Config{}, "at least one route"},
+		{"bad path", Config{Routes: []Route{{Path: "users"}}}, "must start with /"},
+		{"bad rate", Config{Routes: []Route{{Path: "/", ErrorRate: 2}}}, "error_rate"},
+		{"bad distribution", Config{Routes: []Route{{Path: "/", Latency: Latency{Distribution: "pareto"}}}}, "distribution"},
+		{"long tail p99", Config{Routes: []Route{{Path: "/", Latency: Latency{Distribution: "long_tail", Mean: config.Duration{Duration: time.Second}}}}}, "p99"},
+		{"bad limit", Config{Routes: []Route{{Path: "/", RateLimit: &RateLimit{}}}}, "rps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mock.yaml")
+	yaml := `listen: ":9999"
+routes:
+  - method: GET
+    path: /orders/{id}
+    body: '{"id": ${path.id}}'
+    latency: {distribution: normal, mean: 20ms, stddev: 5ms}
+    error_rate: 0.05
+    rate_limit: {rps: 100}
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Listen != ":9999" || len(cfg.Routes) != 1 {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	r := cfg.Routes[0]
+	if r.Latency.Mean.Duration != 20*time.Millisecond || r.ErrorRate != 0.05 || r.RateLimit.RPS != 100 {
+		t.Errorf("route = %+v", r)
+	}
+	if _, err := New(*cfg); err != nil {
+		t.Errorf("New: %v", err)
+	}
+}
+
+func TestLoad_UnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mock.yaml")
+	if err := os.WriteFile(path, []byte("routes:\n  - path: /a\n    stauts: 201\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), `mock.yaml:3:5: unknown key "stauts" in routes[0] (did you mean "status"?)`) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	s, err := New(*Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _, _ := get(t, s, "GET", "/health"); code != 200 {
+		t.Errorf("GET /health = %d", code)
+	}
+}
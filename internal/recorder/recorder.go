@@ -0,0 +1,120 @@
+// Package recorder implements a reverse proxy that records the requests it
+// forwards so they can be turned into a test config.
+package recorder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/importer"
+)
+
+// maxBody is the largest request body recorded. Larger bodies are still
+// forwarded, but the request is recorded without one.
+const maxBody = 1 << 20
+
+// Recorder is an http.Handler that forwards every request to a target and
+// keeps a copy of it. It is safe for concurrent use.
+type Recorder struct {
+	proxy *httputil.ReverseProxy
+
+	// OnRecord, if set, is called after each request is recorded.
+	OnRecord func(importer.Recorded)
+
+	mu   sync.Mutex
+	reqs []importer.Recorded
+}
+
+// New creates a Recorder that forwards to target (scheme://host[/base]).
+func New(target string) (*Recorder, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid target %q (expected scheme://host[:port])", target)
+	}
+	return &Recorder{
+		proxy: &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetURL(u)
+				pr.SetXForwarded()
+			},
+		},
+	}, nil
+}
+
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	header := r.Header.Clone()
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil {
+			http.Error(w, "reading request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		if len(body) > maxBody {
+			body = nil
+		}
+	}
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	rec.proxy.ServeHTTP(sw, r)
+
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	entry := importer.Recorded{
+		Client:   client,
+		Start:    start,
+		Duration: time.Since(start),
+		Method:   r.Method,
+		Path:     r.URL.RequestURI(),
+		Header:   header,
+		Body:     string(body),
+		Status:   sw.status,
+	}
+	rec.mu.Lock()
+	rec.reqs = append(rec.reqs, entry)
+	rec.mu.Unlock()
+	if rec.OnRecord != nil {
+		rec.OnRecord(entry)
+	}
+}
+
+// Requests returns a copy of everything recorded so far.
+func (rec *Recorder) Requests() []importer.Recorded {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]importer.Recorded(nil), rec.reqs...)
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush lets streamed responses through the proxy unbuffered.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
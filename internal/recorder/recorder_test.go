@@ -0,0 +1,78 @@
+package recorder
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var gotBody, gotPath string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody, gotPath = string(b), r.URL.RequestURI()
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "ok")
+	}))
+	defer target.Close()
+
+	rec, err := New(target.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httptest.NewServer(rec)
+	defer proxy.Close()
+
+	req, _ := http.NewRequest("POST", proxy.URL+"/orders?x=1", strings.NewReader(`{"a":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated || string(body) != "ok" {
+		t.Errorf("proxied response = %d %q", resp.StatusCode, body)
+	}
+	if gotBody != `{"a":1}` || gotPath != "/api/orders?x=1" {
+		t.Errorf("target saw %s with body %q", gotPath, gotBody)
+	}
+
+	reqs := rec.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("recorded %d requests, want 1", len(reqs))
+	}
+	r := reqs[0]
+	if r.Method != "POST" || r.Path != "/orders?x=1" || r.Body != `{"a":1}` || r.Status != http.StatusCreated {
+		t.Errorf("recorded %+v", r)
+	}
+	if r.Header.Get("Content-Type") != "application/json" || r.Client != "127.0.0.1" {
+		t.Errorf("recorded header %v from %q", r.Header, r.Client)
+	}
+}
+
+func TestRecorder_TargetDown(t *testing.T) {
+	rec, err := New("http://127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.proxy.ErrorLog = log.New(io.Discard, "", 0)
+	w := httptest.NewRecorder()
+	rec.ServeHTTP(w, httptest.NewRequest("GET", "/x", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", w.Code)
+	}
+	if reqs := rec.Requests(); len(reqs) != 1 || reqs[0].Status != http.StatusBadGateway {
+		t.Errorf("recorded %+v", reqs)
+	}
+}
+
+func TestNew_InvalidTarget(t *testing.T) {
+	if _, err := New("localhost:8080"); err == nil {
+		t.Error("expected error for target without scheme")
+	}
+}
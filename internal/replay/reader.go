@@ -0,0 +1,258 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one request read from an access log.
+type Entry struct {
+	Time    time.Time // zero when the line has no usable timestamp
+	Method  string
+	Path    string // path and query, e.g. /users/42?page=2
+	Status  int    // logged response status, 0 if unknown
+	Headers map[string]string
+}
+
+// Formats lists the supported log formats. "combined" also accepts Apache
+// common log format lines, which simply lack the referer and user agent.
+var Formats = []string{"auto", "combined", "clf", "json"}
+
+// clfRegex matches common and combined log format lines:
+//
+//	host ident user [time] "request" status bytes ["referer" "user-agent"]
+var clfRegex = regexp.MustCompile(`^\S+ \S+ \S+ \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}|-) \S+(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// Candidate JSON keys, in order of preference, for each field.
+var (
+	jsonTimeKeys      = []string{"time", "timestamp", "@timestamp", "time_iso8601", "time_local", "ts", "msec"}
+	jsonMethodKeys    = []string{"method", "request_method", "verb"}
+	jsonPathKeys      = []string{"path", "request_uri", "uri", "url"}
+	jsonStatusKeys    = []string{"status", "status_code", "response_code"}
+	jsonUserAgentKeys = []string{"user_agent", "http_user_agent", "userAgent"}
+	jsonRefererKeys   = []string{"referer", "http_referer", "referrer"}
+)
+
+// Reader reads entries from an access log one line at a time. Lines that
+// can't be parsed are skipped and counted.
+type Reader struct {
+	sc        *bufio.Scanner
+	format    string
+	timeField string
+	line      int
+	skipped   int
+}
+
+// NewReader returns a Reader for the given format. timeField names the JSON
+// key holding the timestamp; when empty, common keys are tried.
+func NewReader(r io.Reader, format, timeField string) (*Reader, error) {
+	if format == "" {
+		format = "auto"
+	}
+	valid := false
+	for _, f := range Formats {
+		valid = valid || f == format
+	}
+	if !valid {
+		return nil, fmt.Errorf("unknown log format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &Reader{sc: sc, format: format, timeField: timeField}, nil
+}
+
+// Skipped returns how many non-empty lines could not be parsed so far.
+func (r *Reader) Skipped() int {
+	return r.skipped
+}
+
+// Next returns the next entry, or io.EOF at the end of the log.
+func (r *Reader) Next() (Entry, error) {
+	for r.sc.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.sc.Text())
+		if line == "" {
+			continue
+		}
+		if r.format == "auto" {
+			r.format = "combined"
+			if strings.HasPrefix(line, "{") {
+				r.format = "json"
+			}
+		}
+
+		var e Entry
+		var ok bool
+		if r.format == "json" {
+			e, ok = r.parseJSON(line)
+		} else {
+			e, ok = parseCLF(line)
+		}
+		if !ok {
+			r.skipped++
+			continue
+		}
+		return e, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return Entry{}, fmt.Errorf("reading log line %d: %w", r.line+1, err)
+	}
+	return Entry{}, io.EOF
+}
+
+func parseCLF(line string) (Entry, bool) {
+	m := clfRegex.FindStringSubmatch(line)
+	if m == nil {
+		return Entry{}, false
+	}
+	method, path, ok := parseRequestLine(m[2])
+	if !ok {
+		return Entry{}, false
+	}
+	e := Entry{Method: method, Path: path}
+	e.Time, _ = time.Parse(clfTimeLayout, m[1])
+	e.Status, _ = strconv.Atoi(m[3])
+	if ref := m[4]; ref != "" && ref != "-" {
+		e.setHeader("Referer", ref)
+	}
+	if ua := m[5]; ua != "" && ua != "-" {
+		e.setHeader("User-Agent", ua)
+	}
+	return e, true
+}
+
+func (r *Reader) parseJSON(line string) (Entry, bool) {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return Entry{}, false
+	}
+
+	var e Entry
+	if req := stringField(obj, []string{"request"}); req != "" {
+		e.Method, e.Path, _ = parseRequestLine(req)
+	}
+	if m := stringField(obj, jsonMethodKeys); m != "" {
+		e.Method = strings.ToUpper(m)
+	}
+	if p := stringField(obj, jsonPathKeys); p != "" {
+		e.Path = pathOf(p)
+	}
+	if e.Method == "" {
+		e.Method = "GET"
+	}
+	if e.Path == "" {
+		return Entry{}, false
+	}
+
+	timeKeys := jsonTimeKeys
+	if r.timeField != "" {
+		timeKeys = []string{r.timeField}
+	}
+	for _, k := range timeKeys {
+		if v, ok := obj[k]; ok {
+			e.Time = parseTime(v)
+			break
+		}
+	}
+	for _, k := range jsonStatusKeys {
+		if v, ok := obj[k]; ok {
+			e.Status, _ = strconv.Atoi(fmt.Sprint(v))
+			break
+		}
+	}
+	if ua := stringField(obj, jsonUserAgentKeys); ua != "" && ua != "-" {
+		e.setHeader("User-Agent", ua)
+	}
+	if ref := stringField(obj, jsonRefererKeys); ref != "" && ref != "-" {
+		e.setHeader("Referer", ref)
+	}
+	return e, true
+}
+
+func (e *Entry) setHeader(k, v string) {
+	if e.Headers == nil {
+		e.Headers = make(map[string]string)
+	}
+	e.Headers[k] = v
+}
+
+// parseRequestLine splits `GET /path HTTP/1.1` into method and path.
+func parseRequestLine(req string) (method, path string, ok bool) {
+	parts := strings.Fields(req)
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	return strings.ToUpper(parts[0]), pathOf(parts[1]), true
+}
+
+// pathOf returns the path and query of an absolute or relative URL.
+func pathOf(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.IsAbs() {
+		return u.RequestURI()
+	}
+	return raw
+}
+
+func stringField(obj map[string]any, keys []string) string {
+	for _, k := range keys {
+		if s, ok := obj[k].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// timeLayouts are tried in order for string timestamps.
+var timeLayouts = []string{time.RFC3339Nano, clfTimeLayout, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"}
+
+// parseTime accepts RFC 3339, CLF and plain date-time strings, and Unix
+// epoch seconds (e.g. nginx $msec) as numbers or numeric strings.
+func parseTime(v any) time.Time {
+	switch t := v.(type) {
+	case float64:
+		return epoch(t)
+	case string:
+		for _, layout := range timeLayouts {
+			if ts, err := time.Parse(layout, t); err == nil {
+				return ts
+			}
+		}
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return epoch(f)
+		}
+	}
+	return time.Time{}
+}
+
+func epoch(sec float64) time.Time {
+	whole, frac := math.Modf(sec)
+	return time.Unix(int64(whole), int64(frac*1e9))
+}
+
+var (
+	idSegment   = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+	queryString = regexp.MustCompile(`\?.*$`)
+)
+
+// EndpointName groups requests for reporting, e.g. "GET /users/{id}".
+// The query string is dropped and numeric, UUID and long hex path segments
+// are replaced with {id} so per-endpoint stats stay readable.
+func EndpointName(method, path string) string {
+	segs := strings.Split(queryString.ReplaceAllString(path, ""), "/")
+	for i, s := range segs {
+		if idSegment.MatchString(s) {
+			segs[i] = "{id}"
+		}
+	}
+	return method + " " + strings.Join(segs, "/")
+}
@@ -0,0 +1,122 @@
+package replay
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, log, format, timeField string) ([]Entry, *Reader) {
+	t.Helper()
+	r, err := NewReader(strings.NewReader(log), format, timeField)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var entries []Entry
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return entries, r
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+func TestReader_Combined(t *testing.T) {
+	log := `203.0.113.9 - - [10/Oct/2024:13:55:36 +0000] "GET /users/42?x=1 HTTP/1.1" 200 512 "https://example.com/" "curl/8.0"
+203.0.113.9 - bob [10/Oct/2024:13:55:38 +0000] "POST /orders HTTP/1.1" 201 64 "-" "Mozilla/5.0"
+`
+	entries, _ := readAll(t, log, "combined", "")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Method != "GET" || e.Path != "/users/42?x=1" || e.Status != 200 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.Headers["User-Agent"] != "curl/8.0" || e.Headers["Referer"] != "https://example.com/" {
+		t.Errorf("expected referer and user agent headers, got %v", e.Headers)
+	}
+	if gap := entries[1].Time.Sub(e.Time); gap != 2*time.Second {
+		t.Errorf("expected 2s between entries, got %v", gap)
+	}
+	if _, ok := entries[1].Headers["Referer"]; ok {
+		t.Error("expected \"-\" referer to be dropped")
+	}
+}
+
+func TestReader_CommonLogFormat(t *testing.T) {
+	log := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`
+	entries, _ := readAll(t, log, "auto", "")
+	if len(entries) != 1 || entries[0].Path != "/apache_pb.gif" || entries[0].Time.IsZero() {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if entries[0].Headers != nil {
+		t.Errorf("expected no headers for CLF, got %v", entries[0].Headers)
+	}
+}
+
+func TestReader_JSON(t *testing.T) {
+	log := `{"time":"2024-10-10T13:55:36.5Z","method":"get","path":"/a","status":200,"user_agent":"k6"}
+{"request":"DELETE /b/7 HTTP/2.0","status":"204","@timestamp":"2024-10-10T13:55:37Z"}
+{"ts":1728568538.25,"request_method":"PUT","url":"https://api.example.com/c?d=1"}
+`
+	entries, _ := readAll(t, log, "auto", "")
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Method != "GET" || entries[0].Headers["User-Agent"] != "k6" {
+		t.Errorf("entry 0: %+v", entries[0])
+	}
+	if entries[1].Method != "DELETE" || entries[1].Path != "/b/7" || entries[1].Status != 204 {
+		t.Errorf("entry 1: %+v", entries[1])
+	}
+	if entries[2].Path != "/c?d=1" || entries[2].Time.UnixMilli() != 1728568538250 {
+		t.Errorf("entry 2: %+v", entries[2])
+	}
+}
+
+func TestReader_JSONTimeField(t *testing.T) {
+	log := `{"when":"2024-10-10 13:55:36","time":"ignored","path":"/a"}`
+	entries, _ := readAll(t, log, "json", "when")
+	if len(entries) != 1 || entries[0].Time.IsZero() {
+		t.Fatalf("expected timestamp from custom field, got %+v", entries)
+	}
+}
+
+func TestReader_SkipsUnparseableLines(t *testing.T) {
+	log := `not a log line
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /ok HTTP/1.0" 200 1
+
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "garbage" 200 1
+`
+	entries, r := readAll(t, log, "clf", "")
+	if len(entries) != 1 || r.Skipped() != 2 {
+		t.Errorf("expected 1 entry and 2 skipped, got %d and %d", len(entries), r.Skipped())
+	}
+}
+
+func TestNewReader_UnknownFormat(t *testing.T) {
+	if _, err := NewReader(strings.NewReader(""), "w3c", ""); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestEndpointName(t *testing.T) {
+	cases := map[string]string{
+		"/users/42":               "GET /users/{id}",
+		"/users/42/orders?page=2": "GET /users/{id}/orders",
+		"/o/3fa85f64-5717-4562-b3fc-2c963f66afa6":         "GET /o/{id}",
+		"/blobs/a94a8fe5ccb19ba61c4c0873d391e987982fbbd3": "GET /blobs/{id}",
+		"/v2/health": "GET /v2/health",
+	}
+	for path, want := range cases {
+		if got := EndpointName("GET", path); got != want {
+			t.Errorf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+}
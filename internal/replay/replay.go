@@ -0,0 +1,198 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+	"github.com/jvreagan/perf-test/internal/httpclient"
+	"github.com/jvreagan/perf-test/internal/metrics"
+	"github.com/jvreagan/perf-test/internal/reporter"
+	"github.com/jvreagan/perf-test/internal/worker"
+)
+
+// Options controls how a log is replayed.
+type Options struct {
+	BaseURL     string            // scheme and host to send requests to
+	Speedup     float64           // divides the original gaps between requests; default 1
+	Rate        float64           // requests/sec for entries without a timestamp; default 10
+	Concurrency int               // max requests in flight; default 100
+	MatchStatus bool              // fail requests whose status differs from the logged one
+	Headers     map[string]string // added to every request
+	HTTP        config.HTTPConfig
+	Interval    time.Duration // periodic stats interval; default 5s
+	OutputFile  string        // optional JSON results file
+}
+
+func (o *Options) applyDefaults() {
+	if o.Speedup <= 0 {
+		o.Speedup = 1
+	}
+	if o.Rate <= 0 {
+		o.Rate = 10
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 100
+	}
+	if o.Interval <= 0 {
+		o.Interval = 5 * time.Second
+	}
+	if o.HTTP.Timeout.Duration == 0 {
+		o.HTTP.Timeout = config.Duration{Duration: 30 * time.Second}
+	}
+}
+
+// Run re-issues every entry from src against opts.BaseURL, preserving the
+// order and (scaled) spacing of the original requests. It writes periodic and
+// summary output to w and returns the final stats. A non-nil error indicates
+// failed requests.
+func Run(ctx context.Context, src *Reader, opts Options, w io.Writer) (*metrics.Stats, error) {
+	opts.applyDefaults()
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	client, err := httpclient.New(opts.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	collector := metrics.NewCollector(startTime)
+	exec := worker.NewExecutor(nil, data.NewGenerator(nil), client)
+
+	reportDone := make(chan struct{})
+	stopReport := make(chan struct{})
+	go func() {
+		defer close(reportDone)
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reporter.Print(w, collector.Snapshot())
+			case <-stopReport:
+				return
+			}
+		}
+	}()
+
+	sem := make(chan struct{}, opts.Concurrency)
+	var inFlight sync.WaitGroup
+	var active atomic.Int64
+
+	var readErr error
+	var due time.Duration // when the current entry should be sent, relative to start
+	var lastTime time.Time
+	first := true
+dispatch:
+	for {
+		e, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+
+		switch {
+		case first:
+		case !e.Time.IsZero() && !lastTime.IsZero():
+			// Out-of-order lines (logs are written at completion) are sent at once.
+			if gap := e.Time.Sub(lastTime); gap > 0 {
+				due += time.Duration(float64(gap) / opts.Speedup)
+			}
+		default:
+			due += time.Duration(float64(time.Second) / opts.Rate)
+		}
+		first = false
+		if !e.Time.IsZero() && e.Time.After(lastTime) {
+			lastTime = e.Time
+		}
+
+		if wait := time.Until(startTime.Add(due)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+
+		ep := endpointFor(e, baseURL, opts)
+		inFlight.Add(1)
+		collector.SetActiveVUs(int(active.Add(1)))
+		go func() {
+			defer inFlight.Done()
+			defer func() {
+				collector.SetActiveVUs(int(active.Add(-1)))
+				<-sem
+			}()
+			result := exec.Execute(ctx, ep)
+			if result.Error != nil && ctx.Err() != nil {
+				return // shutdown artifact
+			}
+			if result.Error == nil && !opts.MatchStatus && result.StatusCode >= 500 {
+				result.Success = false
+				result.Error = fmt.Errorf("server error: status %d", result.StatusCode)
+			}
+			collector.Record(result)
+		}()
+	}
+	inFlight.Wait()
+	close(stopReport)
+	<-reportDone
+
+	finalStats := collector.Snapshot()
+	reporter.Summary(w, finalStats)
+	if n := src.Skipped(); n > 0 {
+		fmt.Fprintf(w, "Skipped %d unparseable log lines\n", n)
+	}
+
+	if opts.OutputFile != "" {
+		if err := reporter.WriteJSON(opts.OutputFile, finalStats); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to write results file: %v\n", err)
+		} else {
+			fmt.Fprintf(w, "Results written to: %s\n", opts.OutputFile)
+		}
+	}
+
+	if readErr != nil {
+		return finalStats, readErr
+	}
+	if finalStats.ErrorCount > 0 {
+		return finalStats, fmt.Errorf("replay completed with %d errors out of %d requests", finalStats.ErrorCount, finalStats.TotalRequests)
+	}
+	return finalStats, nil
+}
+
+// endpointFor builds the request for a log entry. Logs don't record request
+// bodies, so requests are replayed without one.
+func endpointFor(e Entry, baseURL string, opts Options) config.Endpoint {
+	headers := make(map[string]string, len(e.Headers)+len(opts.Headers))
+	for k, v := range e.Headers {
+		headers[k] = v
+	}
+	for k, v := range opts.Headers {
+		headers[k] = v
+	}
+	ep := config.Endpoint{
+		Name:    EndpointName(e.Method, e.Path),
+		Method:  e.Method,
+		URL:     baseURL + e.Path,
+		Headers: headers,
+	}
+	if opts.MatchStatus {
+		ep.Expect.Status = e.Status
+	}
+	return ep
+}
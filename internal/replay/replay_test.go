@@ -0,0 +1,133 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorded struct {
+	path string
+	ua   string
+	at   time.Time
+}
+
+func recordingServer(status int) (*httptest.Server, func() []recorded) {
+	var mu sync.Mutex
+	var reqs []recorded
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqs = append(reqs, recorded{path: r.URL.RequestURI(), ua: r.UserAgent(), at: time.Now()})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	return srv, func() []recorded {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recorded(nil), reqs...)
+	}
+}
+
+func reader(t *testing.T, log string) *Reader {
+	t.Helper()
+	r, err := NewReader(strings.NewReader(log), "auto", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRun_ReplaysWithScaledTiming(t *testing.T) {
+	srv, reqs := recordingServer(200)
+	defer srv.Close()
+
+	// 2s apart in the log; replayed 10x faster => ~200ms apart.
+	log := `1.2.3.4 - - [10/Oct/2024:13:55:36 +0000] "GET /first HTTP/1.1" 200 1 "-" "agent/1"
+1.2.3.4 - - [10/Oct/2024:13:55:38 +0000] "GET /users/1 HTTP/1.1" 200 1 "-" "agent/1"
+1.2.3.4 - - [10/Oct/2024:13:55:38 +0000] "GET /users/2 HTTP/1.1" 200 1 "-" "agent/1"
+`
+	stats, err := Run(context.Background(), reader(t, log), Options{BaseURL: srv.URL + "/", Speedup: 10}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.TotalRequests != 3 {
+		t.Fatalf("expected 3 requests, got %d", stats.TotalRequests)
+	}
+	if _, ok := stats.PerEndpoint["GET /users/{id}"]; !ok {
+		t.Errorf("expected requests grouped by normalized path, got %v", stats.PerEndpoint)
+	}
+
+	got := reqs()
+	if got[0].path != "/first" || got[0].ua != "agent/1" {
+		t.Errorf("expected first request /first with logged user agent, got %+v", got[0])
+	}
+	if gap := got[1].at.Sub(got[0].at); gap < 150*time.Millisecond || gap > 600*time.Millisecond {
+		t.Errorf("expected ~200ms gap with 10x speedup, got %v", gap)
+	}
+}
+
+func TestRun_RateForUntimedEntries(t *testing.T) {
+	srv, reqs := recordingServer(200)
+	defer srv.Close()
+
+	log := `{"path":"/a"}
+{"path":"/b"}
+{"path":"/c"}
+`
+	start := time.Now()
+	if _, err := Run(context.Background(), reader(t, log), Options{BaseURL: srv.URL, Rate: 20}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected entries spaced at 20/s (~100ms total), took %v", elapsed)
+	}
+	if len(reqs()) != 3 {
+		t.Errorf("expected 3 requests, got %d", len(reqs()))
+	}
+}
+
+func TestRun_ServerErrorsFail(t *testing.T) {
+	srv, _ := recordingServer(503)
+	defer srv.Close()
+
+	log := `{"path":"/a","status":200}`
+	stats, err := Run(context.Background(), reader(t, log), Options{BaseURL: srv.URL}, io.Discard)
+	if err == nil || stats.ErrorCount != 1 {
+		t.Errorf("expected 5xx to count as an error, got err=%v errors=%d", err, stats.ErrorCount)
+	}
+}
+
+func TestRun_MatchStatus(t *testing.T) {
+	srv, _ := recordingServer(404)
+	defer srv.Close()
+
+	log := `{"path":"/gone","status":404}
+{"path":"/here","status":200}
+`
+	stats, _ := Run(context.Background(), reader(t, log), Options{BaseURL: srv.URL, MatchStatus: true}, io.Discard)
+	if stats.SuccessCount != 1 || stats.ErrorCount != 1 {
+		t.Errorf("expected 1 match and 1 mismatch, got %d/%d", stats.SuccessCount, stats.ErrorCount)
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	srv, reqs := recordingServer(200)
+	defer srv.Close()
+
+	log := `1.2.3.4 - - [10/Oct/2024:13:55:36 +0000] "GET /now HTTP/1.1" 200 1
+1.2.3.4 - - [10/Oct/2024:14:55:36 +0000] "GET /in-an-hour HTTP/1.1" 200 1
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := Run(ctx, reader(t, log), Options{BaseURL: srv.URL}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(reqs()); n != 1 {
+		t.Errorf("expected only the first request before cancel, got %d", n)
+	}
+}
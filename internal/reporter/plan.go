@@ -0,0 +1,82 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/scheduler"
+)
+
+const (
+	planHeight = 15
+	planWidth  = 60
+)
+
+// Plan writes a planned target curve to w. format is "chart" (an ASCII plot),
+// "table" or "csv"; unit labels the target axis ("VUs" or "RPS").
+func Plan(w io.Writer, points []scheduler.Point, unit, format string) error {
+	switch format {
+	case "chart":
+		planChart(w, points, unit)
+	case "table":
+		fmt.Fprintf(w, "%10s  %8s\n", "Elapsed", unit)
+		fmt.Fprintln(w, strings.Repeat("─", 20))
+		for _, p := range points {
+			fmt.Fprintf(w, "%10s  %8d\n", formatDuration(p.Elapsed), p.Target)
+		}
+	case "csv":
+		fmt.Fprintf(w, "elapsed_seconds,%s\n", strings.ToLower(unit))
+		for _, p := range points {
+			fmt.Fprintf(w, "%g,%d\n", p.Elapsed.Seconds(), p.Target)
+		}
+	default:
+		return fmt.Errorf("unknown plan format %q (use chart, table or csv)", format)
+	}
+	return nil
+}
+
+// planChart plots the curve with time across and target up. When there are
+// more points than columns, each column shows the highest target it covers.
+func planChart(w io.Writer, points []scheduler.Point, unit string) {
+	if len(points) == 0 {
+		return
+	}
+	width := min(len(points), planWidth)
+	cols := make([]int, width)
+	peak := 0
+	for i, p := range points {
+		c := i * width / len(points)
+		cols[c] = max(cols[c], p.Target)
+		peak = max(peak, p.Target)
+	}
+	total := points[len(points)-1].Elapsed
+
+	fmt.Fprintf(w, "Duration: %s  Peak: %d %s\n\n", formatDuration(total), peak, unit)
+
+	scale := max(peak, 1)
+	for row := planHeight - 1; row >= 0; row-- {
+		label := ""
+		switch row {
+		case planHeight - 1:
+			label = fmt.Sprint(peak)
+		case planHeight / 2:
+			label = fmt.Sprint(peak / 2)
+		case 0:
+			label = "0"
+		}
+		var line strings.Builder
+		for _, v := range cols {
+			if v*(planHeight-1)/scale >= row && (v > 0 || row == 0) {
+				line.WriteByte('*')
+			} else {
+				line.WriteByte(' ')
+			}
+		}
+		fmt.Fprintf(w, "%8s │%s\n", label, strings.TrimRight(line.String(), " "))
+	}
+	fmt.Fprintf(w, "%8s └%s\n", "", strings.Repeat("─", width))
+	end := formatDuration(total)
+	fmt.Fprintf(w, "%8s  %-*s%s\n", "", max(width-len(end), 3), formatDuration(time.Duration(0)), end)
+}
@@ -20,8 +20,12 @@ func Print(w io.Writer, stats *metrics.Stats) {
 	}
 
 	elapsed := formatDuration(stats.Elapsed)
-	fmt.Fprintf(w, "\n[ %s ] VUs: %d  RPS: %.1f  Reqs: %d  Errors: %d (%.1f%%)\n",
+	fmt.Fprintf(w, "\n[ %s ] VUs: %d  RPS: %.1f  Reqs: %d  Errors: %d (%.1f%%)",
 		elapsed, stats.ActiveVUs, stats.RPS, stats.TotalRequests, stats.ErrorCount, errPct)
+	if stats.NewConns+stats.ReusedConns > 0 {
+		fmt.Fprintf(w, "  Conns: %.1f/s  Reuse: %.1f%%", stats.NewConnsRate, stats.ConnReuse*100)
+	}
+	fmt.Fprintln(w)
 	fmt.Fprintln(w, strings.Repeat("─", 65))
 	fmt.Fprintf(w, "%-30s %6s  %8s  %8s  %8s\n", "Endpoint", "Reqs", "p50", "p90", "p99")
 	fmt.Fprintln(w, strings.Repeat("─", 65))
@@ -50,12 +54,20 @@ func Summary(w io.Writer, stats *metrics.Stats) {
 	fmt.Fprintf(w, "  Success:        %d\n", stats.SuccessCount)
 	fmt.Fprintf(w, "  Errors:         %d\n", stats.ErrorCount)
 	fmt.Fprintf(w, "  Avg RPS:        %.2f\n", stats.RPS)
+	if stats.NewConns+stats.ReusedConns > 0 {
+		fmt.Fprintf(w, "  Connections:    %d new (%.2f/s), %.1f%% of requests reused one\n",
+			stats.NewConns, stats.NewConnsRate, stats.ConnReuse*100)
+	}
 	fmt.Fprintln(w, strings.Repeat("─", 65))
 	fmt.Fprintf(w, "  %-10s  %10s  %10s  %10s  %10s\n", "Metric", "p50", "p90", "p95", "p99")
 	fmt.Fprintln(w, strings.Repeat("─", 65))
 	fmt.Fprintf(w, "  %-10s  %10s  %10s  %10s  %10s\n", "Latency",
 		fmtDur(stats.P50), fmtDur(stats.P90), fmtDur(stats.P95), fmtDur(stats.P99))
 	fmt.Fprintf(w, "  Min: %s  Max: %s  Avg: %s\n", fmtDur(stats.Min), fmtDur(stats.Max), fmtDur(stats.Avg))
+	if stats.DrainedRequests > 0 || stats.InterruptedRequests > 0 {
+		fmt.Fprintf(w, "  In-flight at stop: %d completed, %d interrupted\n",
+			stats.DrainedRequests, stats.InterruptedRequests)
+	}
 
 	if len(stats.PerEndpoint) > 0 {
 		fmt.Fprintln(w, strings.Repeat("─", 65))
@@ -74,9 +86,156 @@ func Summary(w io.Writer, stats *metrics.Stats) {
 			)
 		}
 	}
+	if len(stats.PerProtocol) > 0 {
+		printProtocols(w, stats.PerProtocol)
+	}
+	if len(stats.PerOperation) > 0 {
+		printOperations(w, stats.PerOperation)
+	}
+	if len(stats.Sessions) > 0 {
+		printSessions(w, stats.Sessions)
+	}
+	if len(stats.Streams) > 0 {
+		printStreams(w, stats.Streams)
+	}
+	printCodes(w, stats.PerEndpoint)
+	if len(stats.Adjustments) > 0 {
+		printAdjustments(w, stats.Adjustments)
+	}
+	if len(stats.Interventions) > 0 {
+		fmt.Fprintln(w, strings.Repeat("─", 65))
+		fmt.Fprintln(w, "  Manual Interventions:")
+		for _, iv := range stats.Interventions {
+			fmt.Fprintf(w, "  [%s] %-8s %-10s %s\n", formatDuration(iv.Elapsed), iv.Action, iv.Value, iv.Source)
+		}
+	}
 	fmt.Fprintln(w, strings.Repeat("═", 65))
 }
 
+// printProtocols shows the protocols requests were sent over. When there is
+// more than one, their latencies are compared.
+func printProtocols(w io.Writer, perProto map[string]*metrics.EndpointStats) {
+	fmt.Fprintln(w, strings.Repeat("─", 65))
+	names := sortedKeys(perProto)
+	if len(names) == 1 {
+		fmt.Fprintf(w, "  Protocol: %s\n", names[0])
+		return
+	}
+	fmt.Fprintln(w, "  Per-Protocol:")
+	fmt.Fprintf(w, "  %-28s %6s %8s %8s %8s %8s\n", "Protocol", "Reqs", "p50", "p90", "p99", "Errors")
+	for _, name := range names {
+		ps := perProto[name]
+		fmt.Fprintf(w, "  %-28s %6d %8s %8s %8s %8d\n",
+			name, ps.TotalRequests, fmtDur(ps.P50), fmtDur(ps.P90), fmtDur(ps.P99), ps.ErrorCount)
+	}
+}
+
+// printOperations shows GraphQL requests by operation name, since all of a
+// service's operations usually share one URL.
+func printOperations(w io.Writer, perOp map[string]*metrics.EndpointStats) {
+	fmt.Fprintln(w, strings.Repeat("─", 65))
+	fmt.Fprintln(w, "  Per-Operation (GraphQL):")
+	fmt.Fprintf(w, "  %-28s %6s %8s %8s %8s %8s\n", "Operation", "Reqs", "p50", "p90", "p99", "Errors")
+	for _, name := range sortedKeys(perOp) {
+		op := perOp[name]
+		fmt.Fprintf(w, "  %-28s %6d %8s %8s %8s %8d\n",
+			truncate(name, 28), op.TotalRequests, fmtDur(op.P50), fmtDur(op.P90), fmtDur(op.P99), op.ErrorCount)
+	}
+}
+
+// printSessions shows connect times, reply round trips and message counts
+// for endpoints that hold a connection open, such as websockets.
+func printSessions(w io.Writer, sessions map[string]*metrics.SessionStats) {
+	names := make([]string, 0, len(sessions))
+	for name := range sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, strings.Repeat("─", 65))
+	fmt.Fprintln(w, "  Sessions:")
+	fmt.Fprintf(w, "  %-16s %6s %8s %8s %8s %7s %7s %6s\n",
+		"Endpoint", "Count", "Conn p50", "RTT p50", "RTT p99", "Sent", "Recv", "Abnml")
+	for _, name := range names {
+		ss := sessions[name]
+		fmt.Fprintf(w, "  %-16s %6d %8s %8s %8s %7d %7d %6d\n",
+			truncate(name, 16), ss.Sessions, fmtDur(ss.ConnectP50), fmtDur(ss.RTTP50), fmtDur(ss.RTTP99),
+			ss.Sent, ss.Received, ss.AbnormalCloses)
+	}
+}
+
+// printStreams shows time to first byte and first event, the gaps between
+// events and event counts for endpoints whose responses are streamed.
+func printStreams(w io.Writer, streams map[string]*metrics.StreamStats) {
+	names := make([]string, 0, len(streams))
+	for name := range streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, strings.Repeat("─", 65))
+	fmt.Fprintln(w, "  Streams:")
+	fmt.Fprintf(w, "  %-16s %6s %8s %8s %8s %8s %8s %7s %6s\n",
+		"Endpoint", "Count", "TTFB p50", "1st p50", "1st p99", "Gap p50", "Gap p99", "Events", "Capped")
+	for _, name := range names {
+		ss := streams[name]
+		fmt.Fprintf(w, "  %-16s %6d %8s %8s %8s %8s %8s %7d %6d\n",
+			truncate(name, 16), ss.Streams, fmtDur(ss.TTFBP50), fmtDur(ss.FirstEventP50), fmtDur(ss.FirstEventP99),
+			fmtDur(ss.GapP50), fmtDur(ss.GapP99), ss.Events, ss.Capped)
+	}
+}
+
+// printCodes shows how often each status code came back, for endpoints
+// whose calls have their own status codes, such as grpc.
+func printCodes(w io.Writer, perEndpoint map[string]*metrics.EndpointStats) {
+	header := false
+	for _, name := range sortedKeys(perEndpoint) {
+		es := perEndpoint[name]
+		if len(es.Codes) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, strings.Repeat("─", 65))
+			fmt.Fprintln(w, "  Status Codes:")
+			header = true
+		}
+		codes := make([]string, 0, len(es.Codes))
+		for code := range es.Codes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		var b strings.Builder
+		for _, code := range codes {
+			fmt.Fprintf(&b, "  %s %d", code, es.Codes[code])
+		}
+		fmt.Fprintf(w, "  %-28s%s\n", truncate(name, 28), b.String())
+	}
+}
+
+// printAdjustments summarizes the adaptive controller's decisions.
+func printAdjustments(w io.Writer, adjs []metrics.Adjustment) {
+	lo, hi := adjs[0].Target, adjs[0].Target
+	changes := 0
+	for _, a := range adjs[1:] {
+		if a.Target != a.PrevTarget {
+			changes++
+		}
+		if a.Target < lo {
+			lo = a.Target
+		}
+		if a.Target > hi {
+			hi = a.Target
+		}
+	}
+	last := adjs[len(adjs)-1]
+	fmt.Fprintln(w, strings.Repeat("─", 65))
+	fmt.Fprintln(w, "  Adaptive Control:")
+	fmt.Fprintf(w, "  Decisions: %d  Changes: %d  Range: %d–%d  Final target: %d\n",
+		len(adjs), changes, lo, hi, last.Target)
+	fmt.Fprintf(w, "  Last window: latency %s  errors %.1f%%  (%s)\n",
+		fmtDur(last.Latency), last.ErrorRate*100, last.Reason)
+}
+
 // WriteJSON writes the stats snapshot as JSON to the given file path.
 func WriteJSON(path string, stats *metrics.Stats) error {
 	f, err := os.Create(path)
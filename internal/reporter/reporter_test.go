@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/jvreagan/perf-test/internal/metrics"
+	"github.com/jvreagan/perf-test/internal/scheduler"
 )
 
 func sampleStats() *metrics.Stats {
@@ -131,3 +132,216 @@ func TestFormatDuration(t *testing.T) {
 		t.Errorf("expected 01:01:01, got %q", got)
 	}
 }
+
+func TestSummary_Adjustments(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	stats.Adjustments = []metrics.Adjustment{
+		{Target: 5, Reason: "initial"},
+		{PrevTarget: 5, Target: 6, Latency: 80 * time.Millisecond, Reason: "healthy"},
+		{PrevTarget: 6, Target: 4, Latency: 300 * time.Millisecond, Reason: "p99 300ms > 250ms"},
+	}
+	Summary(&buf, stats)
+	out := buf.String()
+
+	for _, c := range []string{"Adaptive Control", "Changes: 2", "Final target: 4", "p99 300ms > 250ms"} {
+		if !strings.Contains(out, c) {
+			t.Errorf("Summary output missing %q\nOutput:\n%s", c, out)
+		}
+	}
+}
+
+func TestSummary_Interventions(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	stats.Interventions = []metrics.Intervention{
+		{Elapsed: 12 * time.Second, Action: "pause", Source: "web"},
+		{Elapsed: 20 * time.Second, Action: "extend", Value: "30s", Source: "socket"},
+	}
+	Summary(&buf, stats)
+	out := buf.String()
+
+	for _, c := range []string{"Manual Interventions", "[00:12] pause", "extend   30s", "socket"} {
+		if !strings.Contains(out, c) {
+			t.Errorf("Summary output missing %q\nOutput:\n%s", c, out)
+		}
+	}
+}
+
+func TestSummary_InFlightAtStop(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	Summary(&buf, stats)
+	if strings.Contains(buf.String(), "In-flight at stop") {
+		t.Error("expected no in-flight line when nothing was drained or interrupted")
+	}
+
+	buf.Reset()
+	stats.DrainedRequests = 7
+	stats.InterruptedRequests = 2
+	Summary(&buf, stats)
+	if !strings.Contains(buf.String(), "In-flight at stop: 7 completed, 2 interrupted") {
+		t.Errorf("expected in-flight line\nOutput:\n%s", buf.String())
+	}
+}
+
+func TestPlan_Formats(t *testing.T) {
+	points := []scheduler.Point{
+		{Elapsed: 0, Target: 0},
+		{Elapsed: 30 * time.Second, Target: 50},
+		{Elapsed: time.Minute, Target: 100},
+	}
+
+	var buf bytes.Buffer
+	if err := Plan(&buf, points, "RPS", "chart"); err != nil {
+		t.Fatalf("chart: unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Peak: 100 RPS") || !strings.Contains(buf.String(), "*") {
+		t.Errorf("chart: expected peak and plotted points\nOutput:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Plan(&buf, points, "VUs", "table"); err != nil {
+		t.Fatalf("table: unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "00:30") || !strings.Contains(buf.String(), "50") {
+		t.Errorf("table: expected rows\nOutput:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Plan(&buf, points, "RPS", "csv"); err != nil {
+		t.Fatalf("csv: unexpected error: %v", err)
+	}
+	if buf.String() != "elapsed_seconds,rps\n0,0\n30,50\n60,100\n" {
+		t.Errorf("csv: unexpected output %q", buf.String())
+	}
+
+	if err := Plan(&buf, points, "RPS", "svg"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestSummary_Protocols(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	stats.PerProtocol = map[string]*metrics.EndpointStats{
+		"HTTP/2.0": {Name: "HTTP/2.0", TotalRequests: 10, P50: 5 * time.Millisecond},
+	}
+	Summary(&buf, stats)
+	if !strings.Contains(buf.String(), "Protocol: HTTP/2.0") {
+		t.Errorf("Summary output missing the protocol\nOutput:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	stats.PerProtocol["HTTP/1.1"] = &metrics.EndpointStats{Name: "HTTP/1.1", TotalRequests: 4, ErrorCount: 1}
+	Summary(&buf, stats)
+	out := buf.String()
+	for _, c := range []string{"Per-Protocol:", "HTTP/1.1", "HTTP/2.0"} {
+		if !strings.Contains(out, c) {
+			t.Errorf("Summary output missing %q\nOutput:\n%s", c, out)
+		}
+	}
+}
+
+func TestSummary_Connections(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	Summary(&buf, stats)
+	if strings.Contains(buf.String(), "Connections:") {
+		t.Error("Summary shows connections when none were recorded")
+	}
+
+	buf.Reset()
+	stats.NewConns, stats.ReusedConns = 4, 96
+	stats.NewConnsRate, stats.ConnReuse = 0.4, 0.96
+	Summary(&buf, stats)
+	Print(&buf, stats)
+	for _, c := range []string{"Connections:    4 new (0.40/s), 96.0% of requests reused one", "Conns: 0.4/s  Reuse: 96.0%"} {
+		if !strings.Contains(buf.String(), c) {
+			t.Errorf("output missing %q\nOutput:\n%s", c, buf.String())
+		}
+	}
+}
+
+func TestSummary_Sessions(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	Summary(&buf, stats)
+	if strings.Contains(buf.String(), "Sessions:") {
+		t.Error("Summary shows sessions when none were recorded")
+	}
+
+	buf.Reset()
+	stats.Sessions = map[string]*metrics.SessionStats{
+		"chat": {Name: "chat", Sessions: 4, ConnectP50: 2 * time.Millisecond, RTTP50: 3 * time.Millisecond,
+			RTTP99: 9 * time.Millisecond, Sent: 12, Received: 15, AbnormalCloses: 1},
+	}
+	Summary(&buf, stats)
+	out := buf.String()
+	for _, c := range []string{"Sessions:", "chat", "2.0ms", "3.0ms", "9.0ms", "12", "15"} {
+		if !strings.Contains(out, c) {
+			t.Errorf("Summary output missing %q\nOutput:\n%s", c, out)
+		}
+	}
+}
+
+func TestSummary_Codes(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	Summary(&buf, stats)
+	if strings.Contains(buf.String(), "Status Codes:") {
+		t.Error("Summary shows status codes when none were recorded")
+	}
+
+	buf.Reset()
+	stats.PerEndpoint["greet"] = &metrics.EndpointStats{Name: "greet", TotalRequests: 10,
+		Codes: map[string]int64{"OK": 8, "UNAVAILABLE": 2}}
+	Summary(&buf, stats)
+	if !strings.Contains(buf.String(), "OK 8  UNAVAILABLE 2") {
+		t.Errorf("Summary output missing the status codes\nOutput:\n%s", buf.String())
+	}
+}
+
+func TestSummary_Streams(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	Summary(&buf, stats)
+	if strings.Contains(buf.String(), "Streams:") {
+		t.Error("Summary shows streams when none were recorded")
+	}
+
+	buf.Reset()
+	stats.Streams = map[string]*metrics.StreamStats{
+		"completions": {Name: "completions", Streams: 5, TTFBP50: 2 * time.Millisecond, FirstEventP50: 30 * time.Millisecond,
+			FirstEventP99: 90 * time.Millisecond, GapP50: 4 * time.Millisecond, GapP99: 12 * time.Millisecond, Events: 640, Capped: 1},
+	}
+	Summary(&buf, stats)
+	out := buf.String()
+	for _, c := range []string{"Streams:", "completions", "2.0ms", "30.0ms", "90.0ms", "4.0ms", "12.0ms", "640"} {
+		if !strings.Contains(out, c) {
+			t.Errorf("Summary output missing %q\nOutput:\n%s", c, out)
+		}
+	}
+}
+
+func TestSummary_Operations(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sampleStats()
+	Summary(&buf, stats)
+	if strings.Contains(buf.String(), "Per-Operation") {
+		t.Error("Summary shows operations when none were recorded")
+	}
+
+	buf.Reset()
+	stats.PerOperation = map[string]*metrics.EndpointStats{
+		"GetUser":    {Name: "GetUser", TotalRequests: 90, P50: 7 * time.Millisecond},
+		"CreatePost": {Name: "CreatePost", TotalRequests: 10, ErrorCount: 2},
+	}
+	Summary(&buf, stats)
+	out := buf.String()
+	for _, c := range []string{"Per-Operation (GraphQL):", "GetUser", "7.0ms", "CreatePost"} {
+		if !strings.Contains(out, c) {
+			t.Errorf("Summary output missing %q\nOutput:\n%s", c, out)
+		}
+	}
+}
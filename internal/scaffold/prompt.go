@@ -0,0 +1,187 @@
+package scaffold
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interactive asks for the settings of a new config on in, writing prompts
+// to out. Each question offers a default, taken from o where it is set, that
+// an empty answer accepts. At end of input the remaining defaults are used.
+func Interactive(in io.Reader, out io.Writer, o Options) (Options, error) {
+	p := &prompter{in: bufio.NewReader(in), out: out}
+
+	o.BaseURL = p.ask("Target base URL", or(o.BaseURL, "http://localhost:8080"), checkBaseURL)
+
+	if len(o.Endpoints) == 0 {
+		fmt.Fprintln(out, "Endpoints, as \"[METHOD] /path [status]\". Leave empty to finish.")
+		for i := 1; ; i++ {
+			def := ""
+			if i == 1 {
+				def = "GET /"
+			}
+			s := p.ask(fmt.Sprintf("  Endpoint %d", i), def, func(s string) error {
+				if s == "" {
+					return nil
+				}
+				_, err := ParseEndpoint(s)
+				return err
+			})
+			if s == "" {
+				break
+			}
+			ep, _ := ParseEndpoint(s)
+			o.Endpoints = append(o.Endpoints, ep)
+			if p.eof {
+				break
+			}
+		}
+	}
+
+	fmt.Fprintln(out, `Load style:
+  shorthand     ramp up, hold, ramp down (ramp_up/steady_state/ramp_down)
+  stages        an explicit list of stages: smoke, spike or soak
+  arrival_rate  a fixed request rate: constant or breakpoint`)
+	style := "shorthand"
+	if o.Template != "" {
+		if t, err := Lookup(o.Template); err == nil {
+			style = t.Style
+		}
+	}
+	style = p.choose("Load style", []string{"shorthand", "stages", "arrival_rate"}, style)
+
+	var names []string
+	for _, t := range Templates {
+		if t.Style == style {
+			names = append(names, t.Name)
+		}
+	}
+	tmpl := names[0]
+	if slices.Contains(names, o.Template) {
+		tmpl = o.Template
+	}
+	if len(names) > 1 {
+		for _, name := range names {
+			t, _ := Lookup(name)
+			fmt.Fprintf(out, "  %-12s %s\n", t.Name, t.Summary)
+		}
+		tmpl = p.choose("Template", names, tmpl)
+	}
+	o.Template = tmpl
+	t, _ := Lookup(tmpl)
+
+	unit := "Peak VUs"
+	if t.Style == "arrival_rate" {
+		unit = "Peak requests per second"
+	}
+	o.Target = p.number(unit, or(o.Target, t.Target))
+	o.Duration = p.duration("Duration of the main phase", or(o.Duration, t.Duration))
+
+	o.Format = p.choose("Output format", []string{"console", "json", "csv"}, or(o.Format, "console"))
+	if o.Format != "console" {
+		o.File = p.ask("Results file", or(o.File, "results."+o.Format), nil)
+	}
+	o.Name = p.ask("Test name", or(o.Name, strings.ToUpper(tmpl[:1])+tmpl[1:]+" test"), nil)
+
+	if p.err != nil {
+		return o, p.err
+	}
+	return o, nil
+}
+
+// prompter reads answers line by line. After a read error or end of input
+// every question takes its default.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	eof bool
+	err error
+}
+
+// ask prints question and returns the answer, or def if the answer is
+// empty. Answers that fail check are reported and asked again.
+func (p *prompter) ask(question, def string, check func(string) error) string {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+		if p.eof {
+			fmt.Fprintln(p.out)
+			return def
+		}
+		line, err := p.in.ReadString('\n')
+		if err != nil {
+			p.eof = true
+			if !errors.Is(err, io.EOF) {
+				p.err = err
+			}
+			if line == "" {
+				fmt.Fprintln(p.out)
+				return def
+			}
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if check == nil {
+			return answer
+		}
+		if err := check(answer); err != nil {
+			fmt.Fprintf(p.out, "  %v\n", err)
+			if p.eof {
+				return def
+			}
+			continue
+		}
+		return answer
+	}
+}
+
+func (p *prompter) choose(question string, choices []string, def string) string {
+	return p.ask(fmt.Sprintf("%s (%s)", question, strings.Join(choices, "/")), def, func(s string) error {
+		if !slices.Contains(choices, s) {
+			return fmt.Errorf("choose one of: %s", strings.Join(choices, ", "))
+		}
+		return nil
+	})
+}
+
+func (p *prompter) number(question string, def int) int {
+	s := p.ask(question, strconv.Itoa(def), func(s string) error {
+		if n, err := strconv.Atoi(s); err != nil || n <= 0 {
+			return fmt.Errorf("enter a positive whole number")
+		}
+		return nil
+	})
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func (p *prompter) duration(question string, def time.Duration) time.Duration {
+	s := p.ask(question, dur(def), func(s string) error {
+		if d, err := time.ParseDuration(s); err != nil || d < MinDuration {
+			return fmt.Errorf("enter a duration of at least %s, such as 30s, 5m or 1h", MinDuration)
+		}
+		return nil
+	})
+	d, _ := time.ParseDuration(s)
+	return d
+}
+
+// or returns v unless it is the zero value, in which case def.
+func or[T comparable](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,324 @@
+// Package scaffold writes starter config files, either from a named template
+// or from answers to interactive prompts.
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options describes the config to generate. Zero values take the template's
+// defaults.
+type Options struct {
+	Template  string        // one of TemplateNames()
+	Name      string        // test name; default derived from the template
+	BaseURL   string        // becomes the base_url variable
+	Endpoints []Endpoint    // default: GET /
+	Target    int           // peak VUs, or RPS for arrival_rate templates
+	Duration  time.Duration // length of the main phase
+	Format    string        // output format: console, json or csv
+	File      string        // output file for json and csv
+	Command   string        // how the file was generated, for the header comment
+}
+
+// Endpoint is one request in the generated config.
+type Endpoint struct {
+	Method string
+	Path   string
+	Status int // expected status; default 200, or 201 for POST
+}
+
+// ParseEndpoint parses "METHOD /path" or "/path" (GET), with an optional
+// expected status: "POST /orders 201".
+func ParseEndpoint(s string) (Endpoint, error) {
+	fields := strings.Fields(s)
+	var ep Endpoint
+	if len(fields) > 0 && !strings.HasPrefix(fields[0], "/") {
+		ep.Method, fields = strings.ToUpper(fields[0]), fields[1:]
+	}
+	if len(fields) == 0 || len(fields) > 2 || !strings.HasPrefix(fields[0], "/") {
+		return Endpoint{}, fmt.Errorf("invalid endpoint %q (expected \"[METHOD] /path [status]\")", s)
+	}
+	ep.Path = fields[0]
+	if len(fields) == 2 {
+		if _, err := fmt.Sscanf(fields[1], "%d", &ep.Status); err != nil || ep.Status < 100 || ep.Status > 599 {
+			return Endpoint{}, fmt.Errorf("invalid status %q in endpoint %q", fields[1], s)
+		}
+	}
+	return ep, nil
+}
+
+// Template is a named starting point for a config.
+type Template struct {
+	Name     string
+	Summary  string
+	Style    string // "shorthand", "stages" or "arrival_rate"
+	Target   int
+	Duration time.Duration
+	load     func(target int, d time.Duration) string
+}
+
+// Templates lists the available templates.
+var Templates = []Template{
+	{
+		Name: "smoke", Summary: "one VU for a minute, to check that everything works",
+		Style: "stages", Target: 1, Duration: time.Minute,
+		load: func(target int, d time.Duration) string {
+			return fmt.Sprintf(`  # A smoke test sends a trickle of traffic to prove the endpoints and
+  # expectations are right before putting real load on them.
+  stages:
+    - duration: %s
+      target: %d
+      ramp: step  # start all VUs at once
+`, dur(d), target)
+		},
+	},
+	{
+		Name: "ramp", Summary: "ramp up, hold at a steady load, ramp down",
+		Style: "shorthand", Target: 50, Duration: 5 * time.Minute,
+		load: func(target int, d time.Duration) string {
+			return fmt.Sprintf(`  # Shorthand for three stages: 0 -> max_vus, hold, max_vus -> 0.
+  ramp_up: %s
+  steady_state: %s
+  ramp_down: %s
+  max_vus: %d
+  think_time: 1s  # pause between requests per VU
+`, dur(fraction(d, 5, 10*time.Second)), dur(d), dur(fraction(d, 10, 10*time.Second)), target)
+		},
+	},
+	{
+		Name: "spike", Summary: "a sudden burst on top of a baseline, then recovery",
+		Style: "stages", Target: 200, Duration: 2 * time.Minute,
+		load: func(target int, d time.Duration) string {
+			base := max(target/10, 1)
+			return fmt.Sprintf(`  # Settle at a baseline, spike to the peak, and watch how quickly latency
+  # and errors recover once the spike is over.
+  stages:
+    - duration: 1m
+      target: %d  # baseline
+    - duration: %s
+      target: %d
+      ramp: spike
+      hold: %s  # time at the peak before returning to baseline
+    - duration: 1m
+      target: %d  # recovery
+    - duration: 10s
+      target: 0
+  think_time: 1s
+`, base, dur(d), target, dur(fraction(d, 4, time.Second)), base)
+		},
+	},
+	{
+		Name: "soak", Summary: "moderate load held for hours, to find leaks and drift",
+		Style: "stages", Target: 50, Duration: 2 * time.Hour,
+		load: func(target int, d time.Duration) string {
+			return fmt.Sprintf(`  # Hold a realistic load for a long time. Watch for memory growth,
+  # connection leaks and latency that creeps up over the run.
+  stages:
+    - duration: 5m
+      target: %d
+    - duration: %s
+      target: %d
+    - duration: 5m
+      target: 0
+  think_time: 1s
+  graceful_stop: 30s  # let in-flight requests finish at the end
+`, target, dur(d), target)
+		},
+	},
+	{
+		Name: "breakpoint", Summary: "raise the request rate in steps until the system breaks",
+		Style: "arrival_rate", Target: 1000, Duration: 10 * time.Minute,
+		load: func(target int, d time.Duration) string {
+			var b strings.Builder
+			b.WriteString(`  # Requests are sent at a fixed rate whatever the response times, so
+  # the rate keeps climbing past the point where the system saturates.
+  # Compare the steps to see where latency and errors break; stop early
+  # with Ctrl-C.
+  mode: arrival_rate
+  stages:
+`)
+			const steps = 5
+			for i := 1; i <= steps; i++ {
+				fmt.Fprintf(&b, "    - duration: %s\n      target: %d  # RPS\n      ramp: step\n", dur(d/steps), target*i/steps)
+			}
+			return b.String()
+		},
+	},
+	{
+		Name: "constant", Summary: "a fixed request rate, independent of response times",
+		Style: "arrival_rate", Target: 100, Duration: 5 * time.Minute,
+		load: func(target int, d time.Duration) string {
+			return fmt.Sprintf(`  # Requests are sent at a fixed rate (RPS) whatever the response times.
+  mode: arrival_rate
+  stages:
+    - duration: 30s
+      target: %d  # ramp from 0 to the target rate
+    - duration: %s
+      target: %d
+    - duration: 10s
+      target: 0
+`, target, dur(d), target)
+		},
+	},
+}
+
+// TemplateNames returns the names of all templates.
+func TemplateNames() []string {
+	names := make([]string, len(Templates))
+	for i, t := range Templates {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Lookup returns the template called name.
+func Lookup(name string) (Template, error) {
+	for _, t := range Templates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return Template{}, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(TemplateNames(), ", "))
+}
+
+// MinDuration is the shortest main phase Render accepts. Templates split
+// the phase into stages and holds of their own, which need room.
+const MinDuration = time.Second
+
+// Render returns the config described by o as commented YAML.
+func Render(o Options) ([]byte, error) {
+	if o.Template == "" {
+		o.Template = "smoke"
+	}
+	t, err := Lookup(o.Template)
+	if err != nil {
+		return nil, err
+	}
+	if o.Target <= 0 {
+		o.Target = t.Target
+	}
+	if o.Duration <= 0 {
+		o.Duration = t.Duration
+	}
+	if o.Duration < MinDuration {
+		return nil, fmt.Errorf("duration %s is too short (minimum %s)", o.Duration, MinDuration)
+	}
+	if o.Name == "" {
+		o.Name = strings.ToUpper(t.Name[:1]) + t.Name[1:] + " test"
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = "http://localhost:8080"
+	}
+	if err := checkBaseURL(o.BaseURL); err != nil {
+		return nil, err
+	}
+	if len(o.Endpoints) == 0 {
+		o.Endpoints = []Endpoint{{Method: "GET", Path: "/"}}
+	}
+	switch o.Format {
+	case "":
+		o.Format = "console"
+	case "console", "json", "csv":
+	default:
+		return nil, fmt.Errorf("invalid output format %q (expected console, json or csv)", o.Format)
+	}
+
+	var b bytes.Buffer
+	if o.Command != "" {
+		fmt.Fprintf(&b, "# Generated by %s\n", o.Command)
+	}
+	fmt.Fprintf(&b, `# Check it with "perf-test validate", preview the load curve with
+# "perf-test plan" and send one request per endpoint with "perf-test debug".
+
+name: %s
+description: %s
+
+load:
+%s
+http:
+  timeout: 10s
+  follow_redirects: true
+
+variables:
+  # Override per environment with --var base_url=https://staging.example.com
+  base_url: %s
+
+endpoints:
+`, quote(o.Name), quote(strings.ToUpper(t.Summary[:1])+t.Summary[1:]), t.load(o.Target, o.Duration), quote(strings.TrimSuffix(o.BaseURL, "/")))
+
+	for _, ep := range o.Endpoints {
+		writeEndpoint(&b, ep)
+	}
+
+	fmt.Fprintf(&b, "\noutput:\n  format: %s  # console, json or csv\n  interval: 5s  # how often to print live stats\n", o.Format)
+	if o.Format != "console" {
+		file := o.File
+		if file == "" {
+			file = "results." + o.Format
+		}
+		fmt.Fprintf(&b, "  file: %s\n", quote(file))
+	}
+	return b.Bytes(), nil
+}
+
+func checkBaseURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid base URL %q (expected scheme://host[:port])", s)
+	}
+	return nil
+}
+
+func writeEndpoint(b *bytes.Buffer, ep Endpoint) {
+	if ep.Method == "" {
+		ep.Method = "GET"
+	}
+	if ep.Status == 0 {
+		ep.Status = 200
+		if ep.Method == "POST" {
+			ep.Status = 201
+		}
+	}
+	fmt.Fprintf(b, "  - name: %s\n    method: %s\n    url: %s\n", quote(ep.Method+" "+ep.Path), ep.Method, quote("${base_url}"+ep.Path))
+	switch ep.Method {
+	case "POST", "PUT", "PATCH":
+		b.WriteString(`    headers:
+      Content-Type: application/json
+    # ${random.uuid}, ${random.int(1,100)} and friends give each request fresh data.
+    body: '{"id": "${random.uuid}"}'
+`)
+	}
+	fmt.Fprintf(b, "    weight: 1  # relative share of traffic\n    expect:\n      status: %d\n", ep.Status)
+}
+
+// quote renders s as a YAML scalar, quoting only when needed.
+func quote(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// dur formats d compactly: 1m rather than 1m0s.
+func dur(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// fraction returns d/n rounded to a second, but at least floor.
+func fraction(d time.Duration, n int, floor time.Duration) time.Duration {
+	return max((d / time.Duration(n)).Round(time.Second), floor)
+}
@@ -0,0 +1,171 @@
+package scaffold
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+// load writes out to a file and loads it as a config.
+func load(t *testing.T, out []byte) *config.Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "perf-test.yaml")
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("generated config is invalid: %v\n%s", err, out)
+	}
+	return cfg
+}
+
+func TestRender_AllTemplatesAreValid(t *testing.T) {
+	for _, name := range TemplateNames() {
+		t.Run(name, func(t *testing.T) {
+			out, err := Render(Options{
+				Template:  name,
+				BaseURL:   "https://api.example.com/",
+				Endpoints: []Endpoint{{Method: "GET", Path: "/health"}, {Method: "POST", Path: "/orders"}},
+				Format:    "json",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := load(t, out)
+			if len(cfg.Endpoints) != 2 || cfg.Endpoints[1].Expect.Status != 201 {
+				t.Errorf("endpoints = %+v", cfg.Endpoints)
+			}
+			if cfg.Variables["base_url"] != "https://api.example.com" {
+				t.Errorf("base_url = %q", cfg.Variables["base_url"])
+			}
+			if cfg.Output.File != "results.json" {
+				t.Errorf("output.file = %q", cfg.Output.File)
+			}
+			if !bytes.Contains(out, []byte("\n  # ")) {
+				t.Error("expected comments in the load block")
+			}
+		})
+	}
+}
+
+func TestRender_MinDuration(t *testing.T) {
+	for _, name := range TemplateNames() {
+		for _, d := range []time.Duration{MinDuration, MinDuration + 500*time.Millisecond, 3 * MinDuration} {
+			out, err := Render(Options{Template: name, Duration: d})
+			if err != nil {
+				t.Errorf("%s at %s: %v", name, d, err)
+				continue
+			}
+			load(t, out)
+		}
+	}
+}
+
+func TestRender_TargetAndDuration(t *testing.T) {
+	out, err := Render(Options{Template: "breakpoint", Target: 500, Duration: 5 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := load(t, out)
+	if cfg.Load.Mode != "arrival_rate" {
+		t.Errorf("mode = %q, want arrival_rate", cfg.Load.Mode)
+	}
+	if n := len(cfg.Load.Stages); n != 5 {
+		t.Fatalf("got %d stages, want 5", n)
+	}
+	if last := cfg.Load.Stages[4]; last.Target != 500 || last.Duration.Duration != time.Minute {
+		t.Errorf("last stage = %+v, want 500 RPS for 1m", last)
+	}
+	if got := cfg.TotalDuration(); got != 5*time.Minute {
+		t.Errorf("total duration = %s, want 5m", got)
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	for _, o := range []Options{
+		{Template: "nope"},
+		{BaseURL: "localhost"},
+		{Format: "xml"},
+		{Template: "spike", Duration: 500 * time.Millisecond},
+	} {
+		if _, err := Render(o); err == nil {
+			t.Errorf("Render(%+v): expected an error", o)
+		}
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	for in, want := range map[string]Endpoint{
+		"/health":          {Path: "/health"},
+		"post /orders 202": {Method: "POST", Path: "/orders", Status: 202},
+		"DELETE /items/1":  {Method: "DELETE", Path: "/items/1"},
+	} {
+		got, err := ParseEndpoint(in)
+		if err != nil || got != want {
+			t.Errorf("ParseEndpoint(%q) = %+v, %v; want %+v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "GET", "GET health", "GET /x abc", "GET /x 200 extra"} {
+		if _, err := ParseEndpoint(in); err == nil {
+			t.Errorf("ParseEndpoint(%q): expected an error", in)
+		}
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	in := strings.Join([]string{
+		"api.example.com",         // invalid, asked again
+		"https://api.example.com", // base URL
+		"GET /users",
+		"POST /users",
+		"", // done with endpoints
+		"stages",
+		"spike",
+		"300",
+		"90s",
+		"csv",
+		"", // default results file
+		"Checkout spike",
+	}, "\n") + "\n"
+	var prompts bytes.Buffer
+	o, err := Interactive(strings.NewReader(in), &prompts, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompts.String(), "invalid base URL") {
+		t.Errorf("expected the invalid URL to be reported:\n%s", prompts.String())
+	}
+	want := Options{
+		Template: "spike", Name: "Checkout spike", BaseURL: "https://api.example.com",
+		Target: 300, Duration: 90 * time.Second, Format: "csv", File: "results.csv",
+	}
+	if len(o.Endpoints) != 2 || o.Endpoints[1].Method != "POST" {
+		t.Errorf("endpoints = %+v", o.Endpoints)
+	}
+	o.Endpoints = nil
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("options = %+v\nwant      %+v", o, want)
+	}
+}
+
+func TestInteractive_DefaultsAtEOF(t *testing.T) {
+	o, err := Interactive(strings.NewReader(""), &bytes.Buffer{}, Options{Template: "soak"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Template != "soak" || o.Target != 50 || o.Duration != 2*time.Hour || len(o.Endpoints) != 1 {
+		t.Errorf("options = %+v", o)
+	}
+	out, err := Render(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load(t, out)
+}
@@ -2,7 +2,8 @@ package scheduler
 
 import (
 	"context"
-	"math"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/jvreagan/perf-test/internal/config"
@@ -10,13 +11,38 @@ import (
 
 // Scheduler drives the VU ramp profile by sending target VU counts
 // on a channel as time progresses through the configured stages.
+//
+// The schedule runs on its own clock, which can be paused, advanced past the
+// current stage, or stretched while the test is running. A target override
+// pins the output until the stage it was set in ends.
 type Scheduler struct {
-	stages []config.Stage
+	mu            sync.Mutex
+	stages        []config.Stage
+	start         time.Time
+	offset        time.Duration // shifts wall time: skips add, pauses subtract
+	pausedAt      time.Time     // zero while running
+	override      int           // -1 when no override is active
+	overrideStage int
+	lastSent      int
+	walks         map[int]*randomWalk // random_walk stages, by index
+}
+
+// State describes the scheduler's position in the profile.
+type State struct {
+	Stage    int // zero-based index of the current stage
+	Stages   int
+	Elapsed  time.Duration // schedule time (excludes paused time)
+	Total    time.Duration // sum of stage durations, including extensions
+	Target   int           // last target sent
+	Paused   bool
+	Override bool
 }
 
 // New creates a Scheduler from the given stages.
 func New(stages []config.Stage) *Scheduler {
-	return &Scheduler{stages: stages}
+	s := make([]config.Stage, len(stages))
+	copy(s, stages)
+	return &Scheduler{stages: s, override: -1, lastSent: -1, walks: make(map[int]*randomWalk)}
 }
 
 // Run starts the scheduler in the current goroutine, sending target VU counts
@@ -26,18 +52,21 @@ func (s *Scheduler) Run(ctx context.Context, targetCh chan<- int) {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
-	start := time.Now()
-	lastSent := -1
+	s.mu.Lock()
+	s.start = time.Now()
+	s.mu.Unlock()
 
 	// sendIfChanged sends v on targetCh if it differs from lastSent.
 	// Returns false if ctx was cancelled before the send completed.
 	sendIfChanged := func(v int) bool {
-		if v == lastSent {
+		if v == s.sent() {
 			return true
 		}
 		select {
 		case targetCh <- v:
-			lastSent = v
+			s.mu.Lock()
+			s.lastSent = v
+			s.mu.Unlock()
 			return true
 		case <-ctx.Done():
 			return false
@@ -47,7 +76,7 @@ func (s *Scheduler) Run(ctx context.Context, targetCh chan<- int) {
 	// sendFinal does a best-effort non-blocking send of 0 for graceful shutdown.
 	// Using non-blocking because ctx is already cancelled and we can't block.
 	sendFinal := func() {
-		if lastSent == 0 {
+		if s.sent() == 0 {
 			return
 		}
 		select {
@@ -62,8 +91,7 @@ func (s *Scheduler) Run(ctx context.Context, targetCh chan<- int) {
 			sendFinal()
 			return
 		case t := <-ticker.C:
-			elapsed := t.Sub(start)
-			target, done := s.targetAt(elapsed)
+			target, done := s.next(t)
 			if !sendIfChanged(target) {
 				return
 			}
@@ -74,7 +102,143 @@ func (s *Scheduler) Run(ctx context.Context, targetCh chan<- int) {
 	}
 }
 
-// targetAt returns the interpolated VU target at the given elapsed time.
+func (s *Scheduler) sent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSent
+}
+
+// next returns the target for wall time now, applying any active override.
+func (s *Scheduler) next(now time.Time) (target int, done bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := s.elapsedLocked(now)
+	target, done = s.targetAt(elapsed)
+	if s.override >= 0 {
+		if idx, _, _ := s.stageAt(elapsed); idx == s.overrideStage && !done {
+			return s.override, false
+		}
+		s.override = -1
+	}
+	return target, done
+}
+
+// elapsedLocked returns schedule time at wall time now. Caller holds s.mu.
+func (s *Scheduler) elapsedLocked(now time.Time) time.Duration {
+	if !s.pausedAt.IsZero() {
+		now = s.pausedAt
+	}
+	return now.Sub(s.start) + s.offset
+}
+
+// stageAt returns the index and bounds of the stage containing elapsed, or
+// len(stages) when past the end.
+func (s *Scheduler) stageAt(elapsed time.Duration) (idx int, start, end time.Duration) {
+	for i, stage := range s.stages {
+		end = start + stage.Duration.Duration
+		if elapsed <= end {
+			return i, start, end
+		}
+		start = end
+	}
+	return len(s.stages), start, start
+}
+
+// Pause freezes the schedule clock. The current target is left in place;
+// it is up to the caller to idle the workers.
+func (s *Scheduler) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.pausedAt.IsZero() {
+		return fmt.Errorf("already paused")
+	}
+	s.pausedAt = time.Now()
+	return nil
+}
+
+// Resume restarts the schedule clock from where it was paused.
+func (s *Scheduler) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pausedAt.IsZero() {
+		return fmt.Errorf("not paused")
+	}
+	s.offset -= time.Since(s.pausedAt)
+	s.pausedAt = time.Time{}
+	return nil
+}
+
+// Override pins the target at v until the current stage ends.
+func (s *Scheduler) Override(v int) error {
+	if v < 0 {
+		return fmt.Errorf("target must be >= 0")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, _, _ := s.stageAt(s.elapsedLocked(time.Now()))
+	if idx >= len(s.stages) {
+		return fmt.Errorf("schedule has finished")
+	}
+	s.override = v
+	s.overrideStage = idx
+	return nil
+}
+
+// SkipStage advances the schedule clock to the start of the next stage.
+// Skipping the last stage ends the run.
+func (s *Scheduler) SkipStage() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elapsed := s.elapsedLocked(time.Now())
+	idx, _, end := s.stageAt(elapsed)
+	if idx >= len(s.stages) {
+		return fmt.Errorf("schedule has finished")
+	}
+	s.offset += end - elapsed + time.Nanosecond
+	return nil
+}
+
+// ExtendStage lengthens the current stage by d.
+func (s *Scheduler) ExtendStage(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("extension must be positive")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, _, _ := s.stageAt(s.elapsedLocked(time.Now()))
+	if idx >= len(s.stages) {
+		return fmt.Errorf("schedule has finished")
+	}
+	s.stages[idx].Duration.Duration += d
+	return nil
+}
+
+// State returns the scheduler's current position in the profile.
+func (s *Scheduler) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := State{
+		Stages:   len(s.stages),
+		Target:   s.lastSent,
+		Paused:   !s.pausedAt.IsZero(),
+		Override: s.override >= 0,
+	}
+	if !s.start.IsZero() {
+		st.Elapsed = s.elapsedLocked(time.Now())
+		st.Stage, _, _ = s.stageAt(st.Elapsed)
+	}
+	for _, stage := range s.stages {
+		st.Total += stage.Duration.Duration
+	}
+	if st.Target < 0 {
+		st.Target = 0
+	}
+	return st
+}
+
+// targetAt returns the VU target at the given elapsed time, following each
+// stage's ramp shape from the value the previous stage ended on.
 // done is true when we have passed all stages.
 func (s *Scheduler) targetAt(elapsed time.Duration) (target int, done bool) {
 	var stageStart time.Duration
@@ -85,22 +249,13 @@ func (s *Scheduler) targetAt(elapsed time.Duration) (target int, done bool) {
 
 		if elapsed <= stageEnd {
 			// We're within this stage
-			stageDur := stage.Duration.Duration
-			if stageDur == 0 || stage.Ramp == "step" {
+			if stage.Duration.Duration == 0 || stage.Ramp == "step" {
 				return stage.Target, i == len(s.stages)-1
 			}
-			pct := float64(elapsed-stageStart) / float64(stageDur)
-			if pct < 0 {
-				pct = 0
-			}
-			if pct > 1 {
-				pct = 1
-			}
-			interpolated := int(math.Round(float64(prev) + float64(stage.Target-prev)*pct))
-			return interpolated, false
+			return s.shapeAt(i, prev, elapsed-stageStart), false
 		}
 
-		prev = stage.Target
+		prev = s.shapeAt(i, prev, stage.Duration.Duration)
 		stageStart = stageEnd
 	}
 
@@ -194,3 +194,171 @@ func TestRun_Completes(t *testing.T) {
 		t.Fatal("Run did not complete within timeout")
 	}
 }
+
+func TestControl_PauseFreezesClock(t *testing.T) {
+	s := New(stages([]time.Duration{10 * time.Second}, []int{100}))
+	s.start = time.Now().Add(-5 * time.Second)
+
+	if err := s.Pause(); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := s.Pause(); err == nil {
+		t.Error("expected error pausing twice")
+	}
+	frozen := s.State().Elapsed
+	time.Sleep(20 * time.Millisecond)
+	if got := s.State().Elapsed; got != frozen {
+		t.Errorf("clock advanced while paused: %v → %v", frozen, got)
+	}
+	if err := s.Resume(); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if got := s.State().Elapsed; got < frozen || got > frozen+10*time.Millisecond {
+		t.Errorf("expected clock to resume near %v, got %v", frozen, got)
+	}
+}
+
+func TestControl_SkipStage(t *testing.T) {
+	s := New(stages([]time.Duration{10 * time.Second, 10 * time.Second}, []int{100, 20}))
+	s.start = time.Now().Add(-2 * time.Second)
+
+	if err := s.SkipStage(); err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+	if st := s.State(); st.Stage != 1 {
+		t.Errorf("expected stage 1 after skip, got %d", st.Stage)
+	}
+	if err := s.SkipStage(); err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+	if _, done := s.next(time.Now()); !done {
+		t.Error("expected schedule to be done after skipping the last stage")
+	}
+}
+
+func TestControl_ExtendStage(t *testing.T) {
+	s := New(stages([]time.Duration{10 * time.Second}, []int{100}))
+	s.start = time.Now().Add(-5 * time.Second)
+
+	if err := s.ExtendStage(10 * time.Second); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if st := s.State(); st.Total != 20*time.Second {
+		t.Errorf("expected total 20s after extend, got %v", st.Total)
+	}
+	// Halfway through the original stage is now a quarter through the extended one.
+	if v, _ := s.next(time.Now()); v < 24 || v > 26 {
+		t.Errorf("expected ~25 after extension, got %d", v)
+	}
+}
+
+func TestControl_OverrideLastsUntilStageEnds(t *testing.T) {
+	s := New(stages([]time.Duration{10 * time.Second, 10 * time.Second}, []int{100, 100}))
+	s.start = time.Now().Add(-2 * time.Second)
+
+	if err := s.Override(7); err != nil {
+		t.Fatalf("override: %v", err)
+	}
+	if v, _ := s.next(time.Now()); v != 7 {
+		t.Errorf("expected override 7, got %d", v)
+	}
+	if v, _ := s.next(time.Now().Add(9 * time.Second)); v != 100 {
+		t.Errorf("expected override to clear in the next stage, got %d", v)
+	}
+	if s.State().Override {
+		t.Error("expected override to be cleared")
+	}
+}
+
+func shaped(ramp string, target int, dur time.Duration) config.Stage {
+	return config.Stage{Duration: config.Duration{Duration: dur}, Target: target, Ramp: ramp}
+}
+
+func TestTargetAt_ExponentialAndLogarithmic(t *testing.T) {
+	exp := New([]config.Stage{shaped("exponential", 100, 10*time.Second)})
+	log := New([]config.Stage{shaped("logarithmic", 100, 10*time.Second)})
+
+	e, _ := exp.targetAt(5 * time.Second)
+	l, _ := log.targetAt(5 * time.Second)
+	if e >= 50 || l <= 50 {
+		t.Errorf("at midpoint: expected exponential < 50 < logarithmic, got %d and %d", e, l)
+	}
+	for _, s := range []*Scheduler{exp, log} {
+		if v, _ := s.targetAt(0); v != 0 {
+			t.Errorf("expected curve to start at 0, got %d", v)
+		}
+		if v, _ := s.targetAt(10 * time.Second); v != 100 {
+			t.Errorf("expected curve to end at 100, got %d", v)
+		}
+	}
+}
+
+func TestTargetAt_Sine(t *testing.T) {
+	st := shaped("sine", 50, 40*time.Second)
+	st.Period = config.Duration{Duration: 20 * time.Second}
+	st.Amplitude = 30
+	s := New([]config.Stage{st})
+
+	cases := map[time.Duration]int{0: 50, 5 * time.Second: 80, 10 * time.Second: 50, 15 * time.Second: 20, 25 * time.Second: 80}
+	for at, want := range cases {
+		if v, _ := s.targetAt(at); v != want {
+			t.Errorf("at %s: expected %d, got %d", at, want, v)
+		}
+	}
+}
+
+func TestTargetAt_SpikeReturnsToPrevious(t *testing.T) {
+	spike := shaped("spike", 200, 10*time.Second)
+	spike.Hold = config.Duration{Duration: 2 * time.Second}
+	s := New([]config.Stage{shaped("step", 20, 5*time.Second), spike, shaped("", 20, 5*time.Second)})
+
+	if v, _ := s.targetAt(6 * time.Second); v != 200 {
+		t.Errorf("during spike: expected 200, got %d", v)
+	}
+	if v, _ := s.targetAt(8 * time.Second); v != 20 {
+		t.Errorf("after spike hold: expected 20, got %d", v)
+	}
+	// The next linear stage starts from where the spike returned to.
+	if v, _ := s.targetAt(17 * time.Second); v != 20 {
+		t.Errorf("next stage: expected 20, got %d", v)
+	}
+}
+
+func TestTargetAt_RandomWalkBoundedAndReproducible(t *testing.T) {
+	st := shaped("random_walk", 60, time.Minute)
+	st.Min = 40
+	st.Amplitude = 5
+	st.Period = config.Duration{Duration: time.Second}
+	st.Seed = 7
+
+	a, b := New([]config.Stage{st}), New([]config.Stage{st})
+	prev := -1
+	for sec := 0; sec <= 60; sec++ {
+		at := time.Duration(sec) * time.Second
+		va, _ := a.targetAt(at)
+		vb, _ := b.targetAt(at)
+		if va != vb {
+			t.Fatalf("at %s: same seed produced %d and %d", at, va, vb)
+		}
+		if va < 40 || va > 60 {
+			t.Fatalf("at %s: %d outside bounds [40, 60]", at, va)
+		}
+		if prev >= 0 && (va-prev > 5 || prev-va > 5) {
+			t.Fatalf("at %s: step %d -> %d exceeds amplitude", at, prev, va)
+		}
+		prev = va
+	}
+}
+
+func TestPlan(t *testing.T) {
+	points := Plan(stages([]time.Duration{10 * time.Second, 10 * time.Second}, []int{100, 0}), 5*time.Second)
+	want := []int{0, 50, 100, 50, 0}
+	if len(points) != len(want) {
+		t.Fatalf("expected %d points, got %d", len(want), len(points))
+	}
+	for i, p := range points {
+		if p.Target != want[i] || p.Elapsed != time.Duration(i)*5*time.Second {
+			t.Errorf("point %d: expected %d at %s, got %+v", i, want[i], time.Duration(i)*5*time.Second, p)
+		}
+	}
+}
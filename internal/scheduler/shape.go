@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+)
+
+// curveSteepness controls how sharply the exponential and logarithmic ramps
+// bend. Higher values keep the target near one end for longer.
+const curveSteepness = 4
+
+// shapeAt returns the target offset into stage i, where prev is the target
+// the previous stage ended on.
+func (s *Scheduler) shapeAt(i, prev int, offset time.Duration) int {
+	stage := s.stages[i]
+	pct := 1.0
+	if stage.Duration.Duration > 0 {
+		pct = math.Max(0, math.Min(1, float64(offset)/float64(stage.Duration.Duration)))
+	}
+
+	switch stage.Ramp {
+	case "step":
+		return stage.Target
+	case "exponential":
+		return lerp(prev, stage.Target, math.Expm1(curveSteepness*pct)/math.Expm1(curveSteepness))
+	case "logarithmic":
+		return lerp(prev, stage.Target, math.Log1p(math.Expm1(curveSteepness)*pct)/curveSteepness)
+	case "sine":
+		phase := 2 * math.Pi * float64(offset) / float64(stage.Period.Duration)
+		v := float64(stage.Target) + float64(stage.Amplitude)*math.Sin(phase)
+		return max(int(math.Round(v)), 0)
+	case "spike":
+		if offset < stage.Hold.Duration {
+			return stage.Target
+		}
+		return prev
+	case "random_walk":
+		return s.walk(i, prev).at(int(offset / stage.Period.Duration))
+	default:
+		return lerp(prev, stage.Target, pct)
+	}
+}
+
+// lerp interpolates between from and to by pct in [0, 1].
+func lerp(from, to int, pct float64) int {
+	return int(math.Round(float64(from) + float64(to-from)*pct))
+}
+
+// walk returns the random walk for stage i, starting it from prev on first use.
+func (s *Scheduler) walk(i, prev int) *randomWalk {
+	if w, ok := s.walks[i]; ok {
+		return w
+	}
+	stage := s.stages[i]
+	w := &randomWalk{
+		rng:  rand.New(rand.NewSource(stage.Seed)),
+		lo:   stage.Min,
+		hi:   stage.Target,
+		step: stage.Amplitude,
+	}
+	w.vals = []int{w.clamp(prev)}
+	s.walks[i] = w
+	return w
+}
+
+// randomWalk is a bounded random walk generated lazily, one value per period,
+// so that extending the stage continues the same sequence.
+type randomWalk struct {
+	rng    *rand.Rand
+	lo, hi int
+	step   int
+	vals   []int
+}
+
+func (w *randomWalk) at(n int) int {
+	for len(w.vals) <= n {
+		last := w.vals[len(w.vals)-1]
+		w.vals = append(w.vals, w.clamp(last+w.rng.Intn(2*w.step+1)-w.step))
+	}
+	return w.vals[n]
+}
+
+func (w *randomWalk) clamp(v int) int {
+	return max(w.lo, min(w.hi, v))
+}
+
+// Point is one sample of a planned target curve.
+type Point struct {
+	Elapsed time.Duration
+	Target  int
+}
+
+// Plan samples the target curve for stages every step, from the start of the
+// first stage to the end of the last, without running it. The result is the
+// same curve a Scheduler would follow, including random walks for a given seed.
+func Plan(stages []config.Stage, step time.Duration) []Point {
+	s := New(stages)
+	var total time.Duration
+	for _, stage := range s.stages {
+		total += stage.Duration.Duration
+	}
+
+	var points []Point
+	for t := time.Duration(0); t < total; t += step {
+		v, _ := s.targetAt(t)
+		points = append(points, Point{Elapsed: t, Target: v})
+	}
+	v, _ := s.targetAt(total)
+	return append(points, Point{Elapsed: total, Target: v})
+}
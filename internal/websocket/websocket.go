@@ -0,0 +1,342 @@
+// Package websocket implements the parts of the WebSocket protocol (RFC
+// 6455) needed to load-test WebSocket servers: a client handshake sent
+// through an http.Client, so proxies, TLS and the other client settings
+// apply, message framing with ping and close handling, and a minimal server
+// side.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // required by the handshake, not used for security
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Message types.
+const (
+	OpText   = 1
+	OpBinary = 2
+
+	opContinuation = 0
+	opClose        = 8
+	opPing         = 9
+	opPong         = 10
+)
+
+// Close codes (RFC 6455 section 7.4.1).
+const (
+	CloseNormal    = 1000
+	CloseGoingAway = 1001
+	CloseNoStatus  = 1005
+	CloseAbnormal  = 1006
+)
+
+// maxMessageSize bounds the messages ReadMessage accepts.
+const maxMessageSize = 16 << 20
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// CloseError reports that the connection closed, with the code the peer
+// sent, or CloseAbnormal if it went away without a close frame.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Text)
+}
+
+// Normal reports whether the connection was closed in an orderly way.
+func (e *CloseError) Normal() bool {
+	return e.Code == CloseNormal || e.Code == CloseGoingAway || e.Code == CloseNoStatus
+}
+
+// Conn is a WebSocket connection. One goroutine may read while others
+// write.
+type Conn struct {
+	rwc    io.ReadWriteCloser
+	br     *bufio.Reader
+	client bool // client frames are masked
+
+	wmu       sync.Mutex
+	closeSent bool
+
+	// Subprotocol is the subprotocol the server selected, if any.
+	Subprotocol string
+}
+
+// HTTPURL maps a ws:// or wss:// URL to the http:// or https:// URL the
+// handshake is sent to. Other URLs are returned unchanged.
+func HTTPURL(u string) string {
+	if rest, ok := strings.CutPrefix(u, "ws://"); ok {
+		return "http://" + rest
+	}
+	if rest, ok := strings.CutPrefix(u, "wss://"); ok {
+		return "https://" + rest
+	}
+	return u
+}
+
+// Dial performs the opening handshake by sending req, a GET to an http://
+// or https:// URL, with client. The response is returned whenever one was
+// received, so callers can report its status even if the handshake failed.
+func Dial(client *http.Client, req *http.Request, subprotocols []string) (*Conn, *http.Response, error) {
+	key := make([]byte, 16)
+	rand.Read(key)
+	encKey := base64.StdEncoding.EncodeToString(key)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", encKey)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(subprotocols, ", "))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		return nil, resp, fmt.Errorf("websocket handshake: expected status 101, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != acceptKey(encKey) {
+		resp.Body.Close()
+		return nil, resp, fmt.Errorf("websocket handshake: bad Sec-WebSocket-Accept %q", got)
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, resp, errors.New("websocket handshake: connection cannot be upgraded")
+	}
+	return &Conn{
+		rwc:         rwc,
+		br:          bufio.NewReader(rwc),
+		client:      true,
+		Subprotocol: resp.Header.Get("Sec-WebSocket-Protocol"),
+	}, resp, nil
+}
+
+// Accept completes the handshake for a client's upgrade request, selecting
+// the first of subprotocols the client offers.
+func Accept(w http.ResponseWriter, r *http.Request, subprotocols ...string) (*Conn, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		!strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("not a websocket upgrade request")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		http.Error(w, "unsupported websocket version", http.StatusBadRequest)
+		return nil, errors.New("missing key or unsupported version")
+	}
+	var selected string
+	offered := strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",")
+	for _, p := range subprotocols {
+		for _, o := range offered {
+			if strings.TrimSpace(o) == p && selected == "" {
+				selected = p
+			}
+		}
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection cannot be hijacked", http.StatusInternalServerError)
+		return nil, errors.New("response writer cannot be hijacked")
+	}
+	nc, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("hijacking connection: %w", err)
+	}
+	resp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if selected != "" {
+		resp += "Sec-WebSocket-Protocol: " + selected + "\r\n"
+	}
+	if _, err := io.WriteString(nc, resp+"\r\n"); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("writing handshake: %w", err)
+	}
+	return &Conn{rwc: nc, br: brw.Reader, Subprotocol: selected}, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID)) //nolint:gosec
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// WriteMessage sends one message of type op (OpText or OpBinary).
+func (c *Conn) WriteMessage(op int, data []byte) error {
+	return c.writeFrame(op, data)
+}
+
+// WriteClose starts the closing handshake with code and reason. The peer's
+// reply arrives as a *CloseError from ReadMessage.
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return c.writeFrame(opClose, append(payload, reason...))
+}
+
+// Close closes the underlying connection without a closing handshake.
+func (c *Conn) Close() error {
+	return c.rwc.Close()
+}
+
+// ReadMessage returns the next data message, answering pings on the way. A
+// close frame, or the connection going away, is reported as *CloseError.
+func (c *Conn) ReadMessage() (op int, data []byte, err error) {
+	msgOp := -1
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, &CloseError{Code: CloseAbnormal, Text: err.Error()}
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, &CloseError{Code: CloseAbnormal, Text: err.Error()}
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ce := &CloseError{Code: CloseNoStatus}
+			if len(payload) >= 2 {
+				ce.Code = int(binary.BigEndian.Uint16(payload))
+				ce.Text = string(payload[2:])
+			}
+			c.wmu.Lock()
+			sent := c.closeSent
+			c.wmu.Unlock()
+			if !sent {
+				reply := ce.Code
+				if reply == CloseNoStatus {
+					reply = CloseNormal
+				}
+				c.WriteClose(reply, "")
+			}
+			c.rwc.Close()
+			return 0, nil, ce
+		case opContinuation:
+			if msgOp < 0 {
+				return 0, nil, c.fail("continuation frame without a message")
+			}
+		case OpText, OpBinary:
+			if msgOp >= 0 {
+				return 0, nil, c.fail("new message before the last one finished")
+			}
+			msgOp = op
+		default:
+			return 0, nil, c.fail(fmt.Sprintf("unknown opcode %d", op))
+		}
+		data = append(data, payload...)
+		if len(data) > maxMessageSize {
+			return 0, nil, c.fail("message too large")
+		}
+		if fin {
+			return msgOp, data, nil
+		}
+	}
+}
+
+// fail closes the connection after a protocol error.
+func (c *Conn) fail(reason string) error {
+	c.WriteClose(1002, reason)
+	c.rwc.Close()
+	return &CloseError{Code: CloseAbnormal, Text: "protocol error: " + reason}
+}
+
+func (c *Conn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = hdr[0]&0x80 != 0
+	op = int(hdr[0] & 0x0f)
+	masked := hdr[1]&0x80 != 0
+	n := uint64(hdr[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxMessageSize {
+		return false, 0, nil, fmt.Errorf("frame of %d bytes is too large", n)
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+func (c *Conn) writeFrame(op int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return errors.New("websocket: write after close")
+	}
+	if op == opClose {
+		c.closeSent = true
+	}
+
+	frame := []byte{0x80 | byte(op), 0}
+	n := len(payload)
+	switch {
+	case n < 126:
+		frame[1] = byte(n)
+	case n <= 0xffff:
+		frame[1] = 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame[1] = 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if c.client {
+		frame[1] |= 0x80
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+	_, err := c.rwc.Write(frame)
+	return err
+}
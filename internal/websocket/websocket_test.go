@@ -0,0 +1,138 @@
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// echoServer echoes messages back, upper-cased, pinging the client before
+// each reply. A "bye" message makes it close with code 4000 and "drop"
+// makes it drop the connection.
+func echoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Accept(w, r, "chat")
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			switch string(data) {
+			case "bye":
+				conn.WriteClose(4000, "bye")
+				conn.ReadMessage()
+				return
+			case "drop":
+				return
+			}
+			conn.writeFrame(opPing, []byte("p"))
+			conn.WriteMessage(op, bytes.ToUpper(data))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dial(t *testing.T, url string, subprotocols ...string) *Conn {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, HTTPURL(url), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, resp, err := Dial(http.DefaultClient, req, subprotocols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestHTTPURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"ws://a/b":        "http://a/b",
+		"wss://a:8443/b":  "https://a:8443/b",
+		"http://a/b":      "http://a/b",
+		"unix:///s.sock:": "unix:///s.sock:",
+	} {
+		if got := HTTPURL(in); got != want {
+			t.Errorf("HTTPURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDial_Echo(t *testing.T) {
+	srv := echoServer(t)
+	conn := dial(t, "ws"+strings.TrimPrefix(srv.URL, "http"), "other", "chat")
+	if conn.Subprotocol != "chat" {
+		t.Errorf("subprotocol = %q, want chat", conn.Subprotocol)
+	}
+
+	big := strings.Repeat("x", 70000) // needs a 64-bit length
+	for _, msg := range []string{"hello", strings.Repeat("y", 300), big} {
+		if err := conn.WriteMessage(OpText, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		op, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op != OpText || string(data) != strings.ToUpper(msg) {
+			t.Errorf("reply to %.10q = %d %.10q", msg, op, data)
+		}
+	}
+
+	if err := conn.WriteClose(CloseNormal, ""); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := conn.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || !ce.Normal() {
+		t.Errorf("after close: %v, want a normal close", err)
+	}
+}
+
+func TestReadMessage_Close(t *testing.T) {
+	srv := echoServer(t)
+
+	conn := dial(t, srv.URL)
+	conn.WriteMessage(OpText, []byte("bye"))
+	_, _, err := conn.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != 4000 || ce.Text != "bye" || ce.Normal() {
+		t.Errorf("server close: %v, want code 4000", err)
+	}
+
+	conn = dial(t, srv.URL)
+	conn.WriteMessage(OpText, []byte("drop"))
+	_, _, err = conn.ReadMessage()
+	if !errors.As(err, &ce) || ce.Code != CloseAbnormal {
+		t.Errorf("dropped connection: %v, want code %d", err, CloseAbnormal)
+	}
+}
+
+func TestDial_NotUpgraded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, resp, err := Dial(http.DefaultClient, req, nil)
+	if err == nil || !strings.Contains(err.Error(), "got 403") {
+		t.Errorf("err = %v, want a status error", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("resp = %v, want the 403 response", resp)
+	}
+}
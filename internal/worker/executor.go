@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/jvreagan/perf-test/internal/config"
 	"github.com/jvreagan/perf-test/internal/data"
+	"github.com/jvreagan/perf-test/internal/httpclient"
 	"github.com/jvreagan/perf-test/internal/metrics"
 )
 
@@ -23,6 +26,10 @@ type Executor struct {
 	totalWeight int
 	gen         *data.Generator
 	client      *http.Client
+	clientFor   func(config.Endpoint) *http.Client
+	flow        bool
+	grpc        map[*config.GRPCConfig]*grpcCall // set by PrepareGRPC
+	dial        func(ctx context.Context, network, addr string) (net.Conn, error)
 }
 
 // NewExecutor creates an Executor with pre-computed cumulative weights.
@@ -46,6 +53,52 @@ func NewExecutor(endpoints []config.Endpoint, gen *data.Generator, client *http.
 	}
 }
 
+// SetFlow makes workers request endpoints in order instead of by weight.
+// Call it before any worker starts.
+func (e *Executor) SetFlow(on bool) {
+	e.flow = on
+}
+
+// SetClientFor makes Execute send each endpoint's requests with the client
+// f returns for it, instead of the one passed to NewExecutor. Call it before
+// any worker starts.
+func (e *Executor) SetClientFor(f func(config.Endpoint) *http.Client) {
+	e.clientFor = f
+}
+
+// SetDial makes tcp and udp endpoints connect with f instead of a plain
+// net.Dialer. Call it before any worker starts.
+func (e *Executor) SetDial(f func(ctx context.Context, network, addr string) (net.Conn, error)) {
+	e.dial = f
+}
+
+// WithClientFor returns a copy of e that sends requests with the clients f
+// returns, for a worker with its own connections. e is unchanged.
+func (e *Executor) WithClientFor(f func(config.Endpoint) *http.Client) *Executor {
+	c := *e
+	c.clientFor = f
+	return &c
+}
+
+// WithDial returns a copy of e whose tcp and udp endpoints connect with f,
+// for a worker with its own source address. e is unchanged.
+func (e *Executor) WithDial(f func(ctx context.Context, network, addr string) (net.Conn, error)) *Executor {
+	c := *e
+	c.dial = f
+	return &c
+}
+
+// Flow reports whether endpoints are requested in order.
+func (e *Executor) Flow() bool {
+	return e.flow
+}
+
+// EndpointAt returns the endpoint for the given step of a flow, wrapping
+// around after the last one.
+func (e *Executor) EndpointAt(step int) config.Endpoint {
+	return e.endpoints[step%len(e.endpoints)]
+}
+
 // SelectEndpoint picks an endpoint using weighted random selection (binary search).
 func (e *Executor) SelectEndpoint() config.Endpoint {
 	if len(e.endpoints) == 1 {
@@ -59,33 +112,106 @@ func (e *Executor) SelectEndpoint() config.Endpoint {
 	return e.endpoints[idx]
 }
 
-// Execute performs a single HTTP request and returns the Result.
-func (e *Executor) Execute(ctx context.Context, ep config.Endpoint) metrics.Result {
-	url := e.gen.Generate(ep.URL)
-	method := ep.Method
+// Request is an endpoint with its templates evaluated, ready to send.
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+}
 
-	var bodyReader io.Reader
+// Render evaluates ep's URL, headers and body templates. Each call generates
+// fresh random values. A graphql endpoint's body is its operation as JSON.
+func (e *Executor) Render(ep config.Endpoint) Request {
+	r := Request{
+		Method: ep.Method,
+		URL:    e.gen.Generate(ep.URL),
+		Header: make(http.Header, len(ep.Headers)),
+	}
 	if ep.Body != "" {
-		body := e.gen.Generate(ep.Body)
-		bodyReader = strings.NewReader(body)
+		r.Body = e.gen.Generate(ep.Body)
+	}
+	for k, v := range ep.Headers {
+		r.Header.Set(k, e.gen.Generate(v))
+	}
+	if ep.GraphQL != nil {
+		r.Body = graphQLBody(ep.GraphQL, e.gen.Generate(ep.GraphQL.Variables))
+		if r.Header.Get("Content-Type") == "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+	}
+	return r
+}
+
+// NewHTTPRequest builds the *http.Request for a rendered request. A
+// unix:///path.sock:/path URL becomes a request for path over the socket,
+// with Host "localhost".
+func (r Request) NewHTTPRequest(ctx context.Context) (*http.Request, error) {
+	var bodyReader io.Reader
+	if r.Body != "" {
+		bodyReader = strings.NewReader(r.Body)
+	}
+	target := r.URL
+	socket, path, unix := config.SplitUnixURL(r.URL)
+	if unix {
+		target = httpclient.UnixURL(socket, path)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, target, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	if unix {
+		req.Host = "localhost"
+	}
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	return req, nil
+}
+
+// CheckExpect reports whether a response with the given status meets ep's
+// expectations.
+func CheckExpect(ep config.Endpoint, status int) error {
+	if ep.Expect.Status != 0 && status != ep.Expect.Status {
+		return fmt.Errorf("expected status %d, got %d", ep.Expect.Status, status)
+	}
+	return nil
+}
+
+// Execute performs a single HTTP request and returns the Result.
+// For a websocket endpoint it runs one session instead, and for a grpc
+// endpoint it makes one call; a tcp or udp endpoint makes one exchange on a
+// new connection. A graphql endpoint's response is checked for errors, and
+// a response with stream settings is read as a stream of events.
+func (e *Executor) Execute(ctx context.Context, ep config.Endpoint) metrics.Result {
+	client := e.clientForEndpoint(ep)
+	switch ep.Type {
+	case "websocket":
+		return e.executeWebSocket(ctx, ep, client)
+	case "grpc":
+		return e.executeGRPC(ctx, ep, client)
+	case "graphql":
+		return e.executeGraphQL(ctx, ep, client)
+	case "tcp", "udp":
+		return e.executeSocket(ctx, ep, client)
+	}
+	if ep.Stream != nil {
+		return e.executeStream(ctx, ep, client)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	var conns connCounter
+	req, err := e.Render(ep).NewHTTPRequest(httptrace.WithClientTrace(ctx, conns.trace()))
 	if err != nil {
 		return metrics.Result{
 			EndpointName: ep.Name,
-			Error:        fmt.Errorf("building request: %w", err),
+			Error:        err,
 			Timestamp:    time.Now(),
 			Success:      false,
 		}
 	}
 
-	for k, v := range ep.Headers {
-		req.Header.Set(k, e.gen.Generate(v))
-	}
-
 	start := time.Now()
-	resp, err := e.client.Do(req)
+	resp, err := client.Do(req)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -95,6 +221,8 @@ func (e *Executor) Execute(ctx context.Context, ep config.Endpoint) metrics.Resu
 			Error:        err,
 			Timestamp:    start,
 			Success:      false,
+			ConnsOpened:  conns.opened,
+			ConnsReused:  conns.reused,
 		}
 	}
 	defer resp.Body.Close()
@@ -102,11 +230,7 @@ func (e *Executor) Execute(ctx context.Context, ep config.Endpoint) metrics.Resu
 	body, _ := io.ReadAll(resp.Body)
 	bytesReceived := int64(len(body))
 
-	success := true
-	if ep.Expect.Status != 0 && resp.StatusCode != ep.Expect.Status {
-		success = false
-		err = fmt.Errorf("expected status %d, got %d", ep.Expect.Status, resp.StatusCode)
-	}
+	err = CheckExpect(ep, resp.StatusCode)
 
 	return metrics.Result{
 		EndpointName:  ep.Name,
@@ -115,6 +239,35 @@ func (e *Executor) Execute(ctx context.Context, ep config.Endpoint) metrics.Resu
 		BytesReceived: bytesReceived,
 		Error:         err,
 		Timestamp:     start,
-		Success:       success,
+		Success:       err == nil,
+		Protocol:      resp.Proto,
+		ConnsOpened:   conns.opened,
+		ConnsReused:   conns.reused,
+	}
+}
+
+// clientForEndpoint returns the client to send ep's requests with.
+func (e *Executor) clientForEndpoint(ep config.Endpoint) *http.Client {
+	if e.clientFor != nil {
+		return e.clientFor(ep)
+	}
+	return e.client
+}
+
+// connCounter counts the connections a request (and its redirects) got,
+// by whether they were newly opened or reused from the pool.
+type connCounter struct {
+	opened, reused int
+}
+
+func (c *connCounter) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.reused++
+			} else {
+				c.opened++
+			}
+		},
 	}
 }
@@ -2,12 +2,15 @@ package worker
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/jvreagan/perf-test/internal/config"
 	"github.com/jvreagan/perf-test/internal/data"
+	"github.com/jvreagan/perf-test/internal/httpclient"
 )
 
 func TestExecutor_SelectEndpoint_SingleEndpoint(t *testing.T) {
@@ -106,3 +109,114 @@ func TestExecutor_Execute_CtxCancel(t *testing.T) {
 		t.Error("expected failure with cancelled context")
 	}
 }
+
+func TestExecutor_Render(t *testing.T) {
+	gen := data.NewGenerator(map[string]string{"base_url": "http://api", "token": "abc"})
+	exec := NewExecutor(nil, gen, nil)
+	ep := config.Endpoint{
+		Method:  "POST",
+		URL:     "${base_url}/items/${random.int(3,3)}",
+		Headers: map[string]string{"authorization": "Bearer ${token}"},
+		Body:    `{"n":${random.int(5,5)}}`,
+	}
+	r := exec.Render(ep)
+	if r.Method != "POST" || r.URL != "http://api/items/3" || r.Body != `{"n":5}` {
+		t.Errorf("Render = %+v", r)
+	}
+	if got := r.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Errorf("Authorization = %q", got)
+	}
+
+	req, err := r.NewHTTPRequest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.String() != r.URL || req.Header.Get("Authorization") != "Bearer abc" || req.ContentLength != 7 {
+		t.Errorf("request = %s %v (length %d)", req.URL, req.Header, req.ContentLength)
+	}
+}
+
+func TestCheckExpect(t *testing.T) {
+	ep := makeEndpoint("e", "GET", "http://x", 1, 201)
+	if err := CheckExpect(ep, 201); err != nil {
+		t.Errorf("matching status: %v", err)
+	}
+	if err := CheckExpect(ep, 200); err == nil {
+		t.Error("expected error for mismatched status")
+	}
+	if err := CheckExpect(makeEndpoint("e", "GET", "http://x", 1, 0), 500); err != nil {
+		t.Errorf("no expectation: %v", err)
+	}
+}
+
+func TestExecutor_Execute_CountsConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	ep := makeEndpoint("test", "GET", srv.URL, 1, 200)
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), srv.Client())
+
+	first := exec.Execute(context.Background(), ep)
+	second := exec.Execute(context.Background(), ep)
+	if first.ConnsOpened != 1 || first.ConnsReused != 0 {
+		t.Errorf("first request: opened %d, reused %d; want 1, 0", first.ConnsOpened, first.ConnsReused)
+	}
+	if second.ConnsOpened != 0 || second.ConnsReused != 1 {
+		t.Errorf("second request: opened %d, reused %d; want 0, 1", second.ConnsOpened, second.ConnsReused)
+	}
+}
+
+func TestExecutor_WithClientFor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	ep := makeEndpoint("test", "GET", srv.URL, 1, 200)
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), http.DefaultClient)
+	var used bool
+	vu := exec.WithClientFor(func(config.Endpoint) *http.Client {
+		used = true
+		return srv.Client()
+	})
+	if r := vu.Execute(context.Background(), ep); !r.Success || !used {
+		t.Errorf("success = %v, client used = %v", r.Success, used)
+	}
+	if exec.clientFor != nil {
+		t.Error("WithClientFor changed the original executor")
+	}
+}
+
+func TestExecutor_Execute_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &httptest.Server{Listener: ln, Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/items" || r.URL.RawQuery != "page=2" || r.Host != "localhost" {
+			t.Errorf("server got host %q, url %q", r.Host, r.URL)
+		}
+		w.Write([]byte("ok"))
+	})}}
+	srv.Start()
+	defer srv.Close()
+
+	client, err := httpclient.New(config.HTTPConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := makeEndpoint("unix", "GET", "unix://"+socket+":/items?page=${n}", 1, 200)
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(map[string]string{"n": "2"}), client)
+	for i := range 2 {
+		r := exec.Execute(context.Background(), ep)
+		if !r.Success || r.BytesReceived != 2 {
+			t.Fatalf("request %d: success = %v, bytes = %d, err = %v", i, r.Success, r.BytesReceived, r.Error)
+		}
+		if r.ConnsOpened+r.ConnsReused != 1 || (i == 1 && r.ConnsReused != 1) {
+			t.Errorf("request %d: opened %d, reused %d", i, r.ConnsOpened, r.ConnsReused)
+		}
+	}
+}
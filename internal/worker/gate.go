@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Gate lets the engine pause request dispatch without tearing down workers.
+// A nil Gate is always open. It is safe for concurrent use.
+type Gate struct {
+	mu     sync.Mutex
+	closed chan struct{} // non-nil while paused; closed on resume
+}
+
+// NewGate returns an open Gate.
+func NewGate() *Gate {
+	return &Gate{}
+}
+
+// Pause closes the gate so that Wait blocks. Returns false if already paused.
+func (g *Gate) Pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed != nil {
+		return false
+	}
+	g.closed = make(chan struct{})
+	return true
+}
+
+// Resume reopens the gate, releasing any blocked callers. Returns false if not paused.
+func (g *Gate) Resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed == nil {
+		return false
+	}
+	close(g.closed)
+	g.closed = nil
+	return true
+}
+
+// Paused reports whether the gate is currently closed.
+// Safe to call on a nil Gate (returns false).
+func (g *Gate) Paused() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed != nil
+}
+
+// Wait blocks while the gate is paused. Returns false if ctx was cancelled first.
+// Safe to call on a nil Gate (returns true immediately).
+func (g *Gate) Wait(ctx context.Context) bool {
+	if g == nil {
+		return true
+	}
+	g.mu.Lock()
+	ch := g.closed
+	g.mu.Unlock()
+	if ch == nil {
+		return true
+	}
+	select {
+	case <-ch:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
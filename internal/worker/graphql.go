@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/metrics"
+)
+
+// graphQLBody encodes a GraphQL request: the query, the rendered variables
+// and the operation name. Variables that are not valid JSON are copied in
+// as they are, so the body shows what was rendered; executeGraphQL refuses
+// to send it.
+func graphQLBody(g *config.GraphQLConfig, vars string) string {
+	var b strings.Builder
+	q, _ := json.Marshal(g.Query)
+	b.WriteString(`{"query":`)
+	b.Write(q)
+	if vars = strings.TrimSpace(vars); vars != "" {
+		b.WriteString(`,"variables":`)
+		b.WriteString(vars)
+	}
+	if g.OperationName != "" {
+		op, _ := json.Marshal(g.OperationName)
+		b.WriteString(`,"operationName":`)
+		b.Write(op)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// CheckGraphQL reports the errors in a GraphQL response body, if any.
+// A response must be a JSON object with data or errors.
+func CheckGraphQL(body []byte) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("graphql response is not JSON: %w", err)
+	}
+	switch n := len(resp.Errors); {
+	case n == 1:
+		return fmt.Errorf("graphql error: %s", resp.Errors[0].Message)
+	case n > 1:
+		return fmt.Errorf("graphql error: %s (and %d more)", resp.Errors[0].Message, n-1)
+	case resp.Data == nil:
+		return errors.New("graphql response has neither data nor errors")
+	}
+	return nil
+}
+
+// executeGraphQL sends a graphql endpoint's operation and checks the
+// response for errors. The Result is labelled with the operation's name.
+func (e *Executor) executeGraphQL(ctx context.Context, ep config.Endpoint, client *http.Client) metrics.Result {
+	result := metrics.Result{EndpointName: ep.Name, Timestamp: time.Now()}
+	if ep.GraphQL == nil {
+		result.Error = fmt.Errorf("endpoint %q has no graphql settings", ep.Name)
+		return result
+	}
+	result.Operation = ep.GraphQL.Operation()
+
+	r := e.Render(ep)
+	if !json.Valid([]byte(r.Body)) {
+		result.Error = errors.New("graphql.variables did not render to valid JSON; check that templated strings are quoted")
+		return result
+	}
+	var conns connCounter
+	req, err := r.NewHTTPRequest(httptrace.WithClientTrace(ctx, conns.trace()))
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.Timestamp = start
+	result.ConnsOpened, result.ConnsReused = conns.opened, conns.reused
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Error = err
+		return result
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	result.Duration = time.Since(start)
+	result.StatusCode = resp.StatusCode
+	result.Protocol = resp.Proto
+	result.BytesReceived = int64(len(body))
+
+	if err != nil {
+		result.Error = fmt.Errorf("reading response body: %w", err)
+	} else if result.Error = CheckExpect(ep, resp.StatusCode); result.Error == nil {
+		result.Error = CheckGraphQL(body)
+	}
+	result.Success = result.Error == nil
+	return result
+}
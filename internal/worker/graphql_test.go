@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+)
+
+// graphQLServer answers GetUser with the id variable, "missing" with an
+// errors array, and anything else with a 400.
+func graphQLServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query         string         `json:"query"`
+			Variables     map[string]any `json:"variables"`
+			OperationName string         `json:"operationName"`
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" ||
+			json.NewDecoder(r.Body).Decode(&req) != nil || !strings.Contains(req.Query, "GetUser") {
+			http.Error(w, `{"errors":[{"message":"bad request"}]}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if req.Variables["id"] == "missing" {
+			fmt.Fprint(w, `{"data":{"user":null},"errors":[{"message":"user not found"},{"message":"again"}]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"user":{"id":%q,"op":%q}}}`, req.Variables["id"], req.OperationName)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func graphQLEndpoint(url, vars string) config.Endpoint {
+	return config.Endpoint{Name: "user", Type: "graphql", Method: "POST", URL: url, Expect: config.ExpectConfig{Status: 200},
+		GraphQL: &config.GraphQLConfig{
+			Query:         "query GetUser($id: ID!) { user(id: $id) { id } }\nquery Other { viewer { id } }",
+			Variables:     vars,
+			OperationName: "GetUser",
+		}}
+}
+
+func TestExecutor_Execute_GraphQL(t *testing.T) {
+	srv := graphQLServer(t)
+	ep := graphQLEndpoint(srv.URL+"/graphql", `{"id": "${id}"}`)
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(map[string]string{"id": "u-1"}), srv.Client())
+
+	r := exec.Execute(context.Background(), ep)
+	if !r.Success {
+		t.Fatalf("request failed: %v", r.Error)
+	}
+	if r.Operation != "GetUser" || r.EndpointName != "user" || r.StatusCode != 200 || r.BytesReceived == 0 {
+		t.Errorf("operation = %q, endpoint = %q, status = %d, bytes = %d", r.Operation, r.EndpointName, r.StatusCode, r.BytesReceived)
+	}
+
+	body := exec.Render(ep).Body
+	want := `{"query":"query GetUser($id: ID!) { user(id: $id) { id } }\nquery Other { viewer { id } }","variables":{"id": "u-1"},"operationName":"GetUser"}`
+	if body != want {
+		t.Errorf("body = %s\nwant   %s", body, want)
+	}
+}
+
+func TestExecutor_Execute_GraphQLFailures(t *testing.T) {
+	srv := graphQLServer(t)
+	tests := []struct {
+		name string
+		ep   config.Endpoint
+		err  string
+	}{
+		{"errors array", graphQLEndpoint(srv.URL, `{"id":"missing"}`), "graphql error: user not found (and 1 more)"},
+		{"status", func() config.Endpoint {
+			ep := graphQLEndpoint(srv.URL, "")
+			ep.GraphQL.Query = "{ viewer { id } }"
+			return ep
+		}(), "expected status 200, got 400"},
+		{"unquoted variable", graphQLEndpoint(srv.URL, `{"id": ${random.uuid}}`), "graphql.variables did not render to valid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := NewExecutor([]config.Endpoint{tt.ep}, data.NewGenerator(nil), srv.Client())
+			r := exec.Execute(context.Background(), tt.ep)
+			if r.Success || r.Error == nil || !strings.Contains(r.Error.Error(), tt.err) {
+				t.Errorf("success = %v, error = %v; want %q", r.Success, r.Error, tt.err)
+			}
+			if r.Operation == "" {
+				t.Error("failed request not labelled with its operation")
+			}
+		})
+	}
+}
+
+func TestCheckGraphQL(t *testing.T) {
+	tests := []struct {
+		body, want string // want "" means no error
+	}{
+		{`{"data":{"ok":true}}`, ""},
+		{`{"data":null,"errors":[]}`, ""},
+		{`{"errors":[{"message":"denied","path":["user"]}]}`, "graphql error: denied"},
+		{`{}`, "neither data nor errors"},
+		{`<html>`, "not JSON"},
+	}
+	for _, tt := range tests {
+		err := CheckGraphQL([]byte(tt.body))
+		if (err == nil) != (tt.want == "") || err != nil && !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("CheckGraphQL(%s) = %v, want %q", tt.body, err, tt.want)
+		}
+	}
+}
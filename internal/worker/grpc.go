@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/grpc"
+	"github.com/jvreagan/perf-test/internal/metrics"
+)
+
+// grpcCall is a grpc endpoint's method, loaded by PrepareGRPC.
+type grpcCall struct {
+	method *grpc.Method
+	expect grpc.Code
+}
+
+// PrepareGRPC loads the message types of each grpc endpoint's method, from
+// its protoset or by server reflection. Call it before any worker starts.
+func (e *Executor) PrepareGRPC(ctx context.Context) error {
+	for _, ep := range e.endpoints {
+		if ep.Type != "grpc" || ep.GRPC == nil || e.grpc[ep.GRPC] != nil {
+			continue
+		}
+		call, err := e.loadGRPC(ctx, ep)
+		if err != nil {
+			return fmt.Errorf("endpoint %q: %w", ep.Name, err)
+		}
+		if e.grpc == nil {
+			e.grpc = make(map[*config.GRPCConfig]*grpcCall)
+		}
+		e.grpc[ep.GRPC] = call
+	}
+	return nil
+}
+
+func (e *Executor) loadGRPC(ctx context.Context, ep config.Endpoint) (*grpcCall, error) {
+	name := strings.TrimPrefix(ep.GRPC.Method, "/")
+	var reg *grpc.Registry
+	var err error
+	if ep.GRPC.Protoset != "" {
+		reg, err = grpc.LoadProtoset(ep.GRPC.Protoset)
+	} else {
+		// Reflection requests go to the endpoint's server with its metadata.
+		newRequest := func(method string, body []byte) (*http.Request, error) {
+			r := e.Render(ep)
+			r.Method = http.MethodPost
+			r.URL = grpcURL(r.URL, method)
+			r.Body = string(body)
+			return r.NewHTTPRequest(ctx)
+		}
+		service, _, _ := strings.Cut(name, "/")
+		reg, err = grpc.Reflect(e.clientForEndpoint(ep), newRequest, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := reg.Method(name)
+	if err != nil {
+		return nil, err
+	}
+	if m.ClientStreaming || m.ServerStreaming {
+		return nil, fmt.Errorf("grpc method %s is streaming; only unary methods are supported", name)
+	}
+	call := &grpcCall{method: m}
+	if ep.GRPC.ExpectCode != "" {
+		if call.expect, err = grpc.ParseCode(ep.GRPC.ExpectCode); err != nil {
+			return nil, err
+		}
+	}
+	return call, nil
+}
+
+// grpcURL returns the URL of method on the server at base, a grpc://,
+// grpcs://, http://, https:// or unix:// URL.
+func grpcURL(base, method string) string {
+	base = grpc.HTTPURL(base)
+	if socket, _, ok := config.SplitUnixURL(base); ok {
+		return config.UnixPrefix + socket + ":/" + method
+	}
+	return strings.TrimSuffix(base, "/") + "/" + method
+}
+
+// executeGRPC makes one unary call for a grpc endpoint.
+func (e *Executor) executeGRPC(ctx context.Context, ep config.Endpoint, client *http.Client) metrics.Result {
+	result := metrics.Result{EndpointName: ep.Name, Timestamp: time.Now(), Protocol: "gRPC"}
+	call := e.grpc[ep.GRPC]
+	if call == nil {
+		result.Error = fmt.Errorf("grpc method for endpoint %q was not loaded", ep.Name)
+		return result
+	}
+
+	r := e.Render(ep)
+	msg, err := call.method.Input.Marshal([]byte(cmp.Or(strings.TrimSpace(r.Body), "{}")))
+	if err != nil {
+		result.Error = fmt.Errorf("building %s: %w", call.method.Input.Name, err)
+		return result
+	}
+	r.Method = http.MethodPost
+	r.URL = grpcURL(r.URL, call.method.Name)
+	r.Body = string(grpc.Frame(msg))
+
+	if d := ep.GRPC.Deadline.Duration; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	var conns connCounter
+	req, err := r.NewHTTPRequest(httptrace.WithClientTrace(ctx, conns.trace()))
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	start := time.Now()
+	resp, err := grpc.Invoke(client, req)
+	result.Duration = time.Since(start)
+	result.Timestamp = start
+	result.ConnsOpened, result.ConnsReused = conns.opened, conns.reused
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if resp.HTTP != nil {
+		result.StatusCode = resp.HTTP.StatusCode
+	}
+	result.BytesReceived = resp.Size
+	result.Code = resp.Status.Code.String()
+	if resp.Status.Code != call.expect {
+		result.Error = fmt.Errorf("expected status %s, got %w", call.expect, &resp.Status)
+	}
+	result.Success = result.Error == nil
+	return result
+}
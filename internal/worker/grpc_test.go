@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+	"github.com/jvreagan/perf-test/internal/grpc"
+)
+
+const greeterProtoset = "../grpc/testdata/greeter.protoset"
+
+// greeterServer serves test.v1.Greeter/SayHello over h2c. It needs an
+// x-token of "secret", answers "missing" with NOT_FOUND and is slow to
+// answer "slow".
+func greeterServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		status := func(code int, msg string) {
+			w.Header().Set("Grpc-Status", strconv.Itoa(code))
+			w.Header().Set("Grpc-Message", msg)
+		}
+		if r.URL.Path != "/test.v1.Greeter/SayHello" {
+			status(12, "unknown method")
+			return
+		}
+		if r.Header.Get("X-Token") != "secret" {
+			status(16, "bad token")
+			return
+		}
+		// HelloRequest.name is field 1; the tests send nothing else.
+		var name string
+		if len(body) > 7 && body[5] == 0x0a {
+			name = string(body[7 : 7+int(body[6])])
+		}
+		switch name {
+		case "missing":
+			status(5, "no such greeting")
+			return
+		case "slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		reply := "hello " + name
+		w.Write(grpc.Frame(append([]byte{0x0a, byte(len(reply))}, reply...)))
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetHTTP1(true)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func h2cClient() *http.Client {
+	tr := &http.Transport{Protocols: new(http.Protocols)}
+	tr.Protocols.SetUnencryptedHTTP2(true)
+	return &http.Client{Transport: tr, Timeout: 5 * time.Second}
+}
+
+func grpcEndpoint(url, body string, g *config.GRPCConfig) config.Endpoint {
+	g.Method = "test.v1.Greeter/SayHello"
+	g.Protoset = greeterProtoset
+	return config.Endpoint{Name: "greet", Type: "grpc", URL: url, Body: body,
+		Headers: map[string]string{"x-token": "${token}"}, GRPC: g}
+}
+
+func TestExecutor_Execute_GRPC(t *testing.T) {
+	srv := greeterServer(t)
+	ep := grpcEndpoint("grpc://"+strings.TrimPrefix(srv.URL, "http://"), `{"name": "${who}"}`, &config.GRPCConfig{})
+	gen := data.NewGenerator(map[string]string{"who": "ada", "token": "secret"})
+	exec := NewExecutor([]config.Endpoint{ep}, gen, h2cClient())
+	if err := exec.PrepareGRPC(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := exec.Execute(context.Background(), ep)
+	if !r.Success {
+		t.Fatalf("call failed: %v", r.Error)
+	}
+	if r.Code != "OK" || r.Protocol != "gRPC" || r.StatusCode != http.StatusOK || r.ConnsOpened != 1 {
+		t.Errorf("code = %q, protocol = %q, status = %d, opened = %d", r.Code, r.Protocol, r.StatusCode, r.ConnsOpened)
+	}
+	if want := int64(5 + 2 + len("hello ada")); r.BytesReceived != want {
+		t.Errorf("bytes received = %d, want %d", r.BytesReceived, want)
+	}
+}
+
+func TestExecutor_Execute_GRPCFailures(t *testing.T) {
+	srv := greeterServer(t)
+	url := "grpc://" + strings.TrimPrefix(srv.URL, "http://")
+	tests := []struct {
+		name, body, token string
+		grpc              config.GRPCConfig
+		code, err         string
+		success           bool
+	}{
+		{name: "status", body: `{"name":"missing"}`, token: "secret", code: "NOT_FOUND", err: "expected status OK, got grpc status NOT_FOUND: no such greeting"},
+		{name: "expected status", body: `{"name":"missing"}`, token: "secret", grpc: config.GRPCConfig{ExpectCode: "NOT_FOUND"}, code: "NOT_FOUND", success: true},
+		{name: "metadata", body: `{"name":"ada"}`, token: "wrong", code: "UNAUTHENTICATED", err: "bad token"},
+		{name: "deadline", body: `{"name":"slow"}`, token: "secret", grpc: config.GRPCConfig{Deadline: config.Duration{Duration: 50 * time.Millisecond}},
+			code: "DEADLINE_EXCEEDED", err: "DEADLINE_EXCEEDED"},
+		{name: "bad body", body: `{"nmae":"ada"}`, token: "secret", err: `has no field "nmae"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := grpcEndpoint(url, tt.body, &tt.grpc)
+			exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(map[string]string{"token": tt.token}), h2cClient())
+			if err := exec.PrepareGRPC(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			r := exec.Execute(context.Background(), ep)
+			if r.Success != tt.success || r.Code != tt.code {
+				t.Errorf("success = %v, code = %q; want %v, %q (error %v)", r.Success, r.Code, tt.success, tt.code, r.Error)
+			}
+			if tt.err != "" && (r.Error == nil || !strings.Contains(r.Error.Error(), tt.err)) {
+				t.Errorf("error = %v, want it to contain %q", r.Error, tt.err)
+			}
+		})
+	}
+}
+
+func TestExecutor_PrepareGRPC(t *testing.T) {
+	ep := grpcEndpoint("grpc://localhost:1", "", &config.GRPCConfig{})
+	ep.GRPC.Method = "test.v1.Greeter/SayGoodbye"
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), h2cClient())
+	err := exec.PrepareGRPC(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "unknown grpc method test.v1.Greeter/SayGoodbye") {
+		t.Errorf("error = %v", err)
+	}
+
+	r := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), h2cClient()).Execute(context.Background(), ep)
+	if r.Success || r.Error == nil {
+		t.Error("call succeeded without its method loaded")
+	}
+}
+
+func TestGRPCURL(t *testing.T) {
+	tests := map[string]string{
+		"grpc://localhost:50051":             "http://localhost:50051/test.v1.Greeter/SayHello",
+		"grpcs://api.example.com/":           "https://api.example.com/test.v1.Greeter/SayHello",
+		"unix:///run/greeter.sock":           "unix:///run/greeter.sock:/test.v1.Greeter/SayHello",
+		"unix:///run/greeter.sock:/ignored/": "unix:///run/greeter.sock:/test.v1.Greeter/SayHello",
+	}
+	for base, want := range tests {
+		if got := grpcURL(base, "test.v1.Greeter/SayHello"); got != want {
+			t.Errorf("grpcURL(%q) = %q, want %q", base, got, want)
+		}
+	}
+}
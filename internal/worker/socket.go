@@ -0,0 +1,184 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/metrics"
+)
+
+// defaultReadTimeout bounds the wait for a tcp or udp response when
+// socket.read_timeout is not set.
+const defaultReadTimeout = 5 * time.Second
+
+// maxDatagram is the largest UDP payload.
+const maxDatagram = 65535
+
+// executeSocket makes one exchange for a tcp or udp endpoint: connect, send
+// the payload and read the response, if any. The client's timeout bounds
+// the connect. The Result's Duration covers the whole exchange; its Session
+// has the connect time and the round trip from sending to the end of the
+// response.
+func (e *Executor) executeSocket(ctx context.Context, ep config.Endpoint, client *http.Client) metrics.Result {
+	s := ep.Socket
+	if s == nil {
+		s = &config.SocketConfig{}
+	}
+	sess := &metrics.Session{}
+	result := metrics.Result{EndpointName: ep.Name, Timestamp: time.Now(), Protocol: strings.ToUpper(ep.Type), Session: sess}
+
+	payload := []byte(e.gen.Generate(s.Send))
+	var until []byte
+	if s.Encoding == "hex" {
+		var err error
+		if payload, err = config.DecodeHex(string(payload)); err != nil {
+			result.Error = fmt.Errorf("socket.send: %w", err)
+			return result
+		}
+		until, _ = config.DecodeHex(s.Until) // validated by config.Load
+	} else {
+		until = []byte(s.Until)
+	}
+	addr := strings.TrimPrefix(e.gen.Generate(ep.URL), ep.Type+"://")
+	addr = strings.TrimSuffix(addr, "/")
+
+	dialCtx := ctx
+	if client != nil && client.Timeout > 0 {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+	dial := e.dial
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+	start := time.Now()
+	result.Timestamp = start
+	conn, err := dial(dialCtx, ep.Type, addr)
+	sess.Connect = time.Since(start)
+	if err != nil {
+		result.Duration = sess.Connect
+		result.Error = err
+		return result
+	}
+	defer conn.Close()
+	result.ConnsOpened = 1
+
+	// Cancelling ctx, when the test stops, unblocks reads and writes.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	timeout := s.ReadTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultReadTimeout
+	}
+	sent := time.Now()
+	conn.SetDeadline(sent.Add(timeout))
+	if len(payload) > 0 {
+		if _, err := conn.Write(payload); err != nil {
+			result.Duration = time.Since(start)
+			result.Error = fmt.Errorf("sending: %w", err)
+			return result
+		}
+		sess.Sent = 1
+	}
+	if !s.Reads() {
+		result.Duration = time.Since(start)
+		result.Success = true
+		return result
+	}
+
+	var expect *regexp.Regexp
+	if s.Expect != "" {
+		expect = regexp.MustCompile(s.Expect) // validated by config.Load
+	}
+	format := func(b []byte) []byte {
+		if s.Encoding == "hex" {
+			return []byte(hex.EncodeToString(b))
+		}
+		return b
+	}
+	resp, err := readResponse(conn, ep.Type == "udp", func(resp []byte) bool {
+		switch {
+		case len(until) > 0:
+			return bytes.Contains(resp, until)
+		case s.ReadBytes > 0:
+			return len(resp) >= s.ReadBytes
+		case expect != nil:
+			return expect.Match(format(resp))
+		}
+		return ep.Type == "udp" // one datagram; tcp reads until the timeout
+	})
+	received := time.Now()
+	result.Duration = received.Sub(start)
+	result.BytesReceived = int64(len(resp))
+	if len(resp) > 0 {
+		sess.Received = 1
+	}
+	// Anything read past the end of the response is not part of it.
+	if i := bytes.Index(resp, until); len(until) > 0 && i >= 0 {
+		resp = resp[:i+len(until)]
+	} else if s.ReadBytes > 0 && len(resp) > s.ReadBytes {
+		resp = resp[:s.ReadBytes]
+	}
+	waitedOut := errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() == nil
+	switch {
+	case waitedOut && len(until) == 0 && s.ReadBytes == 0 && expect == nil && len(resp) > 0:
+		// Only read_timeout is set: whatever arrived in time is the response.
+	case waitedOut:
+		result.Error = fmt.Errorf("no complete response within %s (got %d bytes)", timeout, len(resp))
+	case err != nil && !errors.Is(err, io.EOF):
+		result.Error = fmt.Errorf("reading: %w", err)
+	case err != nil && (len(until) > 0 || s.ReadBytes > 0):
+		result.Error = fmt.Errorf("connection closed after %d bytes, before the response was complete", len(resp))
+	case expect != nil && !expect.Match(format(resp)):
+		result.Error = fmt.Errorf("response %q does not match %q", truncateResponse(format(resp)), s.Expect)
+	}
+	if result.Error == nil {
+		sess.RTTs = []time.Duration{received.Sub(sent)}
+	}
+	result.Success = result.Error == nil
+	return result
+}
+
+// readResponse reads from conn until done reports the response complete,
+// the peer closes the connection (io.EOF) or a read fails. Over udp each
+// read is one datagram.
+func readResponse(conn net.Conn, udp bool, done func(resp []byte) bool) ([]byte, error) {
+	var resp []byte
+	buf := make([]byte, 4096)
+	if udp {
+		buf = make([]byte, maxDatagram)
+	}
+	for {
+		n, err := conn.Read(buf)
+		resp = append(resp, buf[:n]...)
+		if n > 0 && done(resp) {
+			return resp, nil
+		}
+		if err != nil {
+			return resp, err
+		}
+	}
+}
+
+// truncateResponse shortens a response for an error message.
+func truncateResponse(b []byte) string {
+	const max = 64
+	if len(b) > max {
+		return string(b[:max]) + "..."
+	}
+	return string(b)
+}
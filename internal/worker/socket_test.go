@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+)
+
+// tcpServer answers each line with "+OK <line>\r\n", "quiet" with nothing
+// and "bye" by closing the connection after a partial reply.
+func tcpServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch line = strings.TrimRight(line, "\r\n"); line {
+					case "quiet":
+					case "bye":
+						conn.Write([]byte("+BY"))
+						return
+					default:
+						conn.Write([]byte("+OK " + line + "\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+	return "tcp://" + ln.Addr().String()
+}
+
+// udpServer echoes each datagram upper-cased, except "quiet".
+func udpServer(t *testing.T) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if string(buf[:n]) != "quiet" {
+				pc.WriteTo(bytes.ToUpper(buf[:n]), from)
+			}
+		}
+	}()
+	return "udp://" + pc.LocalAddr().String()
+}
+
+func socketEndpoint(typ, url string, s *config.SocketConfig) config.Endpoint {
+	return config.Endpoint{Name: typ, Type: typ, URL: url, Socket: s}
+}
+
+func TestExecutor_Execute_TCP(t *testing.T) {
+	url := tcpServer(t)
+	ep := socketEndpoint("tcp", url, &config.SocketConfig{Send: "GET ${key}\r\n", Until: "\r\n", Expect: `^\+OK GET k1`})
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(map[string]string{"key": "k1"}), nil)
+
+	r := exec.Execute(context.Background(), ep)
+	if !r.Success {
+		t.Fatalf("exchange failed: %v", r.Error)
+	}
+	s := r.Session
+	if r.Protocol != "TCP" || r.ConnsOpened != 1 || r.BytesReceived != int64(len("+OK GET k1\r\n")) {
+		t.Errorf("protocol = %q, opened = %d, bytes = %d", r.Protocol, r.ConnsOpened, r.BytesReceived)
+	}
+	if s.Sent != 1 || s.Received != 1 || len(s.RTTs) != 1 || s.Connect <= 0 || r.Duration < s.Connect+s.RTTs[0] {
+		t.Errorf("session = %+v, duration = %v", s, r.Duration)
+	}
+}
+
+func TestExecutor_Execute_TCPFailures(t *testing.T) {
+	url := tcpServer(t)
+	short := config.Duration{Duration: 50 * time.Millisecond}
+	tests := []struct {
+		name    string
+		s       config.SocketConfig
+		success bool
+		err     string
+	}{
+		{name: "read bytes", s: config.SocketConfig{Send: "ping\n", ReadBytes: 4, Expect: `^\+OK $`}, success: true},
+		{name: "hex", s: config.SocketConfig{Send: "70 69 6e 67 0a", Encoding: "hex", Until: "0d0a", Expect: "^2b4f4b2070696e670d0a$"}, success: true},
+		{name: "read timeout only", s: config.SocketConfig{Send: "ping\n", ReadTimeout: short}, success: true},
+		{name: "send only", s: config.SocketConfig{Send: "quiet\n"}, success: true},
+		{name: "mismatch", s: config.SocketConfig{Send: "ping\n", Until: "\n", Expect: "^-ERR"}, err: `response "+OK ping\r\n" does not match "^-ERR"`},
+		{name: "timeout", s: config.SocketConfig{Send: "quiet\n", Until: "\n", ReadTimeout: short}, err: "no complete response within 50ms (got 0 bytes)"},
+		{name: "closed early", s: config.SocketConfig{Send: "bye\n", Until: "\r\n"}, err: "connection closed after 3 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := socketEndpoint("tcp", url, &tt.s)
+			r := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), nil).Execute(context.Background(), ep)
+			if r.Success != tt.success {
+				t.Errorf("success = %v, error = %v", r.Success, r.Error)
+			}
+			if tt.err != "" && (r.Error == nil || !strings.Contains(r.Error.Error(), tt.err)) {
+				t.Errorf("error = %v, want it to contain %q", r.Error, tt.err)
+			}
+		})
+	}
+
+	ep := socketEndpoint("tcp", "tcp://127.0.0.1:1", &config.SocketConfig{Send: "ping\n"})
+	if r := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), nil).Execute(context.Background(), ep); r.Success || r.ConnsOpened != 0 {
+		t.Errorf("connecting to a closed port: success = %v, opened = %d", r.Success, r.ConnsOpened)
+	}
+}
+
+func TestExecutor_Execute_UDP(t *testing.T) {
+	url := udpServer(t)
+	tests := []struct {
+		name    string
+		s       config.SocketConfig
+		success bool
+	}{
+		{name: "one datagram", s: config.SocketConfig{Send: "ping", ReadTimeout: config.Duration{Duration: time.Second}}, success: true},
+		{name: "expect", s: config.SocketConfig{Send: "ping", Expect: "^PING$"}, success: true},
+		{name: "no reply", s: config.SocketConfig{Send: "quiet", Expect: "^QUIET$", ReadTimeout: config.Duration{Duration: 50 * time.Millisecond}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := socketEndpoint("udp", url, &tt.s)
+			r := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), nil).Execute(context.Background(), ep)
+			if r.Success != tt.success || r.Protocol != "UDP" {
+				t.Errorf("success = %v, protocol = %q, error = %v", r.Success, r.Protocol, r.Error)
+			}
+			if tt.success && (r.BytesReceived != 4 || len(r.Session.RTTs) != 1) {
+				t.Errorf("bytes = %d, rtts = %v", r.BytesReceived, r.Session.RTTs)
+			}
+		})
+	}
+}
+
+func TestExecutor_Execute_SocketStop(t *testing.T) {
+	url := tcpServer(t)
+	ep := socketEndpoint("tcp", url, &config.SocketConfig{Send: "quiet\n", Until: "\n"})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	r := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), nil).Execute(ctx, ep)
+	if r.Success || time.Since(start) > time.Second {
+		t.Errorf("success = %v after %v; want a failure soon after the context is cancelled", r.Success, time.Since(start))
+	}
+}
@@ -0,0 +1,175 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/metrics"
+)
+
+// executeStream sends an http endpoint's request and reads the response as
+// a stream of events, timing each, until the server ends it or a cap in
+// ep.Stream is reached. The Result's Duration covers the whole stream.
+func (e *Executor) executeStream(ctx context.Context, ep config.Endpoint, client *http.Client) metrics.Result {
+	st := ep.Stream
+	stream := &metrics.Stream{}
+	result := metrics.Result{EndpointName: ep.Name, Timestamp: time.Now(), Stream: stream}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var conns connCounter
+	var firstByte time.Time
+	trace := conns.trace()
+	trace.GotFirstResponseByte = func() { firstByte = time.Now() }
+	req, err := e.Render(ep).NewHTTPRequest(httptrace.WithClientTrace(ctx, trace))
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	// The client's timeout would cut the stream short, so it bounds only
+	// the wait for the response headers; max_duration bounds the rest.
+	c := *client
+	c.Timeout = 0
+	var timer *time.Timer
+	if client.Timeout > 0 {
+		timer = time.AfterFunc(client.Timeout, cancel)
+	}
+	var capped atomic.Bool
+	if d := st.MaxDuration.Duration; d > 0 {
+		limit := time.AfterFunc(d, func() {
+			capped.Store(true)
+			cancel()
+		})
+		defer limit.Stop()
+	}
+
+	start := time.Now()
+	result.Timestamp = start
+	resp, err := c.Do(req)
+	if timer != nil && !timer.Stop() && err == nil {
+		resp.Body.Close()
+		err = fmt.Errorf("waiting for response headers: timed out after %s", client.Timeout)
+	}
+	result.ConnsOpened, result.ConnsReused = conns.opened, conns.reused
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Error = err
+		if capped.Load() {
+			result.Error = fmt.Errorf("no response within max_duration %s", st.MaxDuration.Duration)
+		}
+		return result
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	result.Protocol = resp.Proto
+	if err := CheckExpect(ep, resp.StatusCode); err != nil {
+		result.Duration = time.Since(start)
+		result.Error = err
+		return result
+	}
+
+	var expect *regexp.Regexp
+	if st.Expect != "" {
+		expect = regexp.MustCompile(st.Expect) // validated by config.Load
+	}
+	matched := false
+	last := start
+	n, err := readEvents(resp.Body, st.Format, func(data []byte) bool {
+		now := time.Now()
+		if stream.Events == 0 {
+			stream.FirstEvent = now.Sub(start)
+		} else {
+			stream.Gaps = append(stream.Gaps, now.Sub(last))
+		}
+		last = now
+		stream.Events++
+		if expect != nil && !matched {
+			matched = expect.Match(data)
+		}
+		if st.MaxEvents > 0 && stream.Events >= st.MaxEvents {
+			stream.Capped = true
+			return false
+		}
+		return true
+	})
+	result.Duration = time.Since(start)
+	result.BytesReceived = n
+	if !firstByte.IsZero() {
+		stream.TTFB = firstByte.Sub(start)
+	}
+	if err != nil && capped.Load() {
+		stream.Capped = true
+		err = nil
+	}
+
+	switch {
+	case err != nil:
+		result.Error = fmt.Errorf("reading stream: %w", err)
+	case stream.Events == 0:
+		result.Error = errors.New("stream ended without an event")
+	case expect != nil && !matched:
+		result.Error = fmt.Errorf("no event matched %q", st.Expect)
+	}
+	result.Success = result.Error == nil
+	return result
+}
+
+// readEvents reads events from r in the given format, passing the data of
+// each to f until f returns false or r ends. It returns the bytes read.
+// Server-Sent Events are separated by blank lines, and their data is the
+// "data:" lines joined by newlines; events without data, such as
+// keep-alive comments, are skipped. In "lines" format each non-empty line
+// is an event.
+func readEvents(r io.Reader, format string, f func(data []byte) bool) (int64, error) {
+	br := bufio.NewReader(r)
+	var n int64
+	var data []byte
+	hasData := false
+	for {
+		line, err := br.ReadBytes('\n')
+		n += int64(len(line))
+		if len(line) == 0 && err != nil {
+			if err == io.EOF {
+				err = nil // an unterminated final event is discarded
+			}
+			return n, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		if format == "lines" {
+			if len(line) > 0 && !f(line) {
+				return n, nil
+			}
+			continue
+		}
+		if len(line) == 0 {
+			if hasData && !f(data) {
+				return n, nil
+			}
+			data, hasData = data[:0], false
+			continue
+		}
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		if string(field) != "data" {
+			continue // comments, event, id and retry fields
+		}
+		value = bytes.TrimPrefix(value, []byte(" "))
+		if hasData {
+			data = append(data, '\n')
+		}
+		data = append(data, value...)
+		hasData = true
+	}
+}
@@ -0,0 +1,137 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+)
+
+// sseServer streams ?n= events 10ms apart, after a keep-alive comment, then
+// ends the stream, or holds it open if ?hold is set.
+func sseServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		w.(http.Flusher).Flush()
+		n := 3
+		fmt.Sscan(r.URL.Query().Get("n"), &n)
+		for i := range n {
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprintf(w, "event: token\nid: %d\ndata: {\"i\":%d,\ndata: \"text\":\"tok%d\"}\n\n", i, i, i)
+			w.(http.Flusher).Flush()
+		}
+		if r.URL.Query().Has("hold") {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func streamEndpoint(url string, st *config.StreamConfig) config.Endpoint {
+	if st.Format == "" {
+		st.Format = "sse"
+	}
+	return config.Endpoint{Name: "events", Method: "GET", URL: url, Expect: config.ExpectConfig{Status: 200}, Stream: st}
+}
+
+func TestExecutor_Execute_Stream(t *testing.T) {
+	srv := sseServer(t)
+	ep := streamEndpoint(srv.URL+"/?n=${n}", &config.StreamConfig{Expect: `"text":"tok2"`})
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(map[string]string{"n": "4"}), srv.Client())
+
+	r := exec.Execute(context.Background(), ep)
+	if !r.Success {
+		t.Fatalf("stream failed: %v", r.Error)
+	}
+	s := r.Stream
+	if s.Events != 4 || len(s.Gaps) != 3 || s.Capped {
+		t.Errorf("events = %d, gaps = %d, capped = %v", s.Events, len(s.Gaps), s.Capped)
+	}
+	if s.TTFB <= 0 || s.FirstEvent < 10*time.Millisecond || s.FirstEvent < s.TTFB || r.Duration < s.FirstEvent+30*time.Millisecond {
+		t.Errorf("ttfb = %v, first event = %v, duration = %v", s.TTFB, s.FirstEvent, r.Duration)
+	}
+	for _, gap := range s.Gaps {
+		if gap < 5*time.Millisecond {
+			t.Errorf("gap = %v, want about 10ms", gap)
+		}
+	}
+	if r.StatusCode != 200 || r.BytesReceived == 0 || r.ConnsOpened != 1 {
+		t.Errorf("status = %d, bytes = %d, opened = %d", r.StatusCode, r.BytesReceived, r.ConnsOpened)
+	}
+}
+
+func TestExecutor_Execute_StreamCaps(t *testing.T) {
+	srv := sseServer(t)
+	tests := []struct {
+		name    string
+		query   string
+		st      config.StreamConfig
+		timeout time.Duration
+		events  int
+		err     string
+	}{
+		{name: "max events", query: "?n=10", st: config.StreamConfig{MaxEvents: 2}, events: 2},
+		{name: "max duration", query: "?n=2&hold", st: config.StreamConfig{MaxDuration: config.Duration{Duration: 80 * time.Millisecond}}, events: 2},
+		{name: "client timeout bounds only the headers", query: "?n=5", timeout: 20 * time.Millisecond, events: 5},
+		{name: "no match", query: "?n=2", st: config.StreamConfig{Expect: "tok9"}, events: 2, err: `no event matched "tok9"`},
+		{name: "no events", query: "?n=0", events: 0, err: "stream ended without an event"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := streamEndpoint(srv.URL+"/"+tt.query, &tt.st)
+			client := *srv.Client()
+			client.Timeout = tt.timeout
+			r := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), &client).Execute(context.Background(), ep)
+			if r.Stream.Events != tt.events {
+				t.Errorf("events = %d, want %d", r.Stream.Events, tt.events)
+			}
+			capped := tt.st.MaxEvents > 0 || tt.st.MaxDuration.Duration > 0
+			if r.Stream.Capped != capped {
+				t.Errorf("capped = %v, want %v", r.Stream.Capped, capped)
+			}
+			if tt.err == "" && !r.Success {
+				t.Errorf("stream failed: %v", r.Error)
+			}
+			if tt.err != "" && (r.Error == nil || !strings.Contains(r.Error.Error(), tt.err)) {
+				t.Errorf("error = %v, want it to contain %q", r.Error, tt.err)
+			}
+		})
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	tests := []struct {
+		format, in string
+		want       []string
+	}{
+		{"sse", "data: a\n\n: comment\n\nevent: x\ndata:b\ndata: c\r\n\r\ndata: unterminated", []string{"a", "b\nc"}},
+		{"sse", "id: 1\n\ndata:\n\n", []string{""}},
+		{"lines", "{\"a\":1}\n\n{\"b\":2}\r\n{\"c\":3}", []string{`{"a":1}`, `{"b":2}`, `{"c":3}`}},
+	}
+	for _, tt := range tests {
+		var got []string
+		n, err := readEvents(strings.NewReader(tt.in), tt.format, func(data []byte) bool {
+			got = append(got, string(data))
+			return true
+		})
+		if err != nil || n != int64(len(tt.in)) {
+			t.Errorf("%s %q: n = %d, err = %v", tt.format, tt.in, n, err)
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("%s %q: events = %q, want %q", tt.format, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/metrics"
+	"github.com/jvreagan/perf-test/internal/websocket"
+)
+
+// closeWait bounds how long a session waits for the server to answer its
+// close frame.
+const closeWait = time.Second
+
+// executeWebSocket runs one session for a websocket endpoint. The Result's
+// Duration is the time taken to connect; the rest is in its Session.
+func (e *Executor) executeWebSocket(ctx context.Context, ep config.Endpoint, client *http.Client) metrics.Result {
+	ws := ep.WebSocket
+	if ws == nil {
+		ws = &config.WebSocketConfig{Timeout: config.Duration{Duration: 10 * time.Second}}
+	}
+	sess := &metrics.Session{}
+	result := metrics.Result{EndpointName: ep.Name, Timestamp: time.Now(), Session: sess}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var conns connCounter
+	r := e.Render(ep)
+	r.Method = http.MethodGet
+	r.Body = ""
+	r.URL = websocket.HTTPURL(r.URL)
+	req, err := r.NewHTTPRequest(httptrace.WithClientTrace(ctx, conns.trace()))
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	// The client's timeout would cut the session short, so it bounds only
+	// the handshake.
+	c := *client
+	c.Timeout = 0
+	var timer *time.Timer
+	if client.Timeout > 0 {
+		timer = time.AfterFunc(client.Timeout, cancel)
+	}
+	conn, resp, err := websocket.Dial(&c, req, ws.Subprotocols)
+	if timer != nil && !timer.Stop() && err == nil {
+		conn.Close()
+		err = fmt.Errorf("websocket handshake: timed out after %s", client.Timeout)
+	}
+	result.Duration = time.Since(result.Timestamp)
+	sess.Connect = result.Duration
+	result.ConnsOpened, result.ConnsReused = conns.opened, conns.reused
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+		result.Protocol = resp.Proto
+	}
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	err = e.runSession(ctx, conn, ws, sess)
+	result.Error = err
+	result.Success = err == nil
+	return result
+}
+
+// runSession sends and receives on conn as ws describes, then closes it.
+func (e *Executor) runSession(ctx context.Context, conn *websocket.Conn, ws *config.WebSocketConfig, sess *metrics.Session) error {
+	msgs := make(chan []byte, 16)
+	done := make(chan struct{})
+	defer close(done)
+	var readErr error // set before msgs is closed
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				readErr = err
+				close(msgs)
+				return
+			}
+			select {
+			case msgs <- data:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// closed records why the server ended the session.
+	closed := func() error {
+		var ce *websocket.CloseError
+		if !errors.As(readErr, &ce) || !ce.Normal() {
+			sess.AbnormalClose = true
+			return readErr
+		}
+		return nil
+	}
+
+	var expect *regexp.Regexp
+	if ws.Expect != "" {
+		expect = regexp.MustCompile(ws.Expect) // validated with the config
+	}
+
+	// send sends the next message and, with an expectation, waits for the
+	// reply that matches it.
+	send := func() error {
+		sent := time.Now()
+		if err := conn.WriteMessage(websocket.OpText, []byte(e.gen.Generate(ws.Send))); err != nil {
+			return fmt.Errorf("sending message: %w", err)
+		}
+		sess.Sent++
+		if expect == nil {
+			return nil
+		}
+		timeout := time.NewTimer(ws.Timeout.Duration)
+		defer timeout.Stop()
+		for {
+			select {
+			case data, ok := <-msgs:
+				if !ok {
+					if err := closed(); err != nil {
+						return err
+					}
+					return fmt.Errorf("connection closed before a reply matching %q", ws.Expect)
+				}
+				sess.Received++
+				if expect.Match(data) {
+					sess.RTTs = append(sess.RTTs, time.Since(sent))
+					return nil
+				}
+			case <-timeout.C:
+				return fmt.Errorf("no reply matching %q within %s", ws.Expect, ws.Timeout.Duration)
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	err := func() error {
+		hold := time.NewTimer(ws.Hold.Duration)
+		defer hold.Stop()
+		var tick <-chan time.Time
+		if ws.Send != "" {
+			if err := send(); err != nil {
+				return err
+			}
+			if ws.Interval.Duration > 0 {
+				t := time.NewTicker(ws.Interval.Duration)
+				defer t.Stop()
+				tick = t.C
+			}
+		}
+		for {
+			select {
+			case <-hold.C:
+				return nil
+			case <-tick:
+				if err := send(); err != nil {
+					return err
+				}
+			case _, ok := <-msgs:
+				if !ok {
+					// The server ended the session early.
+					return closed()
+				}
+				sess.Received++
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}()
+
+	// Start the closing handshake, if the server has not, and wait for its
+	// reply.
+	conn.WriteClose(websocket.CloseNormal, "")
+	wait := time.NewTimer(closeWait)
+	defer wait.Stop()
+drain:
+	for {
+		select {
+		case _, ok := <-msgs:
+			if !ok {
+				break drain
+			}
+			sess.Received++
+		case <-wait.C:
+			break drain
+		}
+	}
+	conn.Close()
+	return err
+}
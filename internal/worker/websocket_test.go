@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/config"
+	"github.com/jvreagan/perf-test/internal/data"
+	"github.com/jvreagan/perf-test/internal/websocket"
+)
+
+// wsServer echoes messages upper-cased. "ignore" gets no reply, and "drop"
+// drops the connection.
+func wsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			switch {
+			case bytes.HasPrefix(data, []byte("ignore")):
+				continue
+			case bytes.HasPrefix(data, []byte("drop")):
+				return
+			}
+			conn.WriteMessage(op, bytes.ToUpper(data))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func wsEndpoint(url string, ws *config.WebSocketConfig) config.Endpoint {
+	if ws.Timeout.Duration == 0 {
+		ws.Timeout = config.Duration{Duration: time.Second}
+	}
+	return config.Endpoint{Name: "ws", Type: "websocket", URL: url, WebSocket: ws}
+}
+
+func TestExecutor_Execute_WebSocket(t *testing.T) {
+	srv := wsServer(t)
+	ep := wsEndpoint("ws"+strings.TrimPrefix(srv.URL, "http"), &config.WebSocketConfig{
+		Send:     `{"id":${n}}`,
+		Interval: config.Duration{Duration: 20 * time.Millisecond},
+		Expect:   `"ID":7`,
+		Hold:     config.Duration{Duration: 90 * time.Millisecond},
+	})
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(map[string]string{"n": "7"}), srv.Client())
+
+	r := exec.Execute(context.Background(), ep)
+	if !r.Success {
+		t.Fatalf("session failed: %v", r.Error)
+	}
+	s := r.Session
+	if r.StatusCode != http.StatusSwitchingProtocols || r.ConnsOpened != 1 || s.Connect != r.Duration {
+		t.Errorf("status = %d, opened = %d, connect = %v", r.StatusCode, r.ConnsOpened, s.Connect)
+	}
+	if s.Sent < 3 || s.Received != s.Sent || len(s.RTTs) != s.Sent {
+		t.Errorf("sent %d, received %d, %d rtts", s.Sent, s.Received, len(s.RTTs))
+	}
+	if s.AbnormalClose {
+		t.Error("orderly close reported as abnormal")
+	}
+}
+
+func TestExecutor_Execute_WebSocketFailures(t *testing.T) {
+	srv := wsServer(t)
+	tests := []struct {
+		name     string
+		send     string
+		wantErr  string
+		abnormal bool
+	}{
+		{"timeout", "ignore", "no reply matching", false},
+		{"dropped", "drop", "code 1006", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := wsEndpoint(srv.URL, &config.WebSocketConfig{
+				Send:    tt.send,
+				Expect:  "never",
+				Timeout: config.Duration{Duration: 50 * time.Millisecond},
+			})
+			exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), srv.Client())
+			r := exec.Execute(context.Background(), ep)
+			if r.Success || r.Error == nil || !strings.Contains(r.Error.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", r.Error, tt.wantErr)
+			}
+			if r.Session.AbnormalClose != tt.abnormal {
+				t.Errorf("abnormal close = %v, want %v", r.Session.AbnormalClose, tt.abnormal)
+			}
+		})
+	}
+}
+
+func TestExecutor_Execute_WebSocketRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	ep := wsEndpoint(srv.URL, &config.WebSocketConfig{})
+	exec := NewExecutor([]config.Endpoint{ep}, data.NewGenerator(nil), srv.Client())
+	r := exec.Execute(context.Background(), ep)
+	if r.Success || r.StatusCode != http.StatusUnauthorized {
+		t.Errorf("success = %v, status = %d, err = %v", r.Success, r.StatusCode, r.Error)
+	}
+}
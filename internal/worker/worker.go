@@ -2,8 +2,11 @@ package worker
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jvreagan/perf-test/internal/config"
 	"github.com/jvreagan/perf-test/internal/metrics"
 	"github.com/jvreagan/perf-test/internal/ratelimit"
 )
@@ -15,40 +18,91 @@ type Worker struct {
 	resultCh  chan<- metrics.Result
 	thinkTime time.Duration
 	limiter   *ratelimit.Limiter // nil = no rate limiting
+	gate      *Gate              // nil = never paused
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	step     int // position in the flow, when the executor runs one
+
+	drained     atomic.Int64 // requests that finished after Stop was called
+	interrupted atomic.Int64 // requests aborted by ctx cancellation
 }
 
-// New creates a Worker that delegates execution to exec and optionally rate-limits via limiter.
-func New(id int, exec *Executor, resultCh chan<- metrics.Result, thinkTime time.Duration, limiter *ratelimit.Limiter) *Worker {
+// New creates a Worker that delegates execution to exec, optionally rate-limits
+// via limiter, and idles while gate is paused.
+func New(id int, exec *Executor, resultCh chan<- metrics.Result, thinkTime time.Duration, limiter *ratelimit.Limiter, gate *Gate) *Worker {
 	return &Worker{
 		id:        id,
 		exec:      exec,
 		resultCh:  resultCh,
 		thinkTime: thinkTime,
 		limiter:   limiter,
+		gate:      gate,
+		stop:      make(chan struct{}),
 	}
 }
 
-// Run executes requests in a loop until ctx is cancelled.
+// Stop asks the worker to exit after its in-flight request, if any, completes.
+// Cancelling the ctx passed to Run remains the hard stop. Safe to call more than once.
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// Drained returns how many in-flight requests completed after Stop was called.
+func (w *Worker) Drained() int64 {
+	return w.drained.Load()
+}
+
+// Interrupted returns how many in-flight requests were aborted by ctx cancellation.
+func (w *Worker) Interrupted() int64 {
+	return w.interrupted.Load()
+}
+
+// Run executes requests in a loop until Stop is called or ctx is cancelled.
+// Requests run on ctx, so a request in flight when Stop is called is allowed
+// to finish and its result is still reported.
 func (w *Worker) Run(ctx context.Context) {
+	// soft ends the loop between requests; ctx aborts the request itself.
+	soft, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stop:
+			cancel()
+		case <-soft.Done():
+		}
+	}()
+
 	for {
 		select {
-		case <-ctx.Done():
+		case <-soft.Done():
 			return
 		default:
 		}
 
-		// Acquire rate-limit token before dispatching (nil-safe no-op when no limiter).
-		if !w.limiter.Wait(ctx) {
+		// Idle while paused, then acquire a rate-limit token before dispatching
+		// (both nil-safe no-ops when unset).
+		if !w.gate.Wait(soft) {
+			return
+		}
+		if !w.limiter.Wait(soft) {
 			return
 		}
 
-		ep := w.exec.SelectEndpoint()
+		ep := w.nextEndpoint()
 		result := w.exec.Execute(ctx, ep)
 
-		// Discard results caused by context cancellation (shutdown artifacts).
+		// Discard results caused by context cancellation (shutdown artifacts),
+		// but count them so the report can show what was cut off.
 		if result.Error != nil && ctx.Err() != nil {
+			w.interrupted.Add(1)
 			return
 		}
+		select {
+		case <-w.stop:
+			w.drained.Add(1)
+		default:
+		}
 
 		select {
 		case w.resultCh <- result:
@@ -56,12 +110,27 @@ func (w *Worker) Run(ctx context.Context) {
 			return
 		}
 
-		if w.thinkTime > 0 {
+		think := w.thinkTime
+		if ep.ThinkTime.Duration > 0 {
+			think = ep.ThinkTime.Duration
+		}
+		if think > 0 {
 			select {
-			case <-time.After(w.thinkTime):
-			case <-ctx.Done():
+			case <-time.After(think):
+			case <-soft.Done():
 				return
 			}
 		}
 	}
 }
+
+// nextEndpoint returns the next step of the flow, or a weighted random pick
+// when the executor doesn't run a flow.
+func (w *Worker) nextEndpoint() config.Endpoint {
+	if !w.exec.Flow() {
+		return w.exec.SelectEndpoint()
+	}
+	ep := w.exec.EndpointAt(w.step)
+	w.step++
+	return ep
+}
@@ -25,7 +25,7 @@ func makeEndpoint(name, method, url string, weight, status int) config.Endpoint
 
 func newWorker(id int, eps []config.Endpoint, gen *data.Generator, client *http.Client, resultCh chan<- metrics.Result, thinkTime time.Duration) *Worker {
 	exec := NewExecutor(eps, gen, client)
-	return New(id, exec, resultCh, thinkTime, nil)
+	return New(id, exec, resultCh, thinkTime, nil, nil)
 }
 
 func TestWorker_BasicRequest(t *testing.T) {
@@ -219,7 +219,7 @@ func TestWorker_WithLimiter_LimitsRate(t *testing.T) {
 
 	// Limit to 10 RPS; run for ~500ms → expect ~5 requests (allow 2–12 range)
 	limiter := ratelimit.NewLimiter(ctx, 10)
-	w := New(1, exec, resultCh, 0, limiter)
+	w := New(1, exec, resultCh, 0, limiter, nil)
 
 	runCtx, runCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer runCancel()
@@ -230,3 +230,138 @@ func TestWorker_WithLimiter_LimitsRate(t *testing.T) {
 		t.Errorf("limiter not working: expected 2–12 requests in 500ms at 10 RPS, got %d", count)
 	}
 }
+
+func TestWorker_GatePausesDispatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	resultCh := make(chan metrics.Result, 10000)
+	gen := data.NewGenerator(nil)
+	ep := makeEndpoint("test", "GET", srv.URL, 1, 200)
+	exec := NewExecutor([]config.Endpoint{ep}, gen, srv.Client())
+
+	gate := NewGate()
+	gate.Pause()
+	w := New(1, exec, resultCh, 0, nil, gate)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		if len(resultCh) != 0 {
+			t.Errorf("expected no requests while paused, got %d", len(resultCh))
+		}
+		gate.Resume()
+	}()
+	w.Run(ctx)
+
+	if len(resultCh) == 0 {
+		t.Error("expected requests after resume")
+	}
+}
+
+func TestWorker_StopDrainsInFlight(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	resultCh := make(chan metrics.Result, 10)
+	gen := data.NewGenerator(nil)
+	ep := makeEndpoint("slow", "GET", srv.URL, 1, 200)
+	w := newWorker(1, []config.Endpoint{ep}, gen, srv.Client(), resultCh, 0)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		w.Stop()
+	}()
+	w.Run(context.Background())
+
+	if len(resultCh) != 1 {
+		t.Fatalf("expected the in-flight request to be reported, got %d results", len(resultCh))
+	}
+	if r := <-resultCh; !r.Success {
+		t.Errorf("expected drained request to succeed, got %v", r.Error)
+	}
+	if w.Drained() != 1 || w.Interrupted() != 0 {
+		t.Errorf("expected drained=1 interrupted=0, got %d/%d", w.Drained(), w.Interrupted())
+	}
+}
+
+func TestWorker_CancelCountsInterrupted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	resultCh := make(chan metrics.Result, 10)
+	gen := data.NewGenerator(nil)
+	ep := makeEndpoint("slow", "GET", srv.URL, 1, 200)
+	w := newWorker(1, []config.Endpoint{ep}, gen, srv.Client(), resultCh, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+
+	if len(resultCh) != 0 {
+		t.Errorf("expected interrupted request to be discarded, got %d results", len(resultCh))
+	}
+	if w.Interrupted() != 1 {
+		t.Errorf("expected interrupted=1, got %d", w.Interrupted())
+	}
+}
+
+func TestWorker_FlowRunsEndpointsInOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	eps := []config.Endpoint{
+		makeEndpoint("login", "POST", srv.URL+"/login", 1, 200),
+		makeEndpoint("browse", "GET", srv.URL+"/items", 10, 200),
+		makeEndpoint("logout", "POST", srv.URL+"/logout", 1, 200),
+	}
+	resultCh := make(chan metrics.Result, 100)
+	exec := NewExecutor(eps, data.NewGenerator(nil), srv.Client())
+	exec.SetFlow(true)
+	w := New(1, exec, resultCh, 0, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+
+	if len(resultCh) < 6 {
+		t.Fatalf("expected at least two passes through the flow, got %d results", len(resultCh))
+	}
+	for i := 0; i < 6; i++ {
+		r := <-resultCh
+		if want := eps[i%3].Name; r.EndpointName != want {
+			t.Errorf("result %d: expected %s, got %s", i, want, r.EndpointName)
+		}
+	}
+}
+
+func TestWorker_EndpointThinkTimeOverrides(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	ep := makeEndpoint("slow-reader", "GET", srv.URL, 1, 200)
+	ep.ThinkTime = config.Duration{Duration: 300 * time.Millisecond}
+	resultCh := make(chan metrics.Result, 100)
+	w := newWorker(1, []config.Endpoint{ep}, data.NewGenerator(nil), srv.Client(), resultCh, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+
+	if n := len(resultCh); n != 2 {
+		t.Errorf("expected the endpoint's 300ms think time to allow 2 requests in 500ms, got %d", n)
+	}
+}
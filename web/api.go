@@ -0,0 +1,103 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jvreagan/perf-test/internal/engine"
+	"github.com/jvreagan/perf-test/internal/metrics"
+)
+
+// apiTest is the JSON representation of a test run.
+type apiTest struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	Status     string              `json:"status"`
+	StartedAt  time.Time           `json:"started_at"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	Control    engine.ControlState `json:"control"`
+	Stats      *metrics.Stats      `json:"stats,omitempty"`
+}
+
+func newAPITest(tr *TestRun, withStats bool) apiTest {
+	t := apiTest{
+		ID:        tr.ID,
+		Name:      tr.Config.Name,
+		Status:    tr.Status,
+		StartedAt: tr.StartedAt,
+		Control:   tr.Engine.ControlState(),
+	}
+	if !tr.FinishedAt.IsZero() {
+		finished := tr.FinishedAt
+		t.FinishedAt = &finished
+	}
+	if tr.Error != nil {
+		t.Error = tr.Error.Error()
+	}
+	if withStats {
+		if tr.Status == "running" {
+			if c := tr.Engine.Collector(); c != nil {
+				t.Stats = c.Snapshot()
+			}
+		} else {
+			t.Stats = tr.FinalStats
+		}
+	}
+	return t
+}
+
+func (h *Handlers) handleAPIList(w http.ResponseWriter, r *http.Request) {
+	tests := []apiTest{}
+	if active := h.state.ActiveTest(); active != nil {
+		tests = append(tests, newAPITest(active, false))
+	}
+	for _, tr := range h.state.RecentTests(20) {
+		tests = append(tests, newAPITest(tr, false))
+	}
+	writeJSON(w, http.StatusOK, tests)
+}
+
+func (h *Handlers) handleAPIGet(w http.ResponseWriter, r *http.Request) {
+	tr := h.state.GetTest(r.PathValue("id"))
+	if tr == nil {
+		writeJSONError(w, http.StatusNotFound, "test not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, newAPITest(tr, true))
+}
+
+func (h *Handlers) handleAPIControl(w http.ResponseWriter, r *http.Request) {
+	tr := h.state.GetTest(r.PathValue("id"))
+	if tr == nil {
+		writeJSONError(w, http.StatusNotFound, "test not found")
+		return
+	}
+	var cmd engine.Command
+	if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	cmd.Source = "api"
+	if err := tr.Engine.Control(cmd); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, engine.ErrNotRunning) {
+			status = http.StatusConflict
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, tr.Engine.ControlState())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIGet_NotFound(t *testing.T) {
+	h, _ := setupTestServer(t)
+	req := httptest.NewRequest("GET", "/api/tests/nope", nil)
+	req.SetPathValue("id", "nope")
+	w := httptest.NewRecorder()
+	h.handleAPIGet(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestAPIControl_TargetOverride(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer target.Close()
+
+	h, state := setupTestServer(t)
+	run := startLongTest(t, state, target.URL)
+	defer state.StopTest(run.ID)
+
+	req := httptest.NewRequest("POST", "/api/tests/"+run.ID+"/control", strings.NewReader(`{"action":"target","value":"3"}`))
+	req.SetPathValue("id", run.ID)
+	w := httptest.NewRecorder()
+	h.handleAPIControl(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var st map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &st); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if st["override"] != true {
+		t.Errorf("expected override=true, got %v", st)
+	}
+
+	req = httptest.NewRequest("GET", "/api/tests/"+run.ID, nil)
+	req.SetPathValue("id", run.ID)
+	w = httptest.NewRecorder()
+	h.handleAPIGet(w, req)
+	var got apiTest
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Status != "running" || got.Stats == nil || len(got.Stats.Interventions) != 1 {
+		t.Errorf("expected running test with one intervention, got %+v", got)
+	}
+	if iv := got.Stats.Interventions[0]; iv.Source != "api" {
+		t.Errorf("expected intervention source api, got %q", iv.Source)
+	}
+}
+
+func TestAPIControl_BadRequest(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer target.Close()
+
+	h, state := setupTestServer(t)
+	run := startLongTest(t, state, target.URL)
+	defer state.StopTest(run.ID)
+
+	for _, body := range []string{`not json`, `{"action":"explode"}`} {
+		req := httptest.NewRequest("POST", "/api/tests/"+run.ID+"/control", strings.NewReader(body))
+		req.SetPathValue("id", run.ID)
+		w := httptest.NewRecorder()
+		h.handleAPIControl(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %q: expected 400, got %d", body, w.Code)
+		}
+	}
+}
@@ -20,6 +20,8 @@ type FormData struct {
 	LoadStyle string // "shorthand" or "stages"
 	ThinkTime string
 	MaxRPS    string
+	// GracefulStop is how long in-flight requests may drain when the test stops.
+	GracefulStop string
 
 	// Shorthand fields
 	RampUp      string
@@ -113,6 +115,7 @@ func ParseFormData(r *http.Request) *FormData {
 		LoadStyle:   r.FormValue("load_style"),
 		ThinkTime:   r.FormValue("think_time"),
 		MaxRPS:      r.FormValue("max_rps"),
+		GracefulStop: r.FormValue("graceful_stop"),
 		RampUp:      r.FormValue("ramp_up"),
 		SteadyState: r.FormValue("steady_state"),
 		RampDown:    r.FormValue("ramp_down"),
@@ -205,6 +208,13 @@ func (fd *FormData) ToConfig() (*config.Config, error) {
 		}
 		cfg.Load.ThinkTime = config.Duration{Duration: d}
 	}
+	if fd.GracefulStop != "" {
+		d, err := time.ParseDuration(fd.GracefulStop)
+		if err != nil {
+			return nil, fmt.Errorf("invalid graceful stop %q: %w", fd.GracefulStop, err)
+		}
+		cfg.Load.GracefulStop = config.Duration{Duration: d}
+	}
 
 	if fd.MaxRPS != "" {
 		f, err := strconv.ParseFloat(fd.MaxRPS, 64)
@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultFormData(t *testing.T) {
@@ -355,3 +356,21 @@ func TestTargetLabel(t *testing.T) {
 		t.Errorf("expected RPS, got %q", got)
 	}
 }
+
+func TestToConfig_GracefulStop(t *testing.T) {
+	fd := DefaultFormData()
+	fd.Endpoints[0].URL = "http://localhost/health"
+	fd.GracefulStop = "15s"
+	cfg, err := fd.ToConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Load.GracefulStop.Duration != 15*time.Second {
+		t.Errorf("expected graceful stop 15s, got %v", cfg.Load.GracefulStop.Duration)
+	}
+
+	fd.GracefulStop = "soon"
+	if _, err := fd.ToConfig(); err == nil || !strings.Contains(err.Error(), "graceful stop") {
+		t.Errorf("expected graceful stop parse error, got %v", err)
+	}
+}
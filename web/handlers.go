@@ -3,10 +3,12 @@ package web
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/jvreagan/perf-test/internal/engine"
 	"github.com/jvreagan/perf-test/internal/metrics"
 )
 
@@ -176,11 +178,23 @@ func (h *Handlers) handleTestStatus(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		control := tr.Engine.ControlState()
+		if control.Total > 0 {
+			// Use schedule time so pauses, skips and extensions show up in progress.
+			totalDur = control.Total
+			pct = float64(control.Elapsed) / float64(control.Total) * 100
+			if pct > 100 {
+				pct = 100
+			}
+		}
+
 		data := map[string]interface{}{
 			"TestRun":       tr,
 			"Stats":         stats,
 			"TotalDuration": totalDur,
 			"ProgressPct":   fmt.Sprintf("%.0f", pct),
+			"Control":       control,
+			"ControlError":  r.URL.Query().Get("control_error"),
 		}
 		h.render(w, "running.html", data)
 		return
@@ -202,6 +216,25 @@ func (h *Handlers) handleTestStop(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/test/"+id, http.StatusSeeOther)
 }
 
+func (h *Handlers) handleTestControl(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	tr := h.state.GetTest(id)
+	if tr == nil {
+		http.NotFound(w, r)
+		return
+	}
+	cmd := engine.Command{
+		Action: r.FormValue("action"),
+		Value:  strings.TrimSpace(r.FormValue("value")),
+		Source: "web",
+	}
+	location := "/test/" + id
+	if err := tr.Engine.Control(cmd); err != nil {
+		location += "?control_error=" + url.QueryEscape(err.Error())
+	}
+	http.Redirect(w, r, location, http.StatusSeeOther)
+}
+
 func (h *Handlers) renderConfigure(w http.ResponseWriter, fd *FormData) {
 	data := map[string]interface{}{
 		"FormData": fd,
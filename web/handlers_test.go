@@ -426,3 +426,77 @@ func TestPostConfigure_RunWhileAlreadyRunning(t *testing.T) {
 		t.Error("expected 'already running' error message")
 	}
 }
+
+// startLongTest starts a 30s single-VU test against target and waits until
+// its engine accepts control commands.
+func startLongTest(t *testing.T, state *State, targetURL string) *TestRun {
+	t.Helper()
+	cfg := &config.Config{
+		Name: "long-test",
+		Load: config.LoadConfig{
+			Mode: "vu",
+			Stages: []config.Stage{
+				{Duration: config.Duration{Duration: 30 * time.Second}, Target: 1},
+			},
+		},
+		HTTP:   config.HTTPConfig{Timeout: config.Duration{Duration: 5 * time.Second}},
+		Output: config.OutputConfig{Format: "console", Interval: config.Duration{Duration: 5 * time.Second}},
+		Endpoints: []config.Endpoint{
+			{Name: "health", Method: "GET", URL: targetURL, Weight: 1, Expect: config.ExpectConfig{Status: 200}},
+		},
+	}
+	run := state.StartTest(cfg)
+	if run == nil {
+		t.Fatal("failed to start test")
+	}
+	for i := 0; i < 100 && !run.Engine.ControlState().Running; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return run
+}
+
+func TestPostTestControl_PauseAndResume(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer target.Close()
+
+	h, state := setupTestServer(t)
+	run := startLongTest(t, state, target.URL)
+	defer state.StopTest(run.ID)
+
+	post := func(vals url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/test/"+run.ID+"/control", strings.NewReader(vals.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", run.ID)
+		w := httptest.NewRecorder()
+		h.handleTestControl(w, req)
+		return w
+	}
+
+	w := post(url.Values{"action": {"pause"}})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/test/"+run.ID {
+		t.Fatalf("expected clean redirect, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if !run.Engine.ControlState().Paused {
+		t.Error("expected test to be paused")
+	}
+
+	req := httptest.NewRequest("GET", "/test/"+run.ID, nil)
+	req.SetPathValue("id", run.ID)
+	rec := httptest.NewRecorder()
+	h.handleTestStatus(rec, req)
+	if body := rec.Body.String(); !strings.Contains(body, "Resume") || !strings.Contains(body, "paused") {
+		t.Error("expected running page to offer Resume while paused")
+	}
+
+	w = post(url.Values{"action": {"extend"}, "value": {"not-a-duration"}})
+	if loc := w.Header().Get("Location"); !strings.Contains(loc, "control_error=") {
+		t.Errorf("expected control error in redirect, got %q", loc)
+	}
+
+	post(url.Values{"action": {"resume"}})
+	if run.Engine.ControlState().Paused {
+		t.Error("expected test to be resumed")
+	}
+}
@@ -15,6 +15,11 @@ func NewServer(addr string, state *State, templates *Templates) *http.Server {
 	mux.HandleFunc("POST /configure", h.handleConfigurePost)
 	mux.HandleFunc("GET /test/{id}", h.handleTestStatus)
 	mux.HandleFunc("GET /test/{id}/stop", h.handleTestStop)
+	mux.HandleFunc("POST /test/{id}/control", h.handleTestControl)
+
+	mux.HandleFunc("GET /api/tests", h.handleAPIList)
+	mux.HandleFunc("GET /api/tests/{id}", h.handleAPIGet)
+	mux.HandleFunc("POST /api/tests/{id}/control", h.handleAPIControl)
 
 	return &http.Server{
 		Addr:    addr,